@@ -1,3 +1,5 @@
+// Code generated by arkegen from specs/protocol.json. DO NOT EDIT.
+
 #pragma once
 #include "inttypes.h"
 
@@ -14,12 +16,12 @@ typedef enum ArkeMessageType_e {
 } ArkeMessageType;
 
 typedef enum ArkeNodeClass_e {
-	ARKE_BROADCAST       = 0x0,
+	ARKE_BROADCAST       = 0x00,
 	ARKE_ZEUS            = 0x38,
 	ARKE_HELIOS          = 0x34,
 	ARKE_CELAENO         = 0x30,
 	ARKE_NOTUS           = 0x2c,
-	ARKE_NODE_CLASS_MASK = 0x3f << 3
+	ARKE_NODE_CLASS_MASK = 0x3f << 3,
 } ArkeNodeClass;
 
 typedef enum ArkeNetworkCommand_e {
@@ -28,7 +30,7 @@ typedef enum ArkeNetworkCommand_e {
 	ARKE_ID_CHANGE_REQUEST = 0x02,
 	ARKE_ERROR_REPORT      = 0x03,
 	ARKE_HEARTBEAT_REQUEST = 0x07,
-	ARKE_SUBID_MASK        = 0x07
+	ARKE_SUBID_MASK        = 0x07,
 } ArkeNetworkCommand;
 
 //#define ARKE_SUBID_MASK ARKE_HEARTBEAT_REQUEST
@@ -51,6 +53,51 @@ typedef enum ArkeMessageClass_e {
 	ARKE_NOTUS_CONFIG           = 0x2d,
 } ArkeMessageClass;
 
+#define ARKE_FAN_AGING_ALERT (0x4000)
+#define ARKE_FAN_STALL_ALERT (0x8000)
+#define ARKE_FAN_RPM_MASK    (0x3fff)
+
+#define ArkeFanAging(status)                                                   \
+	(((status).fanStatus & ARKE_FAN_AGING_ALERT) != 0x0000)
+#define ArkeFanStall(status)                                                   \
+	(((status).fanStatus & ARKE_FAN_STALL_ALERT) != 0x0000)
+#define ArkeFanRPM(status) ((status).fanStatus & ARKE_FAN_RPM_MASK)
+
+typedef uint16_t ArkeFanStatus;
+
+typedef enum ArkeZeusStatus_e {
+	ARKE_ZEUS_IDLE                    = 0,
+	ARKE_ZEUS_ACTIVE                  = (1 << 0),
+	ARKE_ZEUS_CLIMATE_UNCONTROLLED_WD = (1 << 1),
+	ARKE_ZEUS_HUMIDITY_UNREACHABLE    = (1 << 2),
+	ARKE_ZEUS_TEMPERATURE_UNREACHABLE = (1 << 3),
+} ArkeZeusStatus_e;
+
+typedef enum ArkeCelaenoWaterLevel_e {
+	ARKE_CELAENO_NOMINAL  = 0,
+	ARKE_CELAENO_WARNING  = (1 << 0),
+	ARKE_CELAENO_CRITICAL = (1 << 1),
+	ARKE_CELAENO_RO_ERROR = (1 << 2)
+} ArkeCelaenoWaterLevel;
+
+#define ArkeCelaenoWaterNominal(status) ((status).waterLevel == 0)
+#define ArkeCelaenoWaterWarning(status)                                        \
+	((status).waterLevel == ARKE_CELAENO_WARNING)
+#define ArkeCelaenoWaterCritical(status)                                       \
+	(((status).waterLevel & ~(ARKE_CELAENO_CRITICAL | ARKE_CELAENO_RO_ERROR)   \
+	 ) == ARKE_CELAENO_WARNING)
+#define ArkeCelaenoWaterHasRoError(status)                                     \
+	(((status).waterLevel & ARKE_CELAENO_RO_ERROR) != 0x00)
+
+struct ArkePIDConfig_t {
+	uint8_t ProportionalMult;
+	uint8_t DerivativeMult;
+	uint8_t IntegralMult;
+	uint8_t DividerPower : 4;
+	uint8_t DividerPowerInt : 4;
+} __attribute__((packed));
+typedef struct ArkePIDConfig_t ArkePIDConfig;
+
 struct ArkeZeusSetPoint_t {
 	uint16_t Humidity;
 	uint16_t Temperature;
@@ -67,43 +114,12 @@ struct ArkeZeusReport_t {
 } __attribute__((packed));
 typedef struct ArkeZeusReport_t ArkeZeusReport;
 
-struct ArkePIDConfig_t {
-	uint8_t ProportionalMult;
-	uint8_t DerivativeMult;
-	uint8_t IntegralMult;
-	uint8_t DividerPower : 4;
-	uint8_t DividerPowerInt : 4;
-} __attribute__((packed));
-
-typedef struct ArkePIDConfig_t ArkePIDConfig;
-
 struct ArkeZeusConfig_t {
 	ArkePIDConfig Humidity;
 	ArkePIDConfig Temperature;
 } __attribute__((packed));
-
 typedef struct ArkeZeusConfig_t ArkeZeusConfig;
 
-#define ARKE_FAN_AGING_ALERT (0x4000)
-#define ARKE_FAN_STALL_ALERT (0x8000)
-#define ARKE_FAN_RPM_MASK    (0x3fff)
-
-#define ArkeFanAging(status)                                                   \
-	(((status).fanStatus & ARKE_FAN_AGING_ALERT) != 0x0000)
-#define ArkeFanStall(status)                                                   \
-	(((status).fanStatus & ARKE_FAN_STALL_ALERT) != 0x0000)
-#define ArkeFanRPM(status) ((status).fanStatus & ARKE_FAN_RPM_MASK)
-
-typedef uint16_t ArkeFanStatus;
-
-typedef enum ArkeZeusStatus_e {
-	ARKE_ZEUS_IDLE                    = 0,
-	ARKE_ZEUS_ACTIVE                  = (1 << 0),
-	ARKE_ZEUS_CLIMATE_UNCONTROLLED_WD = (1 << 1),
-	ARKE_ZEUS_HUMIDITY_UNREACHABLE    = (1 << 2),
-	ARKE_ZEUS_TEMPERATURE_UNREACHABLE = (1 << 3),
-} ArkeZeusStatus_e;
-
 struct ArkeZeusStatus_t {
 	ArkeZeusStatus_e Status;
 	ArkeFanStatus    Fan[3];
@@ -127,11 +143,17 @@ struct ArkeHeliosSetPoint_t {
 } __attribute__((packed));
 typedef struct ArkeHeliosSetPoint_t ArkeHeliosSetPoint;
 
+struct ArkeHeliosPulseMode_t {
+	uint16_t Period_ms;
+} __attribute__((packed));
+typedef struct ArkeHeliosPulseMode_t ArkeHeliosPulseMode;
+
 struct ArkeHeliosTriggerConfig_t {
 	uint16_t Period_hecto_us;
 	uint16_t Pulse_us;
 	int16_t  CameraDelay_us;
 } __attribute__((packed));
+typedef struct ArkeHeliosTriggerConfig_t ArkeHeliosTriggerConfig;
 
 struct ArkeCelaenoSetPoint_t {
 	uint8_t Power;
@@ -144,40 +166,32 @@ struct ArkeCelaenoStatus_t {
 } __attribute__((packed));
 typedef struct ArkeCelaenoStatus_t ArkeCelaenoStatus;
 
-typedef enum ArkeCelaenoWaterLevel_e {
-	ARKE_CELAENO_NOMINAL  = 0,
-	ARKE_CELAENO_WARNING  = (1 << 0),
-	ARKE_CELAENO_CRITICAL = (1 << 1),
-	ARKE_CELAENO_RO_ERROR = (1 << 2)
-} ArkeCelaenoWaterLevel;
-
-#define ArkeCelaenoWaterNominal(status) ((status).waterLevel == 0)
-#define ArkeCelaenoWaterWarning(status)                                        \
-	((status).waterLevel == ARKE_CELAENO_WARNING)
-#define ArkeCelaenoWaterCritical(status)                                       \
-	(((status).waterLevel & ~(ARKE_CELAENO_CRITICAL | ARKE_CELAENO_RO_ERROR)   \
-	 ) == ARKE_CELAENO_WARNING)
-#define ArkeCelaenoWaterHasRoError(status)                                     \
-	(((status).waterLevel & ARKE_CELAENO_RO_ERROR) != 0x00)
-
 struct ArkeCelaenoConfig_t {
 	uint16_t RampUpTimeMS;
 	uint16_t RampDownTimeMS;
 	uint16_t MinOnTimeMS;
 	uint16_t DebounceTimeMS;
 } __attribute__((packed));
-
 typedef struct ArkeCelaenoConfig_t ArkeCelaenoConfig;
 
+struct ArkeNotusSetPoint_t {
+	uint8_t Power;
+} __attribute__((packed));
+typedef struct ArkeNotusSetPoint_t ArkeNotusSetPoint;
+
 struct ArkeNotusConfig_t {
 	uint16_t RampDownTimeMS;
-	uint8_t  MinFan, MaxHeat;
+	uint8_t  MinFan;
+	uint8_t  MaxHeat;
 } __attribute__((packed));
+typedef struct ArkeNotusConfig_t ArkeNotusConfig;
 
-struct ArkeNotusSetPoint_t {
-	uint8_t Power;
+struct ArkeSynchronisationReply_t {
+	uint8_t Sequence;
+	uint8_t ID;
+	uint8_t Timestamp_us[6];
 } __attribute__((packed));
-typedef struct ArkeNotusSetPoint_t ArkeNotusSetPoint;
+typedef struct ArkeSynchronisationReply_t ArkeSynchronisationReply;
 
 #ifdef __cplusplus
 }
@@ -0,0 +1,411 @@
+-- Code generated by arkewireshark. DO NOT EDIT.
+--
+-- Wireshark dissector for the Arke protocol over SocketCAN. Copy this
+-- file in the Wireshark personal Lua plugins folder, for example
+-- ~/.local/lib/wireshark/plugins/, and open a SocketCAN capture, such
+-- as the pcapng files written by arkedump.
+
+local arke = Proto("arke", "Arke Protocol")
+
+local message_types = {
+	[0] = "NetworkCommand",
+	[1] = "HighPriority",
+	[2] = "Standard",
+	[3] = "HeartBeat",
+}
+
+local node_classes = {
+	[0x00] = "Broadcast",
+	[0x2c] = "Notus",
+	[0x30] = "Celaeno",
+	[0x34] = "Helios",
+	[0x38] = "Zeus",
+}
+
+-- payload layouts of standard and high priority messages, by
+-- message class
+local messages = {
+	[0x2c] = {
+		name = "Notus.SetPoint",
+		size = 1,
+		fields = {
+			{ field = ProtoField.uint32("arke.notus.setpoint.power", "Power"), kind = "uint", offset = 0, size = 8, signed = false, resolution = 1, bias = 0 },
+		},
+	},
+	[0x2d] = {
+		name = "Notus.Config",
+		size = 4,
+		fields = {
+			{ field = ProtoField.uint32("arke.notus.config.rampdowntime", "RampDownTime [ms]"), kind = "uint", offset = 0, size = 16, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.notus.config.minfan", "MinFan"), kind = "uint", offset = 16, size = 8, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.notus.config.maxheat", "MaxHeat"), kind = "uint", offset = 24, size = 8, signed = false, resolution = 1, bias = 0 },
+		},
+	},
+	[0x30] = {
+		name = "Celaeno.SetPoint",
+		size = 1,
+		fields = {
+			{ field = ProtoField.uint32("arke.celaeno.setpoint.power", "Power"), kind = "uint", offset = 0, size = 8, signed = false, resolution = 1, bias = 0 },
+		},
+	},
+	[0x31] = {
+		name = "Celaeno.Status",
+		size = 3,
+		fields = {
+			{ field = ProtoField.uint32("arke.celaeno.status.waterlevel", "WaterLevel"), kind = "uint", offset = 0, size = 8, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint16("arke.celaeno.status.fan", "Fan", base.HEX), kind = "fan", offset = 8, size = 16, signed = false, resolution = 1, bias = 0 },
+		},
+	},
+	[0x32] = {
+		name = "Celaeno.Config",
+		size = 8,
+		fields = {
+			{ field = ProtoField.uint32("arke.celaeno.config.rampuptime", "RampUpTime [ms]"), kind = "uint", offset = 0, size = 16, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.celaeno.config.rampdowntime", "RampDownTime [ms]"), kind = "uint", offset = 16, size = 16, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.celaeno.config.minimumontime", "MinimumOnTime [ms]"), kind = "uint", offset = 32, size = 16, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.celaeno.config.debouncetime", "DebounceTime [ms]"), kind = "uint", offset = 48, size = 16, signed = false, resolution = 1, bias = 0 },
+		},
+	},
+	[0x34] = {
+		name = "Helios.SetPoint",
+		size = 2,
+		fields = {
+			{ field = ProtoField.uint32("arke.helios.setpoint.visible", "Visible"), kind = "uint", offset = 0, size = 8, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.helios.setpoint.uv", "UV"), kind = "uint", offset = 8, size = 8, signed = false, resolution = 1, bias = 0 },
+		},
+	},
+	[0x35] = {
+		name = "Helios.PulseMode",
+		size = 2,
+		fields = {
+			{ field = ProtoField.uint32("arke.helios.pulsemode.period", "Period [ms]"), kind = "uint", offset = 0, size = 16, signed = false, resolution = 1, bias = 0 },
+		},
+	},
+	[0x36] = {
+		name = "Helios.TriggerMode",
+		size = 6,
+		fields = {
+			{ field = ProtoField.double("arke.helios.triggermode.period", "Period [µs]"), kind = "double", offset = 0, size = 16, signed = false, resolution = 100, bias = 0 },
+			{ field = ProtoField.uint32("arke.helios.triggermode.pulselength", "PulseLength [µs]"), kind = "uint", offset = 16, size = 16, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.int32("arke.helios.triggermode.cameradelay", "CameraDelay [µs]"), kind = "int", offset = 32, size = 16, signed = true, resolution = 1, bias = 0 },
+		},
+	},
+	[0x38] = {
+		name = "Zeus.SetPoint",
+		size = 5,
+		fields = {
+			{ field = ProtoField.double("arke.zeus.setpoint.humidity", "Humidity [%]"), kind = "double", offset = 0, size = 16, signed = false, resolution = 0.0061042607740202665, bias = 0 },
+			{ field = ProtoField.double("arke.zeus.setpoint.temperature", "Temperature [°C]"), kind = "double", offset = 16, size = 16, signed = false, resolution = 0.010072030277133439, bias = -40 },
+			{ field = ProtoField.uint32("arke.zeus.setpoint.wind", "Wind"), kind = "uint", offset = 32, size = 8, signed = false, resolution = 1, bias = 0 },
+		},
+	},
+	[0x39] = {
+		name = "Zeus.Report",
+		size = 8,
+		fields = {
+			{ field = ProtoField.double("arke.zeus.report.humidity", "Humidity [%]"), kind = "double", offset = 0, size = 14, signed = false, resolution = 0.0061042607740202665, bias = 0 },
+			{ field = ProtoField.double("arke.zeus.report.temperature_0", "Temperature[0] [°C]"), kind = "double", offset = 14, size = 14, signed = false, resolution = 0.010072030277133439, bias = -40 },
+			{ field = ProtoField.double("arke.zeus.report.temperature_1", "Temperature[1] [°C]"), kind = "double", offset = 28, size = 12, signed = true, resolution = 0.0625, bias = 0 },
+			{ field = ProtoField.double("arke.zeus.report.temperature_2", "Temperature[2] [°C]"), kind = "double", offset = 40, size = 12, signed = true, resolution = 0.0625, bias = 0 },
+			{ field = ProtoField.double("arke.zeus.report.temperature_3", "Temperature[3] [°C]"), kind = "double", offset = 52, size = 12, signed = true, resolution = 0.0625, bias = 0 },
+		},
+	},
+	[0x3a] = {
+		name = "Zeus.VibrationReport",
+		size = 0,
+		fields = {
+		},
+	},
+	[0x3b] = {
+		name = "Zeus.Config",
+		size = 8,
+		fields = {
+			{ field = ProtoField.uint32("arke.zeus.config.humidity.proportionnalmultiplier", "Humidity.ProportionnalMultiplier"), kind = "uint", offset = 0, size = 8, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.zeus.config.humidity.derivativemultiplier", "Humidity.DerivativeMultiplier"), kind = "uint", offset = 8, size = 8, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.zeus.config.humidity.integralmultiplier", "Humidity.IntegralMultiplier"), kind = "uint", offset = 16, size = 8, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.zeus.config.humidity.dividerpower", "Humidity.DividerPower"), kind = "uint", offset = 24, size = 4, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.zeus.config.humidity.dividerpowerintegral", "Humidity.DividerPowerIntegral"), kind = "uint", offset = 28, size = 4, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.zeus.config.temperature.proportionnalmultiplier", "Temperature.ProportionnalMultiplier"), kind = "uint", offset = 32, size = 8, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.zeus.config.temperature.derivativemultiplier", "Temperature.DerivativeMultiplier"), kind = "uint", offset = 40, size = 8, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.zeus.config.temperature.integralmultiplier", "Temperature.IntegralMultiplier"), kind = "uint", offset = 48, size = 8, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.zeus.config.temperature.dividerpower", "Temperature.DividerPower"), kind = "uint", offset = 56, size = 4, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint32("arke.zeus.config.temperature.dividerpowerintegral", "Temperature.DividerPowerIntegral"), kind = "uint", offset = 60, size = 4, signed = false, resolution = 1, bias = 0 },
+		},
+	},
+	[0x3c] = {
+		name = "Zeus.Status",
+		size = 7,
+		fields = {
+			{ field = ProtoField.uint32("arke.zeus.status.status", "Status"), kind = "uint", offset = 0, size = 8, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint16("arke.zeus.status.fans_0", "Fans[0]", base.HEX), kind = "fan", offset = 8, size = 16, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint16("arke.zeus.status.fans_1", "Fans[1]", base.HEX), kind = "fan", offset = 24, size = 16, signed = false, resolution = 1, bias = 0 },
+			{ field = ProtoField.uint16("arke.zeus.status.fans_2", "Fans[2]", base.HEX), kind = "fan", offset = 40, size = 16, signed = false, resolution = 1, bias = 0 },
+		},
+	},
+	[0x3d] = {
+		name = "Zeus.ControlPoint",
+		size = 4,
+		fields = {
+			{ field = ProtoField.int32("arke.zeus.controlpoint.humidity", "Humidity"), kind = "int", offset = 0, size = 16, signed = true, resolution = 1, bias = 0 },
+			{ field = ProtoField.int32("arke.zeus.controlpoint.temperature", "Temperature"), kind = "int", offset = 16, size = 16, signed = true, resolution = 1, bias = 0 },
+		},
+	},
+	[0x3e] = {
+		name = "Zeus.DeltaTemperature",
+		size = 8,
+		fields = {
+			{ field = ProtoField.double("arke.zeus.deltatemperature.delta_0", "Delta[0] [°C]"), kind = "double", offset = 0, size = 16, signed = true, resolution = 0.010072030277133439, bias = 0 },
+			{ field = ProtoField.double("arke.zeus.deltatemperature.delta_1", "Delta[1] [°C]"), kind = "double", offset = 16, size = 16, signed = true, resolution = 0.0625, bias = 0 },
+			{ field = ProtoField.double("arke.zeus.deltatemperature.delta_2", "Delta[2] [°C]"), kind = "double", offset = 32, size = 16, signed = true, resolution = 0.0625, bias = 0 },
+			{ field = ProtoField.double("arke.zeus.deltatemperature.delta_3", "Delta[3] [°C]"), kind = "double", offset = 48, size = 16, signed = true, resolution = 0.0625, bias = 0 },
+		},
+	},
+}
+
+-- payload layouts of network commands, by command. The first layout
+-- large enough for the payload is used.
+local commands = {
+	[0] = {
+		{
+			name = "arke.ResetRequest",
+			size = 1,
+			fields = {
+				{ field = ProtoField.uint32("arke.resetrequest.id", "ID"), kind = "uint", offset = 0, size = 8, signed = false, resolution = 1, bias = 0 },
+			},
+		},
+	},
+	[1] = {
+		{
+			name = "arke.SynchronisationRequest",
+			size = 1,
+			fields = {
+				{ field = ProtoField.uint32("arke.synchronisationrequest.sequence", "Sequence"), kind = "uint", offset = 0, size = 8, signed = false, resolution = 1, bias = 0 },
+			},
+		},
+		{
+			name = "arke.SynchronisationReply",
+			size = 8,
+			fields = {
+				{ field = ProtoField.uint32("arke.synchronisationreply.sequence", "Sequence"), kind = "uint", offset = 0, size = 8, signed = false, resolution = 1, bias = 0 },
+				{ field = ProtoField.uint32("arke.synchronisationreply.id", "ID"), kind = "uint", offset = 8, size = 8, signed = false, resolution = 1, bias = 0 },
+				{ field = ProtoField.uint64("arke.synchronisationreply.timestamp", "Timestamp [µs]"), kind = "uint64", offset = 16, size = 48, signed = false, resolution = 1, bias = 0 },
+			},
+		},
+	},
+	[2] = {
+		{
+			name = "arke.IDChangeRequest",
+			size = 2,
+			fields = {
+				{ field = ProtoField.uint32("arke.idchangerequest.old", "Old"), kind = "uint", offset = 0, size = 8, signed = false, resolution = 1, bias = 0 },
+				{ field = ProtoField.uint32("arke.idchangerequest.new", "New"), kind = "uint", offset = 8, size = 8, signed = false, resolution = 1, bias = 0 },
+			},
+		},
+	},
+	[3] = {
+		{
+			name = "arke.ErrorReport",
+			size = 4,
+			fields = {
+				{ field = ProtoField.uint8("arke.errorreport.class", "Class", base.HEX, node_classes), kind = "class", offset = 0, size = 8, signed = false, resolution = 1, bias = 0 },
+				{ field = ProtoField.uint32("arke.errorreport.id", "ID"), kind = "uint", offset = 8, size = 8, signed = false, resolution = 1, bias = 0 },
+				{ field = ProtoField.uint32("arke.errorreport.errorcode", "ErrorCode"), kind = "uint", offset = 16, size = 16, signed = false, resolution = 1, bias = 0 },
+			},
+		},
+	},
+	[7] = {
+		{
+			name = "arke.HeartBeatRequest",
+			size = 2,
+			fields = {
+				{ field = ProtoField.uint32("arke.heartbeatrequest.period", "Period [ms]"), kind = "uint", offset = 0, size = 16, signed = false, resolution = 1, bias = 0 },
+			},
+		},
+	},
+}
+
+local heartbeat = {
+	name = "arke.HeartBeat",
+	size = 4,
+	fields = {
+		{ field = ProtoField.uint32("arke.heartbeat.majorversion", "MajorVersion"), kind = "uint", offset = 0, size = 8, signed = false, resolution = 1, bias = 0 },
+		{ field = ProtoField.uint32("arke.heartbeat.minorversion", "MinorVersion"), kind = "uint", offset = 8, size = 8, signed = false, resolution = 1, bias = 0 },
+		{ field = ProtoField.uint32("arke.heartbeat.patchversion", "PatchVersion"), kind = "uint", offset = 16, size = 8, signed = false, resolution = 1, bias = 0 },
+		{ field = ProtoField.uint32("arke.heartbeat.tweakversion", "TweakVersion"), kind = "uint", offset = 24, size = 8, signed = false, resolution = 1, bias = 0 },
+	},
+}
+
+local pf = {
+	type = ProtoField.uint8("arke.type", "Type", base.DEC, message_types),
+	class = ProtoField.uint8("arke.class", "Class", base.HEX),
+	id = ProtoField.uint8("arke.id", "ID", base.DEC),
+	node = ProtoField.uint8("arke.node", "Node Class", base.HEX, node_classes),
+	message = ProtoField.string("arke.message", "Message"),
+	request = ProtoField.bool("arke.request", "Request"),
+	payload = ProtoField.bytes("arke.payload", "Payload"),
+	fan_rpm = ProtoField.uint16("arke.fan.rpm", "RPM", base.DEC, nil, 0x3fff),
+	fan_aging = ProtoField.bool("arke.fan.aging", "Aging", 16, nil, 0x4000),
+	fan_stalled = ProtoField.bool("arke.fan.stalled", "Stalled", 16, nil, 0x8000),
+}
+
+local fields = {}
+for _, f in pairs(pf) do
+	table.insert(fields, f)
+end
+local function register_layout(layout)
+	for _, f in ipairs(layout.fields) do
+		table.insert(fields, f.field)
+	end
+end
+for _, layout in pairs(messages) do
+	register_layout(layout)
+end
+for _, layouts in pairs(commands) do
+	for _, layout in ipairs(layouts) do
+		register_layout(layout)
+	end
+end
+register_layout(heartbeat)
+arke.fields = fields
+
+local can_id = Field.new("can.id")
+local can_rtr = Field.new("can.flags.rtr")
+local can_xtd = Field.new("can.flags.xtd")
+
+-- add_field decodes a little endian field of at most 64 bits starting
+-- at bit f.offset of the payload.
+local function add_field(tree, tvb, f)
+	local first = math.floor(f.offset / 8)
+	local last = math.floor((f.offset + f.size - 1) / 8)
+	local range = tvb(first, last - first + 1)
+	if f.kind == "fan" then
+		local item = tree:add_le(f.field, range)
+		item:add_le(pf.fan_rpm, range)
+		item:add_le(pf.fan_aging, range)
+		item:add_le(pf.fan_stalled, range)
+		return
+	end
+	local raw = range:le_uint64():rshift(f.offset % 8):band(UInt64.max():rshift(64 - f.size))
+	if f.kind == "uint64" then
+		tree:add(f.field, range, raw)
+		return
+	end
+	local value = raw:tonumber()
+	if f.signed and value >= 2 ^ (f.size - 1) then
+		value = value - 2 ^ f.size
+	end
+	if f.kind == "double" then
+		value = value * f.resolution + f.bias
+	end
+	tree:add(f.field, range, value)
+end
+
+local function add_payload(tree, tvb, layout)
+	if tvb:len() == 0 then
+		return
+	end
+	tree:add(pf.payload, tvb())
+	for _, f in ipairs(layout.fields) do
+		if f.offset + f.size <= tvb:len() * 8 then
+			add_field(tree, tvb, f)
+		end
+	end
+end
+
+-- node_of returns the class of the node handling a message class, i.e.
+-- the highest node class not greater than it.
+local function node_of(class)
+	local res = 0
+	for c, _ in pairs(node_classes) do
+		if c <= class and c > res then
+			res = c
+		end
+	end
+	return res
+end
+
+local function class_name(class)
+	return node_classes[class] or string.format("0x%02x", class)
+end
+
+local function message_name(class)
+	local layout = messages[class]
+	if layout == nil then
+		return string.format("Unknown 0x%02x", class)
+	end
+	return layout.name
+end
+
+local function dissect(tvb, pinfo, tree)
+	local id = can_id()
+	local xtd = can_xtd()
+	if id == nil or (xtd ~= nil and xtd.value) then
+		return false
+	end
+	local idt = id.value
+	local mtype = math.floor(idt / 512) % 4
+	local class = math.floor(idt / 8) % 64
+	local node = idt % 8
+	local rtr = can_rtr()
+
+	pinfo.cols.protocol = "Arke"
+	local subtree = tree:add(arke)
+	subtree:add(pf.type, mtype):set_generated()
+	subtree:add(pf.class, class):set_generated()
+	subtree:add(pf.id, node):set_generated()
+
+	local summary
+	if rtr ~= nil and rtr.value then
+		subtree:add(pf.request, true):set_generated()
+		subtree:add(pf.node, node_of(class)):set_generated()
+		subtree:add(pf.message, message_name(class)):set_generated()
+		local target = "all"
+		if node ~= 0 then
+			target = string.format("ID:%d", node)
+		end
+		summary = string.format("Request %s %s", message_name(class), target)
+	elseif mtype == 0 then
+		local layouts = commands[node]
+		local layout = { name = string.format("arke.Command%d", node), fields = {} }
+		if layouts ~= nil then
+			layout = layouts[#layouts]
+			for _, candidate in ipairs(layouts) do
+				if tvb:len() <= candidate.size then
+					layout = candidate
+					break
+				end
+			end
+		end
+		subtree:add(pf.node, class):set_generated()
+		subtree:add(pf.message, layout.name):set_generated()
+		add_payload(subtree, tvb, layout)
+		summary = string.format("%s %s", layout.name, class_name(class))
+	elseif mtype == 3 then
+		subtree:add(pf.node, class):set_generated()
+		subtree:add(pf.message, heartbeat.name):set_generated()
+		add_payload(subtree, tvb, heartbeat)
+		summary = string.format("%s %s ID:%d", heartbeat.name, class_name(class), node)
+	else
+		local layout = messages[class] or { name = message_name(class), fields = {} }
+		subtree:add(pf.node, node_of(class)):set_generated()
+		subtree:add(pf.message, layout.name):set_generated()
+		add_payload(subtree, tvb, layout)
+		summary = string.format("%s ID:%d", layout.name, node)
+		if mtype == 1 then
+			summary = summary .. " (high priority)"
+		end
+	end
+	subtree:append_text(", " .. summary)
+	pinfo.cols.info:set(summary)
+	return true
+end
+
+function arke.dissector(tvb, pinfo, tree)
+	if dissect(tvb, pinfo, tree) == false then
+		return 0
+	end
+	return tvb:len()
+end
+
+DissectorTable.get("can.subdissector"):add_for_decode_as(arke)
+arke:register_heuristic("can", dissect)
@@ -0,0 +1,134 @@
+#include <gtest/gtest.h>
+
+#include <cstring>
+
+#include <arke.h>
+
+#include "arke-private-conversion.h"
+#include "golden-frames.hpp"
+
+class GoldenFrames : public ::testing::Test {
+protected:
+	static void SetUpTestSuite() {
+		frames = LoadGoldenFrames(ARKE_GOLDEN_FRAMES_PATH);
+	}
+
+	template <typename T> static T Decode(const GoldenFrame &f) {
+		T res;
+		EXPECT_EQ(f.Data.size(), sizeof(T)) << "line " << f.Line;
+		memset(&res, 0, sizeof(T));
+		memcpy(&res, f.Data.data(), std::min(f.Data.size(), sizeof(T)));
+		return res;
+	}
+
+	static std::vector<GoldenFrame> frames;
+};
+
+std::vector<GoldenFrame> GoldenFrames::frames;
+
+TEST_F(GoldenFrames, CorpusIsNotEmpty) {
+	EXPECT_FALSE(frames.empty());
+}
+
+TEST_F(GoldenFrames, IDTDecoding) {
+	for (const auto &f : frames) {
+		uint8_t type = (f.IDT & ARKE_MESSAGE_TYPE_MASK) >> 9;
+		if (type == ARKE_MESSAGE || type == ARKE_HIGH_PRIORITY_MESSAGE) {
+			EXPECT_EQ(f.IDT & ARKE_SUBID_MASK, f.ID) << "line " << f.Line;
+		}
+		if (type == ARKE_HEARTBEAT) {
+			EXPECT_EQ((f.IDT & ARKE_NODE_CLASS_MASK) >> 3, f.Fields.at("Class"))
+			    << "line " << f.Line;
+		}
+	}
+}
+
+TEST_F(GoldenFrames, ZeusSetPoint) {
+	for (const auto &f : frames) {
+		if (f.Message != "Zeus.SetPoint") {
+			continue;
+		}
+		EXPECT_EQ((f.IDT & ARKE_NODE_CLASS_MASK) >> 3, ARKE_ZEUS_SET_POINT);
+		auto sp = Decode<ArkeZeusSetPoint>(f);
+		EXPECT_NEAR(humidity_to_float(sp.Humidity), f.Fields.at("Humidity"), 1e-3)
+		    << "line " << f.Line;
+		EXPECT_NEAR(
+		    hih6030_temperature_to_float(sp.Temperature),
+		    f.Fields.at("Temperature"),
+		    1e-3
+		) << "line "
+		  << f.Line;
+		EXPECT_EQ(sp.Wind, f.Fields.at("Wind")) << "line " << f.Line;
+	}
+}
+
+TEST_F(GoldenFrames, ZeusReport) {
+	for (const auto &f : frames) {
+		if (f.Message != "Zeus.Report") {
+			continue;
+		}
+		EXPECT_EQ((f.IDT & ARKE_NODE_CLASS_MASK) >> 3, ARKE_ZEUS_REPORT);
+		auto r = Decode<ArkeZeusReport>(f);
+		EXPECT_NEAR(humidity_to_float(r.Humidity), f.Fields.at("Humidity"), 1e-3)
+		    << "line " << f.Line;
+		EXPECT_NEAR(
+		    hih6030_temperature_to_float(r.Temperature1),
+		    f.Fields.at("Temperature[0]"),
+		    1e-3
+		) << "line "
+		  << f.Line;
+		EXPECT_EQ(tmp1075_to_float(r.Temperature2), f.Fields.at("Temperature[1]"))
+		    << "line " << f.Line;
+		EXPECT_EQ(tmp1075_to_float(r.Temperature3), f.Fields.at("Temperature[2]"))
+		    << "line " << f.Line;
+		EXPECT_EQ(tmp1075_to_float(r.Temperature4), f.Fields.at("Temperature[3]"))
+		    << "line " << f.Line;
+	}
+}
+
+TEST_F(GoldenFrames, ZeusConfig) {
+	for (const auto &f : frames) {
+		if (f.Message != "Zeus.Config") {
+			continue;
+		}
+		auto c = Decode<ArkeZeusConfig>(f);
+		for (auto [prefix, pid] :
+		     {std::make_pair(std::string("Humidity."), c.Humidity),
+		      std::make_pair(std::string("Temperature."), c.Temperature)}) {
+			EXPECT_EQ(pid.ProportionalMult, f.Fields.at(prefix + "ProportionnalMultiplier"));
+			EXPECT_EQ(pid.DerivativeMult, f.Fields.at(prefix + "DerivativeMultiplier"));
+			EXPECT_EQ(pid.IntegralMult, f.Fields.at(prefix + "IntegralMultiplier"));
+			EXPECT_EQ(pid.DividerPower, f.Fields.at(prefix + "DividerPower"));
+			EXPECT_EQ(pid.DividerPowerInt, f.Fields.at(prefix + "DividerPowerIntegral"));
+		}
+	}
+}
+
+TEST_F(GoldenFrames, HeliosTriggerMode) {
+	for (const auto &f : frames) {
+		if (f.Message != "Helios.TriggerMode") {
+			continue;
+		}
+		auto t = Decode<ArkeHeliosTriggerConfig>(f);
+		EXPECT_EQ(t.Period_hecto_us * 100, f.Fields.at("Period")) << "line " << f.Line;
+		EXPECT_EQ(t.Pulse_us, f.Fields.at("PulseLength")) << "line " << f.Line;
+		EXPECT_EQ(t.CameraDelay_us, f.Fields.at("CameraDelay")) << "line " << f.Line;
+	}
+}
+
+TEST_F(GoldenFrames, SynchronisationReply) {
+	for (const auto &f : frames) {
+		if (f.Message != "arke.SynchronisationReply") {
+			continue;
+		}
+		EXPECT_EQ(f.IDT & ARKE_SUBID_MASK, ARKE_SYNCHRONISATION);
+		auto r = Decode<ArkeSynchronisationReply>(f);
+		EXPECT_EQ(r.Sequence, f.Fields.at("Sequence")) << "line " << f.Line;
+		EXPECT_EQ(r.ID, f.Fields.at("ID")) << "line " << f.Line;
+		uint64_t timestamp = 0;
+		for (int i = 5; i >= 0; --i) {
+			timestamp = (timestamp << 8) | r.Timestamp_us[i];
+		}
+		EXPECT_EQ(timestamp, f.Fields.at("Timestamp")) << "line " << f.Line;
+	}
+}
@@ -0,0 +1,86 @@
+#pragma once
+
+#include <cstdint>
+#include <cstdlib>
+#include <fstream>
+#include <map>
+#include <sstream>
+#include <stdexcept>
+#include <string>
+#include <vector>
+
+// GoldenFrame is a reference frame of specs/golden-frames.txt, the
+// corpus shared with the Go implementation. See the header of that
+// file for its format.
+struct GoldenFrame {
+	int                           Line;
+	uint16_t                      IDT;
+	bool                          RTR;
+	std::vector<uint8_t>          Data;
+	std::string                   Message;
+	uint8_t                       ID;
+	std::map<std::string, double> Fields;
+};
+
+inline GoldenFrame ParseGoldenFrame(const std::string &text, int line) {
+	auto fail = [line](const std::string &what) {
+		return std::runtime_error("line " + std::to_string(line) + ": " + what);
+	};
+
+	GoldenFrame res{line, 0, false, {}, {}, 0, {}};
+	std::istringstream columns(text);
+	std::string        frame, node;
+	if (!(columns >> frame >> res.Message >> node)) {
+		throw fail("expected at least 3 columns");
+	}
+
+	auto sep = frame.find('#');
+	if (sep == std::string::npos) {
+		throw fail("invalid frame '" + frame + "'");
+	}
+	res.IDT              = std::stoul(frame.substr(0, sep), nullptr, 16);
+	std::string payload = frame.substr(sep + 1);
+	if (payload == "R") {
+		res.RTR = true;
+	} else {
+		if (payload.size() % 2 != 0 || payload.size() > 16) {
+			throw fail("invalid payload '" + payload + "'");
+		}
+		for (size_t i = 0; i < payload.size(); i += 2) {
+			res.Data.push_back(std::stoul(payload.substr(i, 2), nullptr, 16));
+		}
+	}
+	res.ID = std::stoul(node, nullptr, 0);
+
+	std::string field;
+	while (columns >> field) {
+		auto eq = field.find('=');
+		if (eq == std::string::npos) {
+			throw fail("invalid field '" + field + "'");
+		}
+		std::string value = field.substr(eq + 1);
+		// strtod does not parse octal or binary, but the corpus only
+		// uses decimal and hexadecimal values.
+		res.Fields[field.substr(0, eq)] = strtod(value.c_str(), nullptr);
+	}
+	return res;
+}
+
+inline std::vector<GoldenFrame> LoadGoldenFrames(const std::string &path) {
+	std::ifstream file(path);
+	if (!file) {
+		throw std::runtime_error("could not open '" + path + "'");
+	}
+	std::vector<GoldenFrame> res;
+	std::string              text;
+	int                      line = 0;
+	while (std::getline(file, text)) {
+		++line;
+		auto start = text.find_first_not_of(" \t\r");
+		if (start == std::string::npos || text[start] == '#') {
+			continue;
+		}
+		res.push_back(ParseGoldenFrame(text.substr(start), line));
+	}
+	return res;
+}
@@ -0,0 +1,32 @@
+package arke
+
+import (
+	"context"
+	"errors"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+)
+
+var ErrBusClosed = errors.New("Bus is closed")
+
+// FrameSender is the minimal interface needed to emit frames. Both
+// Bus and socketcan.RawInterface satisfy it.
+type FrameSender interface {
+	Send(socketcan.CanFrame) error
+}
+
+// Bus is a transport agnostic access to an Arke CAN bus.
+type Bus interface {
+	FrameSender
+	// Receive blocks until a frame is available, the context is
+	// done or the Bus is closed.
+	Receive(ctx context.Context) (socketcan.CanFrame, error)
+	Close() error
+}
+
+func copyFrame(f socketcan.CanFrame) socketcan.CanFrame {
+	data := make([]byte, 8)
+	copy(data, f.Data)
+	f.Data = data
+	return f
+}
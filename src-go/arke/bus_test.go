@@ -0,0 +1,150 @@
+package arke
+
+import (
+	"context"
+	"syscall"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+	. "gopkg.in/check.v1"
+)
+
+type BusSuite struct{}
+
+var _ = Suite(&BusSuite{})
+
+func receiveWithin(b Bus, timeout time.Duration) (socketcan.CanFrame, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return b.Receive(ctx)
+}
+
+func (s *BusSuite) TestLoopbackDelivery(c *C) {
+	l := NewLoopback()
+	defer l.Close()
+	a, b, d := l.Endpoint(), l.Endpoint(), l.Endpoint()
+
+	c.Assert(SendMessage(a, &ZeusSetPoint{Humidity: 50, Temperature: 25, Wind: 100}, false, 3), IsNil)
+
+	for _, e := range []Bus{b, d} {
+		f, err := receiveWithin(e, 100*time.Millisecond)
+		c.Assert(err, IsNil)
+		m, ID, err := ParseMessage(&f)
+		c.Assert(err, IsNil)
+		c.Check(ID, Equals, NodeID(3))
+		c.Check(m.MessageClassID(), Equals, ZeusSetPointMessage)
+	}
+
+	_, err := receiveWithin(a, 10*time.Millisecond)
+	c.Check(err, Equals, context.DeadlineExceeded)
+}
+
+func (s *BusSuite) TestLoopbackPreservesOrder(c *C) {
+	l := NewLoopback()
+	defer l.Close()
+	a, b := l.Endpoint(), l.Endpoint()
+
+	for i := 1; i <= 5; i++ {
+		c.Assert(RequestMessage(a, &ZeusReport{}, NodeID(i)), IsNil)
+	}
+	for i := 1; i <= 5; i++ {
+		f, err := receiveWithin(b, 100*time.Millisecond)
+		c.Assert(err, IsNil)
+		c.Check(f.RTR, Equals, true)
+		_, _, ID := ExtractCANIDT(f.ID)
+		c.Check(ID, Equals, NodeID(i))
+	}
+}
+
+func (s *BusSuite) TestLoopbackFramesAreCopied(c *C) {
+	l := NewLoopback()
+	defer l.Close()
+	a, b := l.Endpoint(), l.Endpoint()
+
+	data := []byte{1, 2}
+	c.Assert(a.Send(socketcan.CanFrame{ID: 0x12, Dlc: 2, Data: data}), IsNil)
+	data[0] = 42
+
+	f, err := receiveWithin(b, 100*time.Millisecond)
+	c.Assert(err, IsNil)
+	c.Check(f.Data[0:f.Dlc], DeepEquals, []byte{1, 2})
+}
+
+func (s *BusSuite) TestLoopbackClose(c *C) {
+	l := NewLoopback()
+	a, b := l.Endpoint(), l.Endpoint()
+
+	errs := make(chan error)
+	go func() {
+		_, err := b.Receive(context.Background())
+		errs <- err
+	}()
+
+	c.Check(b.Close(), IsNil)
+	c.Check(<-errs, Equals, ErrBusClosed)
+	c.Check(b.Close(), Equals, ErrBusClosed)
+	c.Check(b.Send(socketcan.CanFrame{}), Equals, ErrBusClosed)
+	// a closed endpoint is simply not reached anymore
+	c.Check(a.Send(socketcan.CanFrame{}), IsNil)
+
+	c.Check(l.Close(), IsNil)
+	_, err := a.Receive(context.Background())
+	c.Check(err, Equals, ErrBusClosed)
+}
+
+type fakeRawInterface struct {
+	sent     chan socketcan.CanFrame
+	received chan socketcan.CanFrame
+	closed   chan struct{}
+}
+
+func newFakeRawInterface() *fakeRawInterface {
+	return &fakeRawInterface{
+		sent:     make(chan socketcan.CanFrame, 10),
+		received: make(chan socketcan.CanFrame, 10),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (i *fakeRawInterface) Send(f socketcan.CanFrame) error {
+	i.sent <- f
+	return nil
+}
+
+func (i *fakeRawInterface) Receive() (socketcan.CanFrame, error) {
+	select {
+	case f := <-i.received:
+		return f, nil
+	case <-i.closed:
+		return socketcan.CanFrame{}, syscall.EBADF
+	}
+}
+
+func (i *fakeRawInterface) Close() error {
+	close(i.closed)
+	return nil
+}
+
+func (s *BusSuite) TestSocketCANBus(c *C) {
+	itf := newFakeRawInterface()
+	b := NewSocketCANBus(itf)
+
+	c.Assert(RequestMessage(b, &CelaenoStatus{}, 2), IsNil)
+	sent := <-itf.sent
+	c.Check(sent.ID, Equals, MakeCANIDT(StandardMessage, CelaenoStatusMessage, 2))
+	c.Check(sent.RTR, Equals, true)
+
+	itf.received <- socketcan.CanFrame{ID: 0x42, Dlc: 1, Data: []byte{1}}
+	f, err := receiveWithin(b, 100*time.Millisecond)
+	c.Check(err, IsNil)
+	c.Check(f.ID, Equals, uint32(0x42))
+
+	_, err = receiveWithin(b, 10*time.Millisecond)
+	c.Check(err, Equals, context.DeadlineExceeded)
+
+	c.Check(b.Close(), IsNil)
+	_, err = b.Receive(context.Background())
+	c.Check(err, Equals, ErrBusClosed)
+	c.Check(b.Send(socketcan.CanFrame{}), Equals, ErrBusClosed)
+	c.Check(b.Close(), Equals, ErrBusClosed)
+}
@@ -0,0 +1,93 @@
+package arke
+
+import (
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+)
+
+// Bitrate is the bitrate of Arke CAN buses, in bit/s.
+const Bitrate = 250000
+
+// canCRC15 updates the CAN CRC-15 with one bit.
+func canCRC15(crc uint16, bit bool) uint16 {
+	feedback := bit != (crc&0x4000 != 0)
+	crc = (crc << 1) & 0x7fff
+	if feedback == true {
+		crc ^= 0x4599
+	}
+	return crc
+}
+
+// frameBitWriter accumulates the bits of a frame subject to bit
+// stuffing, and counts the stuff bits a transmitter would insert.
+type frameBitWriter struct {
+	crc         uint16
+	bits, stuff int
+	last        bool
+	run         int
+}
+
+func (w *frameBitWriter) stuffBit(bit bool) {
+	w.bits++
+	if w.run > 0 && bit == w.last {
+		w.run++
+	} else {
+		w.last, w.run = bit, 1
+	}
+	if w.run == 5 {
+		// the complement stuff bit starts the next run
+		w.stuff++
+		w.last, w.run = !bit, 1
+	}
+}
+
+func (w *frameBitWriter) write(value uint32, size int) {
+	for i := size - 1; i >= 0; i-- {
+		bit := value&(1<<i) != 0
+		w.crc = canCRC15(w.crc, bit)
+		w.stuffBit(bit)
+	}
+}
+
+// FrameBits returns the number of bits a frame occupies on the bus,
+// from its start of frame to the end of the following intermission,
+// including the stuff bits.
+func FrameBits(f *socketcan.CanFrame) int {
+	w := &frameBitWriter{}
+	rtr := uint32(0)
+	if f.RTR == true {
+		rtr = 1
+	}
+	w.write(0, 1) // SOF
+	if f.Extended == true {
+		w.write(f.ID>>18&0x7ff, 11)
+		w.write(1, 1) // SRR
+		w.write(1, 1) // IDE
+		w.write(f.ID&0x3ffff, 18)
+		w.write(rtr, 1)
+		w.write(0, 2) // r1, r0
+	} else {
+		w.write(f.ID&0x7ff, 11)
+		w.write(rtr, 1)
+		w.write(0, 2) // IDE, r0
+	}
+	w.write(uint32(f.Dlc), 4)
+	if f.RTR == false {
+		for _, b := range f.Data[:min(int(f.Dlc), len(f.Data), 8)] {
+			w.write(uint32(b), 8)
+		}
+	}
+	crc := w.crc
+	for i := 14; i >= 0; i-- {
+		w.stuffBit(crc&(1<<i) != 0)
+	}
+	// CRC delimiter, ACK slot and delimiter, EOF and intermission
+	// are not stuffed.
+	return w.bits + w.stuff + 1 + 2 + 7 + 3
+}
+
+// FrameDuration returns the time a frame occupies an Arke bus.
+func FrameDuration(f *socketcan.CanFrame) time.Duration {
+	return time.Duration(FrameBits(f)) * time.Second / Bitrate
+}
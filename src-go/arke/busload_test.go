@@ -0,0 +1,63 @@
+package arke
+
+import (
+	"math/rand"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+	. "gopkg.in/check.v1"
+)
+
+type BusLoadSuite struct{}
+
+var _ = Suite(&BusLoadSuite{})
+
+func (s *BusLoadSuite) TestCRC(c *C) {
+	crc := uint16(0)
+	for _, b := range []byte("123456789") {
+		for i := 7; i >= 0; i-- {
+			crc = canCRC15(crc, b&(1<<i) != 0)
+		}
+	}
+	c.Check(crc, Equals, uint16(0x059e))
+}
+
+func (s *BusLoadSuite) TestFrameBits(c *C) {
+	testdata := []struct {
+		Frame socketcan.CanFrame
+		Bits  int
+	}{
+		// 34 stuffed zeros need 6 stuff bits
+		{socketcan.CanFrame{}, 53},
+	}
+	for _, d := range testdata {
+		c.Check(FrameBits(&d.Frame), Equals, d.Bits, Commentf("%+v", d.Frame))
+	}
+	// remote requests have no data field
+	request := socketcan.CanFrame{RTR: true, Dlc: 8, Data: []byte{0xff, 0, 0xff, 0, 0xff, 0, 0xff, 0}}
+	c.Check(FrameBits(&request), Equals, FrameBits(&socketcan.CanFrame{RTR: true, Dlc: 8}))
+	c.Check(FrameBits(&request) < 47+8, Equals, true)
+	c.Check(FrameDuration(&socketcan.CanFrame{}), Equals, 212*time.Microsecond)
+}
+
+func (s *BusLoadSuite) TestFrameBitsBounds(c *C) {
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 1000; i++ {
+		f := socketcan.CanFrame{
+			ID:       r.Uint32() & 0x1fffffff,
+			Dlc:      uint8(r.Intn(9)),
+			Extended: r.Intn(2) == 0,
+			Data:     make([]byte, 8),
+		}
+		r.Read(f.Data)
+		stuffed := 34 + 8*int(f.Dlc)
+		if f.Extended == true {
+			stuffed += 20
+		} else {
+			f.ID &= 0x7ff
+		}
+		bits := FrameBits(&f)
+		c.Check(bits >= stuffed+13, Equals, true, Commentf("%+v: %d bits", f, bits))
+		c.Check(bits <= stuffed+13+(stuffed-1)/4, Equals, true, Commentf("%+v: %d bits", f, bits))
+	}
+}
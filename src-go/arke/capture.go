@@ -0,0 +1,507 @@
+package arke
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+)
+
+// CaptureFormat is the file format of a capture.
+type CaptureFormat int
+
+const (
+	// CandumpFormat is the text log format of `candump -l`, one frame
+	// per line: "(1436509052.249713) can0 5C1#A40FA21F80". It can be
+	// replayed by canplayer. Players also accept bare "5C1#A40FA21F80"
+	// frames, read with a zero Time and no Interface.
+	CandumpFormat CaptureFormat = iota
+	// BinaryFormat is a compact binary format, starting with
+	// captureMagic.
+	BinaryFormat
+	// PcapngFormat is the pcapng format, with the
+	// LINKTYPE_CAN_SOCKETCAN link type, which Wireshark and other
+	// packet tools can read.
+	PcapngFormat
+)
+
+func (f CaptureFormat) String() string {
+	switch f {
+	case CandumpFormat:
+		return "candump"
+	case BinaryFormat:
+		return "binary"
+	case PcapngFormat:
+		return "pcapng"
+	}
+	return "<unknown>"
+}
+
+func (f CaptureFormat) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+func (f *CaptureFormat) UnmarshalText(text []byte) error {
+	for _, candidate := range []CaptureFormat{CandumpFormat, BinaryFormat, PcapngFormat} {
+		if candidate.String() == string(text) {
+			*f = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown capture format '%s'", text)
+}
+
+// Record is a timestamped frame of a capture. Time has a microsecond
+// resolution once recorded.
+type Record struct {
+	Time      time.Time
+	Interface string
+	Frame     socketcan.CanFrame
+}
+
+// Binary captures start with captureMagic, followed by a sequence of
+// records, each starting with its kind:
+//
+//   - binaryInterface declares an interface before its first frame:
+//     index (1 byte), name length (1 byte) and name.
+//   - binaryFrame holds a frame: microseconds since the Unix epoch
+//     (8 bytes, little-endian), interface index (1 byte), IDT with the
+//     SocketCAN EFF and RTR flags (4 bytes, little-endian), Dlc
+//     (1 byte) and the Dlc data bytes.
+const captureMagic = "ARKECAP\x01"
+
+const (
+	binaryInterface byte = 0x01
+	binaryFrame     byte = 0x02
+
+	captureEFFFlag uint32 = 0x80000000
+	captureRTRFlag uint32 = 0x40000000
+	captureEFFMask uint32 = 0x1fffffff
+	captureSFFMask uint32 = 0x7ff
+)
+
+// Recorder writes a capture. It is safe for concurrent use, so frames
+// from several buses can be recorded in the same capture. Written
+// records are buffered until Flush is called.
+type Recorder struct {
+	mx         sync.Mutex
+	w          *bufio.Writer
+	format     CaptureFormat
+	interfaces map[string]uint8
+	buffer     []byte
+}
+
+// NewRecorder returns a Recorder writing a capture to w.
+func NewRecorder(w io.Writer, format CaptureFormat) (*Recorder, error) {
+	res := &Recorder{
+		w:          bufio.NewWriter(w),
+		format:     format,
+		interfaces: make(map[string]uint8),
+		buffer:     make([]byte, 0, 32),
+	}
+	switch format {
+	case CandumpFormat:
+	case BinaryFormat:
+		if _, err := res.w.WriteString(captureMagic); err != nil {
+			return nil, err
+		}
+	case PcapngFormat:
+		if _, err := res.w.Write(appendPcapngSectionHeader(nil)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Unknown capture format %d", format)
+	}
+	return res, nil
+}
+
+func checkInterfaceName(name string) error {
+	if len(name) == 0 || len(name) > 255 || strings.ContainsAny(name, " \t\r\n") == true {
+		return fmt.Errorf("Invalid interface name '%s'", name)
+	}
+	return nil
+}
+
+// Write records a frame.
+func (r *Recorder) Write(record Record) error {
+	if err := checkInterfaceName(record.Interface); err != nil {
+		return err
+	}
+	f := &record.Frame
+	if f.Dlc > 8 || (f.RTR == false && int(f.Dlc) > len(f.Data)) {
+		return fmt.Errorf("Invalid frame Dlc %d with %d bytes of data", f.Dlc, len(f.Data))
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	switch r.format {
+	case CandumpFormat:
+		return r.writeCandump(record)
+	case PcapngFormat:
+		return r.writePcapng(record)
+	}
+	return r.writeBinary(record)
+}
+
+// interfaceIndex returns the index of an interface in the capture. New
+// interfaces are declared by appending declare to b.
+func (r *Recorder) interfaceIndex(name string, b []byte, declare func(b []byte, index uint8, name string) []byte) (uint8, []byte, error) {
+	if index, ok := r.interfaces[name]; ok == true {
+		return index, b, nil
+	}
+	if len(r.interfaces) == 256 {
+		return 0, b, fmt.Errorf("Too many interfaces in capture")
+	}
+	index := uint8(len(r.interfaces))
+	r.interfaces[name] = index
+	return index, declare(b, index, name), nil
+}
+
+func appendBinaryInterface(b []byte, index uint8, name string) []byte {
+	b = append(b, binaryInterface, index, byte(len(name)))
+	return append(b, name...)
+}
+
+func (r *Recorder) writeCandump(record Record) error {
+	f := &record.Frame
+	us := record.Time.UnixMicro()
+	b := r.buffer[:0]
+	b = fmt.Appendf(b, "(%010d.%06d) %s ", us/1e6, us%1e6, record.Interface)
+	if f.Extended == true {
+		b = fmt.Appendf(b, "%08X#", f.ID&captureEFFMask)
+	} else {
+		b = fmt.Appendf(b, "%03X#", f.ID&captureSFFMask)
+	}
+	if f.RTR == true {
+		b = append(b, 'R')
+		if f.Dlc > 0 {
+			b = strconv.AppendUint(b, uint64(f.Dlc), 10)
+		}
+	} else {
+		b = append(b, strings.ToUpper(hex.EncodeToString(f.Data[:f.Dlc]))...)
+	}
+	b = append(b, '\n')
+	r.buffer = b
+	_, err := r.w.Write(b)
+	return err
+}
+
+func (r *Recorder) writeBinary(record Record) error {
+	f := &record.Frame
+	index, b, err := r.interfaceIndex(record.Interface, r.buffer[:0], appendBinaryInterface)
+	if err != nil {
+		return err
+	}
+	ID := f.ID & captureSFFMask
+	if f.Extended == true {
+		ID = f.ID&captureEFFMask | captureEFFFlag
+	}
+	if f.RTR == true {
+		ID |= captureRTRFlag
+	}
+	b = append(b, binaryFrame)
+	b = binary.LittleEndian.AppendUint64(b, uint64(record.Time.UnixMicro()))
+	b = append(b, index)
+	b = binary.LittleEndian.AppendUint32(b, ID)
+	b = append(b, f.Dlc)
+	if f.RTR == false {
+		b = append(b, f.Data[:f.Dlc]...)
+	}
+	r.buffer = b
+	_, err = r.w.Write(b)
+	return err
+}
+
+// Flush writes any buffered record.
+func (r *Recorder) Flush() error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.w.Flush()
+}
+
+// Capture records all frames received on bus, timestamped on
+// reception, until ctx is done or the bus is closed. Records are
+// flushed before returning. A closed bus is not an error.
+func (r *Recorder) Capture(ctx context.Context, bus Bus, iface string) error {
+	if err := checkInterfaceName(iface); err != nil {
+		return err
+	}
+	defer r.Flush()
+	for {
+		f, err := bus.Receive(ctx)
+		if errors.Is(err, ErrBusClosed) == true {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := r.Write(Record{Time: time.Now(), Interface: iface, Frame: f}); err != nil {
+			return err
+		}
+	}
+}
+
+// Copy writes all remaining records of p, converting them to the
+// format of the Recorder, and returns the number of records
+// written. Records are flushed before returning.
+func (r *Recorder) Copy(p *Player) (int, error) {
+	defer r.Flush()
+	n := 0
+	for {
+		record, err := p.Next()
+		if errors.Is(err, io.EOF) == true {
+			return n, r.Flush()
+		}
+		if err != nil {
+			return n, err
+		}
+		if err := r.Write(record); err != nil {
+			return n, err
+		}
+		n += 1
+	}
+}
+
+// Player reads a capture written by a Recorder, by `candump -l` or
+// by any tool writing pcapng. Packets of pcapng captures that are not
+// SocketCAN frames are skipped.
+type Player struct {
+	r          *bufio.Reader
+	format     CaptureFormat
+	interfaces []string
+	line       int
+	pcapng     pcapngReader
+}
+
+// NewPlayer returns a Player reading the capture in r. Its format is
+// detected from its first bytes.
+func NewPlayer(r io.Reader) (*Player, error) {
+	res := &Player{r: bufio.NewReader(r), format: CandumpFormat}
+	magic, err := res.r.Peek(len(captureMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(magic) >= 4 && binary.LittleEndian.Uint32(magic) == pcapngSectionHeader {
+		res.format = PcapngFormat
+	} else if string(magic) == captureMagic {
+		res.format = BinaryFormat
+		res.r.Discard(len(captureMagic))
+		res.interfaces = make([]string, 0, 256)
+	} else if len(magic) > 0 && strings.HasPrefix(captureMagic, string(magic)) == true {
+		return nil, fmt.Errorf("Truncated binary capture header")
+	}
+	return res, nil
+}
+
+// Format returns the detected format of the capture.
+func (p *Player) Format() CaptureFormat {
+	return p.format
+}
+
+// Next returns the next record of the capture, or io.EOF at its end.
+func (p *Player) Next() (Record, error) {
+	switch p.format {
+	case BinaryFormat:
+		return p.nextBinary()
+	case PcapngFormat:
+		return p.nextPcapng()
+	}
+	return p.nextCandump()
+}
+
+// NextMessage returns the next record of the capture, decoded with
+// ParseMessage. Decoding errors are of type *ParseError, and are
+// returned alongside the record and any message ParseMessage
+// returned. Other errors, including io.EOF, come from reading the
+// capture.
+func (p *Player) NextMessage() (Record, ReceivableMessage, NodeID, error) {
+	record, err := p.Next()
+	if err != nil {
+		return record, nil, 0, err
+	}
+	m, ID, err := ParseMessage(&record.Frame)
+	return record, m, ID, err
+}
+
+func (p *Player) nextCandump() (Record, error) {
+	for {
+		text, err := p.r.ReadString('\n')
+		if err != nil && (err != io.EOF || len(text) == 0) {
+			return Record{}, err
+		}
+		p.line += 1
+		text = strings.TrimSpace(text)
+		if len(text) == 0 {
+			continue
+		}
+		record, perr := parseCandumpLine(text)
+		if perr != nil {
+			return Record{}, fmt.Errorf("Line %d: %w", p.line, perr)
+		}
+		return record, nil
+	}
+}
+
+func parseCandumpLine(text string) (Record, error) {
+	columns := strings.Fields(text)
+	if len(columns) == 1 {
+		frame, err := parseCandumpFrame(columns[0])
+		return Record{Frame: frame}, err
+	}
+	if len(columns) != 3 {
+		return Record{}, fmt.Errorf("Expected 1 or 3 columns, got %d", len(columns))
+	}
+	res := Record{Interface: columns[1]}
+
+	ts := columns[0]
+	if len(ts) < 3 || ts[0] != '(' || ts[len(ts)-1] != ')' {
+		return Record{}, fmt.Errorf("Invalid timestamp '%s'", ts)
+	}
+	sec, frac, _ := strings.Cut(ts[1:len(ts)-1], ".")
+	s, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return Record{}, fmt.Errorf("Invalid timestamp '%s'", ts)
+	}
+	us := int64(0)
+	if len(frac) > 0 {
+		if len(frac) > 6 {
+			frac = frac[:6]
+		}
+		us, err = strconv.ParseInt(frac+strings.Repeat("0", 6-len(frac)), 10, 64)
+		if err != nil {
+			return Record{}, fmt.Errorf("Invalid timestamp '%s'", ts)
+		}
+	}
+	res.Time = time.Unix(s, us*1000)
+
+	frame, err := parseCandumpFrame(columns[2])
+	if err != nil {
+		return Record{}, err
+	}
+	res.Frame = frame
+	return res, nil
+}
+
+func parseCandumpFrame(text string) (socketcan.CanFrame, error) {
+	res := socketcan.CanFrame{}
+	idt, payload, ok := strings.Cut(text, "#")
+	if ok == false || strings.HasPrefix(payload, "#") == true {
+		return res, fmt.Errorf("Invalid frame '%s'", text)
+	}
+	ID, err := strconv.ParseUint(idt, 16, 32)
+	switch {
+	case err != nil:
+		return res, fmt.Errorf("Invalid IDT '%s'", idt)
+	case len(idt) == 3 && uint32(ID) <= captureSFFMask:
+	case len(idt) == 8 && uint32(ID) <= captureEFFMask:
+		res.Extended = true
+	default:
+		return res, fmt.Errorf("Invalid IDT '%s'", idt)
+	}
+	res.ID = uint32(ID)
+
+	if strings.HasPrefix(payload, "R") == true {
+		res.RTR = true
+		if len(payload) > 1 {
+			dlc, err := strconv.ParseUint(payload[1:], 16, 8)
+			if err != nil || dlc > 8 {
+				return res, fmt.Errorf("Invalid RTR length '%s'", payload[1:])
+			}
+			res.Dlc = uint8(dlc)
+		}
+		return res, nil
+	}
+	res.Data, err = hex.DecodeString(strings.ReplaceAll(payload, ".", ""))
+	if err != nil || len(res.Data) > 8 {
+		return res, fmt.Errorf("Invalid payload '%s'", payload)
+	}
+	res.Dlc = uint8(len(res.Data))
+	return res, nil
+}
+
+func (p *Player) nextBinary() (Record, error) {
+	for {
+		kind, err := p.r.ReadByte()
+		if err != nil {
+			return Record{}, err
+		}
+		switch kind {
+		case binaryInterface:
+			if err := p.readInterface(); err != nil {
+				return Record{}, err
+			}
+		case binaryFrame:
+			return p.readFrame()
+		default:
+			return Record{}, fmt.Errorf("Invalid binary capture record kind 0x%02x", kind)
+		}
+	}
+}
+
+func (p *Player) readFull(buf []byte) error {
+	_, err := io.ReadFull(p.r, buf)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (p *Player) readInterface() error {
+	var header [2]byte
+	if err := p.readFull(header[:]); err != nil {
+		return err
+	}
+	name := make([]byte, header[1])
+	if err := p.readFull(name); err != nil {
+		return err
+	}
+	if int(header[0]) != len(p.interfaces) {
+		return fmt.Errorf("Unexpected interface index %d in binary capture", header[0])
+	}
+	p.interfaces = append(p.interfaces, string(name))
+	return nil
+}
+
+func (p *Player) readFrame() (Record, error) {
+	var header [14]byte
+	if err := p.readFull(header[:]); err != nil {
+		return Record{}, err
+	}
+	res := Record{
+		Time: time.UnixMicro(int64(binary.LittleEndian.Uint64(header[0:]))),
+	}
+	index := int(header[8])
+	if index >= len(p.interfaces) {
+		return Record{}, fmt.Errorf("Undeclared interface index %d in binary capture", index)
+	}
+	res.Interface = p.interfaces[index]
+
+	ID := binary.LittleEndian.Uint32(header[9:])
+	res.Frame.Extended = ID&captureEFFFlag != 0
+	res.Frame.RTR = ID&captureRTRFlag != 0
+	if res.Frame.Extended == true {
+		res.Frame.ID = ID & captureEFFMask
+	} else {
+		res.Frame.ID = ID & captureSFFMask
+	}
+	res.Frame.Dlc = header[13]
+	if res.Frame.Dlc > 8 {
+		return Record{}, fmt.Errorf("Invalid Dlc %d in binary capture", res.Frame.Dlc)
+	}
+	if res.Frame.RTR == false {
+		res.Frame.Data = make([]byte, res.Frame.Dlc)
+		if err := p.readFull(res.Frame.Data); err != nil {
+			return Record{}, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,345 @@
+package arke
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"strings"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+	. "gopkg.in/check.v1"
+)
+
+type CaptureSuite struct {
+	Records []Record
+}
+
+var _ = Suite(&CaptureSuite{})
+
+func (s *CaptureSuite) SetUpTest(c *C) {
+	start := time.Unix(1436509052, 249713000)
+	s.Records = []Record{
+		{
+			Time:      start,
+			Interface: "can0",
+			Frame:     socketcan.CanFrame{ID: 0x5c1, Dlc: 5, Data: []byte{0xa4, 0x0f, 0xa2, 0x1f, 0x80}},
+		},
+		{
+			Time:      start.Add(1500 * time.Microsecond),
+			Interface: "can1",
+			Frame:     socketcan.CanFrame{ID: 0x5ca, RTR: true},
+		},
+		{
+			Time:      start.Add(time.Second),
+			Interface: "can0",
+			Frame:     socketcan.CanFrame{ID: 0x784, Dlc: 0, Data: []byte{}},
+		},
+		{
+			Time:      start.Add(2 * time.Second),
+			Interface: "vcan0",
+			Frame:     socketcan.CanFrame{ID: 0x1234567, Extended: true, Dlc: 2, Data: []byte{1, 2}},
+		},
+	}
+}
+
+func (s *CaptureSuite) record(c *C, format CaptureFormat) []byte {
+	buffer := bytes.NewBuffer(nil)
+	r, err := NewRecorder(buffer, format)
+	c.Assert(err, IsNil)
+	for _, record := range s.Records {
+		c.Assert(r.Write(record), IsNil)
+	}
+	c.Assert(r.Flush(), IsNil)
+	return buffer.Bytes()
+}
+
+func (s *CaptureSuite) replay(c *C, data []byte, format CaptureFormat) []Record {
+	p, err := NewPlayer(bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	c.Check(p.Format(), Equals, format)
+	var res []Record
+	for {
+		record, err := p.Next()
+		if err == io.EOF {
+			return res
+		}
+		c.Assert(err, IsNil)
+		res = append(res, record)
+	}
+}
+
+func (s *CaptureSuite) TestCandumpFormat(c *C) {
+	data := s.record(c, CandumpFormat)
+	c.Check(string(data), Equals, `(1436509052.249713) can0 5C1#A40FA21F80
+(1436509052.251213) can1 5CA#R
+(1436509053.249713) can0 784#
+(1436509054.249713) vcan0 01234567#0102
+`)
+}
+
+func (s *CaptureSuite) TestRoundTrip(c *C) {
+	for _, format := range []CaptureFormat{CandumpFormat, BinaryFormat, PcapngFormat} {
+		records := s.replay(c, s.record(c, format), format)
+		c.Assert(records, HasLen, len(s.Records))
+		for i, expected := range s.Records {
+			comment := Commentf("%s record %d", format, i)
+			c.Check(records[i].Time.Equal(expected.Time), Equals, true, comment)
+			c.Check(records[i].Interface, Equals, expected.Interface, comment)
+			c.Check(records[i].Frame, DeepEquals, expected.Frame, comment)
+		}
+	}
+}
+
+func (s *CaptureSuite) TestBinaryIsCompact(c *C) {
+	binary := s.record(c, BinaryFormat)
+	candump := s.record(c, CandumpFormat)
+	c.Check(len(binary) < len(candump), Equals, true)
+}
+
+func (s *CaptureSuite) TestReadsCandumpLogs(c *C) {
+	log := `(1436509052.249713) can0 044#2A366C2BBA
+
+(1436509052.3) can0 5C1#A4.0F.A2.1F.80
+(1436509052.449713) can0 5CA#R2
+`
+	records := s.replay(c, []byte(log), CandumpFormat)
+	c.Assert(records, HasLen, 3)
+	c.Check(records[0].Frame, DeepEquals, socketcan.CanFrame{ID: 0x44, Dlc: 5, Data: []byte{0x2a, 0x36, 0x6c, 0x2b, 0xba}})
+	c.Check(records[1].Time.Equal(time.Unix(1436509052, 300000000)), Equals, true)
+	c.Check(records[1].Frame.Data, DeepEquals, []byte{0xa4, 0x0f, 0xa2, 0x1f, 0x80})
+	c.Check(records[2].Frame, DeepEquals, socketcan.CanFrame{ID: 0x5ca, RTR: true, Dlc: 2})
+}
+
+func (s *CaptureSuite) TestReadsBareFrames(c *C) {
+	p, err := NewPlayer(strings.NewReader("044#2A366C2BBA\n 5CA#R2 \n"))
+	c.Assert(err, IsNil)
+	r, err := p.Next()
+	c.Assert(err, IsNil)
+	c.Check(r.Time.IsZero(), Equals, true)
+	c.Check(r.Interface, Equals, "")
+	c.Check(r.Frame, DeepEquals, socketcan.CanFrame{ID: 0x44, Dlc: 5, Data: []byte{0x2a, 0x36, 0x6c, 0x2b, 0xba}})
+	r, err = p.Next()
+	c.Assert(err, IsNil)
+	c.Check(r.Frame, DeepEquals, socketcan.CanFrame{ID: 0x5ca, RTR: true, Dlc: 2})
+	_, err = p.Next()
+	c.Check(err, Equals, io.EOF)
+}
+
+func (s *CaptureSuite) TestCandumpErrors(c *C) {
+	testdata := []struct {
+		Log   string
+		Error string
+	}{
+		{"(1.0) can0", "Line 1: Expected 1 or 3 columns, got 2"},
+		{"\n1.0 can0 5C1#00", "Line 2: Invalid timestamp '1.0'"},
+		{"(1.0) can0 5C100", "Line 1: Invalid frame '5C100'"},
+		{"(1.0) can0 5C1##100", "Line 1: Invalid frame '5C1##100'"},
+		{"(1.0) can0 FC1#00", "Line 1: Invalid IDT 'FC1'"},
+		{"(1.0) can0 5C1#0", "Line 1: Invalid payload '0'"},
+		{"(1.0) can0 5C1#000000000000000000", "Line 1: Invalid payload '000000000000000000'"},
+		{"(1.0) can0 5C1#R9", "Line 1: Invalid RTR length '9'"},
+		{"5C1#0", "Line 1: Invalid payload '0'"},
+	}
+	for _, d := range testdata {
+		p, err := NewPlayer(strings.NewReader(d.Log))
+		c.Assert(err, IsNil)
+		_, err = p.Next()
+		c.Check(err, ErrorMatches, d.Error)
+	}
+}
+
+func (s *CaptureSuite) TestBinaryErrors(c *C) {
+	data := s.record(c, BinaryFormat)
+
+	_, err := NewPlayer(bytes.NewReader(data[:4]))
+	c.Check(err, ErrorMatches, "Truncated binary capture header")
+
+	p, err := NewPlayer(bytes.NewReader(data[:len(data)-1]))
+	c.Assert(err, IsNil)
+	for i := 0; i < len(s.Records)-1; i++ {
+		_, err = p.Next()
+		c.Assert(err, IsNil)
+	}
+	_, err = p.Next()
+	c.Check(err, Equals, io.ErrUnexpectedEOF)
+
+	p, err = NewPlayer(bytes.NewReader(append([]byte(captureMagic), 0x42)))
+	c.Assert(err, IsNil)
+	_, err = p.Next()
+	c.Check(err, ErrorMatches, "Invalid binary capture record kind 0x42")
+}
+
+func (s *CaptureSuite) TestPcapngLayout(c *C) {
+	data := s.record(c, PcapngFormat)
+
+	type block struct {
+		Type uint32
+		Body []byte
+	}
+	var blocks []block
+	for len(data) > 0 {
+		c.Assert(len(data) >= 12, Equals, true)
+		length := binary.LittleEndian.Uint32(data[4:])
+		c.Assert(int(length) <= len(data), Equals, true)
+		c.Check(length%4, Equals, uint32(0))
+		c.Check(binary.LittleEndian.Uint32(data[length-4:]), Equals, length)
+		blocks = append(blocks, block{binary.LittleEndian.Uint32(data), data[8 : length-4]})
+		data = data[length:]
+	}
+	// SHB, then an IDB before the first frame of each interface
+	types := []uint32{0x0a0d0d0a, 1, 6, 1, 6, 6, 1, 6}
+	c.Assert(blocks, HasLen, len(types))
+	for i, t := range types {
+		c.Check(blocks[i].Type, Equals, t, Commentf("block %d", i))
+	}
+
+	c.Check(blocks[0].Body[:8], DeepEquals, []byte{0x4d, 0x3c, 0x2b, 0x1a, 1, 0, 0, 0})
+
+	c.Check(blocks[1].Body, DeepEquals, []byte{
+		227, 0, 0, 0, 16, 0, 0, 0,
+		2, 0, 4, 0, 'c', 'a', 'n', '0',
+		9, 0, 1, 0, 6, 0, 0, 0,
+		0, 0, 0, 0,
+	})
+	c.Check(blocks[6].Body[12:17], DeepEquals, []byte{'v', 'c', 'a', 'n', '0'})
+
+	epb := blocks[2].Body
+	ts := uint64(binary.LittleEndian.Uint32(epb[4:]))<<32 | uint64(binary.LittleEndian.Uint32(epb[8:]))
+	c.Check(ts, Equals, uint64(1436509052249713))
+	c.Check(epb[20:], DeepEquals, []byte{
+		0, 0, 0x05, 0xc1, 5, 0, 0, 0,
+		0xa4, 0x0f, 0xa2, 0x1f, 0x80, 0, 0, 0,
+	})
+	c.Check(blocks[4].Body[20:24], DeepEquals, []byte{0x40, 0, 0x05, 0xca})
+	c.Check(blocks[7].Body[20:24], DeepEquals, []byte{0x81, 0x23, 0x45, 0x67})
+}
+
+func (s *CaptureSuite) TestReadsPcapng(c *C) {
+	// a big-endian section with a nanosecond resolution ethernet
+	// interface, followed by a CAN interface.
+	block := func(t uint32, body ...byte) []byte {
+		b := binary.BigEndian.AppendUint32(nil, t)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(body)+12))
+		b = append(b, body...)
+		return binary.BigEndian.AppendUint32(b, uint32(len(body)+12))
+	}
+	data := block(0x0a0d0d0a, 0x1a, 0x2b, 0x3c, 0x4d, 0, 1, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	data = append(data, block(1, 0, 1, 0, 0, 0, 0, 0, 0)...)
+	data = append(data, block(1, 0, 227, 0, 0, 0, 0, 0, 16, 0, 9, 0, 1, 9, 0, 0, 0, 0, 0, 0, 0)...)
+	data = append(data, block(6,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4, 0, 0, 0, 4,
+		0xde, 0xad, 0xbe, 0xef)...)
+	data = append(data, block(6,
+		0, 0, 0, 1, 0, 0, 0, 0, 0x77, 0x35, 0x94, 0x01, 0, 0, 0, 10, 0, 0, 0, 10,
+		0, 0, 0x05, 0xc1, 2, 0, 0, 0, 0x2a, 0x42, 0, 0)...)
+
+	records := s.replay(c, data, PcapngFormat)
+	c.Assert(records, HasLen, 1)
+	c.Check(records[0].Interface, Equals, "pcapng1")
+	c.Check(records[0].Time.Equal(time.Unix(2, 1)), Equals, true)
+	c.Check(records[0].Frame, DeepEquals, socketcan.CanFrame{ID: 0x5c1, Dlc: 2, Data: []byte{0x2a, 0x42}})
+}
+
+func (s *CaptureSuite) TestPcapngErrors(c *C) {
+	data := s.record(c, PcapngFormat)
+
+	p, err := NewPlayer(bytes.NewReader(data[:len(data)-1]))
+	c.Assert(err, IsNil)
+	for i := 0; i < len(s.Records)-1; i++ {
+		_, err = p.Next()
+		c.Assert(err, IsNil)
+	}
+	_, err = p.Next()
+	c.Check(err, Equals, io.ErrUnexpectedEOF)
+
+	corrupted := append([]byte{}, data...)
+	corrupted[8] = 0x42
+	p, err = NewPlayer(bytes.NewReader(corrupted))
+	c.Assert(err, IsNil)
+	_, err = p.Next()
+	c.Check(err, ErrorMatches, "Invalid pcapng byte-order magic")
+
+	corrupted = append([]byte{}, data...)
+	corrupted[27] = 0x42
+	p, err = NewPlayer(bytes.NewReader(corrupted))
+	c.Assert(err, IsNil)
+	_, err = p.Next()
+	c.Check(err, ErrorMatches, "Inconsistent pcapng block length")
+}
+
+func (s *CaptureSuite) TestCopy(c *C) {
+	p, err := NewPlayer(bytes.NewReader(s.record(c, CandumpFormat)))
+	c.Assert(err, IsNil)
+	buffer := bytes.NewBuffer(nil)
+	r, err := NewRecorder(buffer, PcapngFormat)
+	c.Assert(err, IsNil)
+	n, err := r.Copy(p)
+	c.Check(err, IsNil)
+	c.Check(n, Equals, len(s.Records))
+	c.Check(buffer.Bytes(), DeepEquals, s.record(c, PcapngFormat))
+}
+
+func (s *CaptureSuite) TestCaptureFormatText(c *C) {
+	for _, format := range []CaptureFormat{CandumpFormat, BinaryFormat, PcapngFormat} {
+		text, err := format.MarshalText()
+		c.Assert(err, IsNil)
+		var parsed CaptureFormat
+		c.Check(parsed.UnmarshalText(text), IsNil)
+		c.Check(parsed, Equals, format)
+	}
+	var parsed CaptureFormat
+	c.Check(parsed.UnmarshalText([]byte("pcap")), ErrorMatches, "Unknown capture format 'pcap'")
+}
+
+func (s *CaptureSuite) TestRecorderErrors(c *C) {
+	r, err := NewRecorder(io.Discard, BinaryFormat)
+	c.Assert(err, IsNil)
+	c.Check(r.Write(Record{Interface: "can 0"}), ErrorMatches, "Invalid interface name 'can 0'")
+	c.Check(r.Write(Record{Interface: "can0", Frame: socketcan.CanFrame{Dlc: 4, Data: []byte{1}}}),
+		ErrorMatches, "Invalid frame Dlc 4 with 1 bytes of data")
+
+	_, err = NewRecorder(io.Discard, CaptureFormat(42))
+	c.Check(err, ErrorMatches, "Unknown capture format 42")
+}
+
+func (s *CaptureSuite) TestNextMessage(c *C) {
+	p, err := NewPlayer(bytes.NewReader(s.record(c, BinaryFormat)))
+	c.Assert(err, IsNil)
+	record, m, ID, err := p.NextMessage()
+	c.Assert(err, IsNil)
+	c.Check(record.Interface, Equals, "can0")
+	c.Check(ID, Equals, NodeID(1))
+	c.Check(m, FitsTypeOf, &ZeusSetPoint{})
+
+	_, m, _, err = p.NextMessage()
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, &MessageRequestData{Class: ZeusReportMessage, ID: 2})
+}
+
+func (s *CaptureSuite) TestCapture(c *C) {
+	loopback := NewLoopback()
+	sender := loopback.Endpoint()
+	receiver := loopback.Endpoint()
+
+	buffer := bytes.NewBuffer(nil)
+	r, err := NewRecorder(buffer, CandumpFormat)
+	c.Assert(err, IsNil)
+	done := make(chan error)
+	go func() {
+		done <- r.Capture(context.Background(), receiver, "vcan0")
+	}()
+
+	c.Assert(sender.Send(MakePing(ZeusClass)), IsNil)
+	c.Assert(sender.Send(MakeResetRequest(HeliosClass, 2)), IsNil)
+	time.Sleep(10 * time.Millisecond)
+	c.Assert(receiver.Close(), IsNil)
+	c.Assert(<-done, IsNil)
+
+	records := s.replay(c, buffer.Bytes(), CandumpFormat)
+	c.Assert(records, HasLen, 2)
+	c.Check(records[0].Interface, Equals, "vcan0")
+	c.Check(records[0].Frame.ID, Equals, MakeCANIDT(NetworkControlCommand, MessageClass(ZeusClass), NodeID(HeartBeatRequest)))
+	c.Check(records[1].Frame.Data, DeepEquals, []byte{2})
+}
@@ -3,21 +3,17 @@ package arke
 import (
 	"encoding/binary"
 	"fmt"
+	"strings"
 	"time"
 )
 
-type CelaenoSetPoint struct {
-	Power uint8 `positional-arg-name:"power" required:"yes"`
-}
-
-func (m *CelaenoSetPoint) MessageClassID() MessageClass {
-	return CelaenoSetPointMessage
-}
-
 func (m CelaenoSetPoint) Marshal(buf []byte) (int, error) {
 	if err := checkSize(buf, 1); err != nil {
 		return 0, err
 	}
+	if err := m.Validate(); err != nil {
+		return 0, err
+	}
 	buf[0] = m.Power
 	return 1, nil
 }
@@ -43,19 +39,13 @@ const (
 	CelaenoWaterReadError WaterLevelStatus = 0x04
 )
 
-type CelaenoStatus struct {
-	WaterLevel WaterLevelStatus
-	Fan        FanStatusAndRPM
-}
-
-func (m *CelaenoStatus) MessageClassID() MessageClass {
-	return CelaenoStatusMessage
-}
-
 func (m *CelaenoStatus) Marshal(buf []byte) (int, error) {
 	if err := checkSize(buf, 3); err != nil {
 		return 0, err
 	}
+	if err := m.Validate(); err != nil {
+		return 0, err
+	}
 
 	buf[0] = byte(m.WaterLevel)
 	binary.LittleEndian.PutUint16(buf[1:], uint16(m.Fan))
@@ -96,19 +86,31 @@ func (s WaterLevelStatus) String() string {
 	return prefix + "nominal"
 }
 
-func (m *CelaenoStatus) String() string {
-	return fmt.Sprintf("Celaeno.Status{WaterLevel: %s, Fan:%s}", m.WaterLevel, m.Fan)
+func (s WaterLevelStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
 }
 
-type CelaenoConfig struct {
-	RampUpTime    time.Duration `positional-arg-name:"ramp_up" required:"yes"`
-	RampDownTime  time.Duration `positional-arg-name:"ramp_down" required:"yes"`
-	MinimumOnTime time.Duration `positional-arg-name:"minimum_on" required:"yes"`
-	DebounceTime  time.Duration `positional-arg-name:"debounce_time" required:"yes"`
+func (s *WaterLevelStatus) UnmarshalText(text []byte) error {
+	res := CelaenoWaterNominal
+	for _, flag := range strings.Split(string(text), "|") {
+		switch flag {
+		case "nominal":
+		case "warning":
+			res |= CelaenoWaterWarning
+		case "critical":
+			res |= CelaenoWaterCritical
+		case "readout-error":
+			res |= CelaenoWaterReadError
+		default:
+			return fmt.Errorf("Invalid water level '%s'", flag)
+		}
+	}
+	*s = res
+	return nil
 }
 
-func (m *CelaenoConfig) MessageClassID() MessageClass {
-	return CelaenoConfigMessage
+func (m *CelaenoStatus) String() string {
+	return fmt.Sprintf("Celaeno.Status{WaterLevel: %s, Fan:%s}", m.WaterLevel, m.Fan)
 }
 
 const MaxUint16 = ^uint16(0)
@@ -116,7 +118,7 @@ const MaxUint16 = ^uint16(0)
 func castDuration(t time.Duration) (uint16, error) {
 	res := t.Nanoseconds() / 1000000
 	if res > int64(MaxUint16) {
-		return 0xffff, fmt.Errorf("Time constant overflow")
+		return 0xffff, ErrDurationOverflow
 	}
 	return uint16(res), nil
 }
@@ -125,6 +127,9 @@ func (m CelaenoConfig) Marshal(buf []byte) (int, error) {
 	if err := checkSize(buf, 8); err != nil {
 		return 0, err
 	}
+	if err := m.Validate(); err != nil {
+		return 0, err
+	}
 	for i, t := range []time.Duration{m.RampUpTime, m.RampDownTime, m.MinimumOnTime, m.DebounceTime} {
 		data, err := castDuration(t)
 		if err != nil {
@@ -153,12 +158,3 @@ func (m *CelaenoConfig) String() string {
 		m.MinimumOnTime,
 		m.DebounceTime)
 }
-
-func init() {
-	messageFactory[CelaenoSetPointMessage] = func() Message { return &CelaenoSetPoint{} }
-	messagesName[CelaenoSetPointMessage] = "Celaeno.SetPoint"
-	messageFactory[CelaenoStatusMessage] = func() Message { return &CelaenoStatus{} }
-	messagesName[CelaenoStatusMessage] = "Celaeno.Status"
-	messageFactory[CelaenoConfigMessage] = func() Message { return &CelaenoConfig{} }
-	messagesName[CelaenoConfigMessage] = "Celaeno.Config"
-}
@@ -1,6 +1,7 @@
 package arke
 
 import (
+	"errors"
 	"time"
 
 	. "gopkg.in/check.v1"
@@ -46,5 +47,6 @@ func (s *CelaenoSuite) TestConfig(c *C) {
 		RampDownTime: (1 << 16) * time.Millisecond,
 	}
 	_, err := m.Marshal(make([]byte, 8))
-	c.Check(err, ErrorMatches, "Time constant overflow")
+	c.Check(err, ErrorMatches, "RampDownTime 65536ms is out of range \\[0ms, 65535ms\\]")
+	c.Check(errors.Is(err, ErrDurationOverflow), Equals, true)
 }
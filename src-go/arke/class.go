@@ -2,39 +2,66 @@ package arke
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
-var nameByClass = make(map[NodeClass]string)
-
-var classByName = make(map[string]NodeClass)
-
 func (c NodeClass) String() string {
 	return ClassName(c)
 }
 
 func ClassName(c NodeClass) string {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
 	if n, ok := nameByClass[c]; ok == true {
 		return n
 	}
 	return "<unknown>"
 }
 
+// NodeClass returns the class of the node handling a standard
+// message, i.e. the highest node class not greater than c.
+func (c MessageClass) NodeClass() NodeClass {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	res := BroadcastClass
+	for nc := range nameByClass {
+		if MessageClass(nc) <= c && nc > res {
+			res = nc
+		}
+	}
+	return res
+}
+
 func Class(s string) (NodeClass, error) {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
 	if c, ok := classByName[strings.ToLower(s)]; ok == true {
 		return c, nil
 	}
 	return 0, fmt.Errorf("Unknown node class '%s'", s)
 }
 
-func init() {
-	nameByClass[ZeusClass] = "Zeus"
-	nameByClass[CelaenoClass] = "Celaeno"
-	nameByClass[HeliosClass] = "Helios"
-	nameByClass[0] = "Broadcast"
-	nameByClass[NotusClass] = "Notus"
+// MarshalText returns the class name, or its hexadecimal value if it
+// is not registered.
+func (c NodeClass) MarshalText() ([]byte, error) {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	if n, ok := nameByClass[c]; ok == true {
+		return []byte(n), nil
+	}
+	return []byte(fmt.Sprintf("0x%02x", int(c))), nil
+}
 
-	for c, n := range nameByClass {
-		classByName[strings.ToLower(n)] = c
+func (c *NodeClass) UnmarshalText(text []byte) error {
+	if res, err := Class(string(text)); err == nil {
+		*c = res
+		return nil
+	}
+	v, err := strconv.ParseUint(string(text), 0, 8)
+	if err != nil || v > maxClass {
+		return fmt.Errorf("Unknown node class '%s'", text)
 	}
+	*c = NodeClass(v)
+	return nil
 }
@@ -42,3 +42,22 @@ func (s *ClassSuite) TestNameMapping(c *C) {
 
 	c.Check(ClassName(NodeClass(1)), Equals, "<unknown>")
 }
+
+func (s *ClassSuite) TestMessageNodeClass(c *C) {
+	testdata := []struct {
+		M MessageClass
+		C NodeClass
+	}{
+		{ZeusSetPointMessage, ZeusClass},
+		{ZeusDeltaTemperatureMessage, ZeusClass},
+		{MessageClass(0x3f), ZeusClass},
+		{HeliosTriggerModeMessage, HeliosClass},
+		{CelaenoConfigMessage, CelaenoClass},
+		{NotusConfigMessage, NotusClass},
+		{MessageClass(0x2b), BroadcastClass},
+	}
+
+	for _, d := range testdata {
+		c.Check(d.M.NodeClass(), Equals, d.C, Commentf("for %s", d.M))
+	}
+}
@@ -0,0 +1,175 @@
+package arke
+
+import (
+	"context"
+	"fmt"
+)
+
+// node is the common base of the device clients. Like Request, the
+// getters of a client discard any unrelated frame received on the
+// Bus while waiting for their reply.
+type node struct {
+	bus   Bus
+	class NodeClass
+	ID    NodeID
+}
+
+func (n *node) send(m SendableMessage) error {
+	return SendMessage(n.bus, m, false, n.ID)
+}
+
+func (n *node) Reset() error {
+	return n.bus.Send(MakeResetRequest(n.class, n.ID))
+}
+
+func (n *node) ChangeID(new NodeID) error {
+	if err := checkID(new); err != nil {
+		return err
+	}
+	if err := n.bus.Send(MakeIDChangeRequest(n.class, n.ID, new)); err != nil {
+		return err
+	}
+	n.ID = new
+	return nil
+}
+
+func requestAs[T ReceivableMessage](ctx context.Context, n *node, c MessageClass) (T, error) {
+	var zero T
+	m, err := Request(ctx, n.bus, c, n.ID)
+	if err != nil {
+		return zero, err
+	}
+	res, ok := m.(T)
+	if ok == false {
+		return zero, fmt.Errorf("Unexpected reply %s for %s", m, c)
+	}
+	return res, nil
+}
+
+type Zeus struct {
+	node
+}
+
+func NewZeus(bus Bus, ID NodeID) *Zeus {
+	return &Zeus{node{bus: bus, class: ZeusClass, ID: ID}}
+}
+
+func (z *Zeus) SetSetPoint(m ZeusSetPoint) error {
+	return z.send(&m)
+}
+
+func (z *Zeus) SetConfig(m ZeusConfig) error {
+	return z.send(&m)
+}
+
+func (z *Zeus) SetDeltas(m ZeusDeltaTemperature) error {
+	return z.send(&m)
+}
+
+func (z *Zeus) SetPoint(ctx context.Context) (*ZeusSetPoint, error) {
+	return requestAs[*ZeusSetPoint](ctx, &z.node, ZeusSetPointMessage)
+}
+
+func (z *Zeus) Report(ctx context.Context) (*ZeusReport, error) {
+	return requestAs[*ZeusReport](ctx, &z.node, ZeusReportMessage)
+}
+
+func (z *Zeus) Config(ctx context.Context) (*ZeusConfig, error) {
+	return requestAs[*ZeusConfig](ctx, &z.node, ZeusConfigMessage)
+}
+
+func (z *Zeus) Status(ctx context.Context) (*ZeusStatus, error) {
+	return requestAs[*ZeusStatus](ctx, &z.node, ZeusStatusMessage)
+}
+
+func (z *Zeus) ControlPoint(ctx context.Context) (*ZeusControlPoint, error) {
+	return requestAs[*ZeusControlPoint](ctx, &z.node, ZeusControlPointMessage)
+}
+
+func (z *Zeus) Deltas(ctx context.Context) (*ZeusDeltaTemperature, error) {
+	return requestAs[*ZeusDeltaTemperature](ctx, &z.node, ZeusDeltaTemperatureMessage)
+}
+
+type Helios struct {
+	node
+}
+
+func NewHelios(bus Bus, ID NodeID) *Helios {
+	return &Helios{node{bus: bus, class: HeliosClass, ID: ID}}
+}
+
+func (h *Helios) SetSetPoint(m HeliosSetPoint) error {
+	return h.send(&m)
+}
+
+func (h *Helios) SetPulseMode(m HeliosPulseMode) error {
+	return h.send(&m)
+}
+
+func (h *Helios) SetTriggerMode(m HeliosTriggerMode) error {
+	return h.send(&m)
+}
+
+func (h *Helios) SetPoint(ctx context.Context) (*HeliosSetPoint, error) {
+	return requestAs[*HeliosSetPoint](ctx, &h.node, HeliosSetPointMessage)
+}
+
+func (h *Helios) PulseMode(ctx context.Context) (*HeliosPulseMode, error) {
+	return requestAs[*HeliosPulseMode](ctx, &h.node, HeliosPulseModeMessage)
+}
+
+func (h *Helios) TriggerMode(ctx context.Context) (*HeliosTriggerMode, error) {
+	return requestAs[*HeliosTriggerMode](ctx, &h.node, HeliosTriggerModeMessage)
+}
+
+type Celaeno struct {
+	node
+}
+
+func NewCelaeno(bus Bus, ID NodeID) *Celaeno {
+	return &Celaeno{node{bus: bus, class: CelaenoClass, ID: ID}}
+}
+
+func (c *Celaeno) SetSetPoint(m CelaenoSetPoint) error {
+	return c.send(&m)
+}
+
+func (c *Celaeno) SetConfig(m CelaenoConfig) error {
+	return c.send(&m)
+}
+
+func (c *Celaeno) SetPoint(ctx context.Context) (*CelaenoSetPoint, error) {
+	return requestAs[*CelaenoSetPoint](ctx, &c.node, CelaenoSetPointMessage)
+}
+
+func (c *Celaeno) Status(ctx context.Context) (*CelaenoStatus, error) {
+	return requestAs[*CelaenoStatus](ctx, &c.node, CelaenoStatusMessage)
+}
+
+func (c *Celaeno) Config(ctx context.Context) (*CelaenoConfig, error) {
+	return requestAs[*CelaenoConfig](ctx, &c.node, CelaenoConfigMessage)
+}
+
+type Notus struct {
+	node
+}
+
+func NewNotus(bus Bus, ID NodeID) *Notus {
+	return &Notus{node{bus: bus, class: NotusClass, ID: ID}}
+}
+
+func (n *Notus) SetSetPoint(m NotusSetPoint) error {
+	return n.send(&m)
+}
+
+func (n *Notus) SetConfig(m NotusConfig) error {
+	return n.send(&m)
+}
+
+func (n *Notus) SetPoint(ctx context.Context) (*NotusSetPoint, error) {
+	return requestAs[*NotusSetPoint](ctx, &n.node, NotusSetPointMessage)
+}
+
+func (n *Notus) Config(ctx context.Context) (*NotusConfig, error) {
+	return requestAs[*NotusConfig](ctx, &n.node, NotusConfigMessage)
+}
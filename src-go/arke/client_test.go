@@ -0,0 +1,136 @@
+package arke
+
+import (
+	"context"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ClientSuite struct {
+	loopback *Loopback
+	host     Bus
+	spy      Bus
+}
+
+var _ = Suite(&ClientSuite{})
+
+func (s *ClientSuite) SetUpTest(c *C) {
+	s.loopback = NewLoopback()
+	s.host = s.loopback.Endpoint()
+	s.spy = s.loopback.Endpoint()
+}
+
+func (s *ClientSuite) TearDownTest(c *C) {
+	s.loopback.Close()
+}
+
+// resetSpy discards all frames previously seen by the spy.
+func (s *ClientSuite) resetSpy() {
+	s.spy.Close()
+	s.spy = s.loopback.Endpoint()
+}
+
+func (s *ClientSuite) checkSent(c *C, t MessageType, m ReceivableMessage, ID NodeID) {
+	f, err := receiveWithin(s.spy, time.Second)
+	c.Assert(err, IsNil)
+	parsed, pID, err := ParseMessage(&f)
+	c.Assert(err, IsNil)
+	mType, _, _ := ExtractCANIDT(f.ID)
+	c.Check(mType, Equals, t)
+	c.Check(pID, Equals, ID)
+	c.Check(parsed, DeepEquals, m)
+}
+
+func (s *ClientSuite) TestZeus(c *C) {
+	report := &ZeusReport{Humidity: 0, Temperature: [4]float32{-40, 1, 2, 3}}
+	status := &ZeusStatus{Status: ZeusActive, Fans: [3]FanStatusAndRPM{1200, 1300, 1400}}
+	control := &ZeusControlPoint{Humidity: 100, Temperature: -200}
+	n := startFakeNode(s.loopback, 3, report, status, control)
+	defer n.stop()
+
+	z := NewZeus(s.host, 3)
+	ctx := context.Background()
+
+	r, err := z.Report(ctx)
+	c.Check(err, IsNil)
+	c.Check(r, DeepEquals, report)
+	st, err := z.Status(ctx)
+	c.Check(err, IsNil)
+	c.Check(st, DeepEquals, status)
+	cp, err := z.ControlPoint(ctx)
+	c.Check(err, IsNil)
+	c.Check(cp, DeepEquals, control)
+
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = z.Config(tctx)
+	c.Check(err, ErrorMatches, "No Zeus.Config reply from node 3: .*")
+
+	s.resetSpy()
+
+	c.Check(z.SetSetPoint(ZeusSetPoint{Humidity: 0, Temperature: -40, Wind: 12}), IsNil)
+	s.checkSent(c, StandardMessage, &ZeusSetPoint{Humidity: 0, Temperature: -40, Wind: 12}, 3)
+	c.Check(z.SetDeltas(ZeusDeltaTemperature{Delta: [4]float32{0, 0.5, 0, 0}}), IsNil)
+	s.checkSent(c, StandardMessage, &ZeusDeltaTemperature{Delta: [4]float32{0, 0.5, 0, 0}}, 3)
+	c.Check(z.SetConfig(ZeusConfig{Humidity: PDConfig{DividerPower: 16}}), ErrorMatches, "Could not marshall .*")
+}
+
+func (s *ClientSuite) TestHelios(c *C) {
+	n := startFakeNode(s.loopback, 1, &HeliosSetPoint{Visible: 12, UV: 34})
+	defer n.stop()
+	h := NewHelios(s.host, 1)
+
+	sp, err := h.SetPoint(context.Background())
+	c.Check(err, IsNil)
+	c.Check(sp, DeepEquals, &HeliosSetPoint{Visible: 12, UV: 34})
+	s.resetSpy()
+
+	mode := HeliosTriggerMode{Period: 100 * time.Millisecond, PulseLength: 2 * time.Millisecond}
+	c.Check(h.SetTriggerMode(mode), IsNil)
+	s.checkSent(c, StandardMessage, &mode, 1)
+}
+
+func (s *ClientSuite) TestCelaeno(c *C) {
+	config := &CelaenoConfig{time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second}
+	n := startFakeNode(s.loopback, 2, config, &CelaenoStatus{WaterLevel: CelaenoWaterWarning})
+	defer n.stop()
+	cl := NewCelaeno(s.host, 2)
+
+	cf, err := cl.Config(context.Background())
+	c.Check(err, IsNil)
+	c.Check(cf, DeepEquals, config)
+	st, err := cl.Status(context.Background())
+	c.Check(err, IsNil)
+	c.Check(st.WaterLevel, Equals, CelaenoWaterWarning)
+	s.resetSpy()
+
+	c.Check(cl.SetSetPoint(CelaenoSetPoint{Power: 42}), IsNil)
+	s.checkSent(c, StandardMessage, &CelaenoSetPoint{Power: 42}, 2)
+}
+
+func (s *ClientSuite) TestNotus(c *C) {
+	config := &NotusConfig{RampDownTime: time.Second, MinFan: 10, MaxHeat: 100}
+	n := startFakeNode(s.loopback, 4, config)
+	defer n.stop()
+	nt := NewNotus(s.host, 4)
+
+	cf, err := nt.Config(context.Background())
+	c.Check(err, IsNil)
+	c.Check(cf, DeepEquals, config)
+	s.resetSpy()
+
+	c.Check(nt.SetConfig(*config), IsNil)
+	s.checkSent(c, StandardMessage, config, 4)
+}
+
+func (s *ClientSuite) TestNetworkCommands(c *C) {
+	h := NewHelios(s.host, 2)
+	c.Check(h.Reset(), IsNil)
+	s.checkSent(c, NetworkControlCommand, &ResetRequestData{Class: HeliosClass, ID: 2}, 2)
+
+	c.Check(h.ChangeID(8), ErrorMatches, "Invalid device ID 8 .*")
+	c.Check(h.ChangeID(5), IsNil)
+	s.checkSent(c, NetworkControlCommand, &IDChangeRequestData{Class: HeliosClass, Old: 2, New: 5}, 2)
+	c.Check(h.ID, Equals, NodeID(5))
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"slices"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+	"github.com/formicidae-tracker/libarke/src-go/arke"
+)
+
+type NodeClass arke.NodeClass
+
+func (c *NodeClass) UnmarshalFlag(value string) error {
+	return (*arke.NodeClass)(c).UnmarshalText([]byte(value))
+}
+
+// frameType returns the type of a frame as selected by --type.
+func frameType(f *socketcan.CanFrame) string {
+	if f.RTR == true {
+		return "rtr"
+	}
+	t, _, _ := arke.ExtractCANIDT(f.ID)
+	switch t {
+	case arke.NetworkControlCommand:
+		return "network"
+	case arke.HighPriorityMessage:
+		return "priority"
+	case arke.HeartBeat:
+		return "heartbeat"
+	}
+	return "standard"
+}
+
+// filter selects the printed frames. A frame is printed if it matches
+// include and types, and none of the exclusions.
+type filter struct {
+	include      arke.FrameFilter
+	types        []string
+	exclude      []arke.FrameFilter
+	excludeTypes []string
+}
+
+func classes(values []NodeClass) []arke.NodeClass {
+	res := make([]arke.NodeClass, 0, len(values))
+	for _, c := range values {
+		res = append(res, arke.NodeClass(c))
+	}
+	return res
+}
+
+func (o *Options) filter() (*filter, error) {
+	for _, name := range append(slices.Clone(o.Messages), o.ExcludeMessages...) {
+		if _, err := arke.NewMessageByName(name); err != nil {
+			return nil, err
+		}
+	}
+	res := &filter{
+		include:      arke.FrameFilter{Classes: classes(o.Classes), IDs: o.IDs, Messages: o.Messages},
+		types:        o.Types,
+		excludeTypes: o.ExcludeTypes,
+	}
+	// each exclusion list is matched on its own
+	for _, exclude := range []arke.FrameFilter{
+		{Classes: classes(o.ExcludeClasses)},
+		{IDs: o.ExcludeIDs},
+		{Messages: o.ExcludeMessages},
+	} {
+		if len(exclude.Classes) > 0 || len(exclude.IDs) > 0 || len(exclude.Messages) > 0 {
+			res.exclude = append(res.exclude, exclude)
+		}
+	}
+	return res, nil
+}
+
+func (f *filter) match(frame *socketcan.CanFrame) bool {
+	t := frameType(frame)
+	if len(f.types) > 0 && slices.Contains(f.types, t) == false {
+		return false
+	}
+	if slices.Contains(f.excludeTypes, t) == true {
+		return false
+	}
+	if f.include.Match(frame) == false {
+		return false
+	}
+	for _, exclude := range f.exclude {
+		if exclude.Match(frame) == true {
+			return false
+		}
+	}
+	return true
+}
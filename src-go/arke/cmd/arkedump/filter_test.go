@@ -0,0 +1,114 @@
+package main
+
+import (
+	socketcan "github.com/atuleu/golang-socketcan"
+	"github.com/formicidae-tracker/libarke/src-go/arke"
+	. "gopkg.in/check.v1"
+)
+
+type FilterSuite struct {
+	Frames []socketcan.CanFrame
+}
+
+var _ = Suite(&FilterSuite{})
+
+type frameSink []socketcan.CanFrame
+
+func (s *frameSink) Send(f socketcan.CanFrame) error {
+	*s = append(*s, f)
+	return nil
+}
+
+func (s *FilterSuite) SetUpSuite(c *C) {
+	sink := frameSink{}
+	c.Assert(arke.SendMessage(&sink, &arke.ZeusReport{}, false, 1), IsNil)
+	c.Assert(arke.SendMessage(&sink, &arke.HeliosSetPoint{}, false, 2), IsNil)
+	sink.Send(arke.MakeHeartBeat(arke.ZeusClass, 1, arke.FirmwareVersion{}))
+	c.Assert(arke.SendMessage(&sink, &arke.CelaenoStatus{}, true, 3), IsNil)
+	c.Assert(arke.RequestMessage(&sink, &arke.ZeusReport{}, 2), IsNil)
+	sink.Send(arke.MakeResetRequest(arke.ZeusClass, 4))
+	s.Frames = sink
+}
+
+func (s *FilterSuite) TestFrameType(c *C) {
+	expected := []string{"standard", "standard", "heartbeat", "priority", "rtr", "network"}
+	for i, f := range s.Frames {
+		c.Check(frameType(&f), Equals, expected[i])
+	}
+}
+
+func (s *FilterSuite) TestMatch(c *C) {
+	testdata := []struct {
+		Options  Options
+		Expected []bool
+	}{
+		{
+			Options{},
+			[]bool{true, true, true, true, true, true},
+		},
+		{
+			Options{Types: []string{"standard", "network"}},
+			[]bool{true, true, false, false, false, true},
+		},
+		{
+			Options{Classes: []NodeClass{NodeClass(arke.ZeusClass)}},
+			[]bool{true, false, true, false, true, true},
+		},
+		{
+			Options{IDs: []arke.NodeID{1, 3}},
+			[]bool{true, false, true, true, false, false},
+		},
+		{
+			Options{Messages: []string{"Zeus.Report", "arke.HeartBeat"}},
+			[]bool{true, false, true, false, false, false},
+		},
+		{
+			Options{Classes: []NodeClass{NodeClass(arke.ZeusClass)}, IDs: []arke.NodeID{2}},
+			[]bool{false, false, false, false, true, false},
+		},
+		{
+			Options{ExcludeTypes: []string{"rtr", "heartbeat"}},
+			[]bool{true, true, false, true, false, true},
+		},
+		{
+			Options{ExcludeClasses: []NodeClass{NodeClass(arke.ZeusClass)}},
+			[]bool{false, true, false, true, false, false},
+		},
+		{
+			Options{ExcludeIDs: []arke.NodeID{1, 4}},
+			[]bool{false, true, false, true, true, false},
+		},
+		{
+			Options{ExcludeMessages: []string{"Helios.SetPoint"}},
+			[]bool{true, false, true, true, true, true},
+		},
+		{
+			// each exclusion list is applied on its own
+			Options{
+				Classes:         []NodeClass{NodeClass(arke.ZeusClass)},
+				ExcludeIDs:      []arke.NodeID{4},
+				ExcludeMessages: []string{"Zeus.Report"},
+			},
+			[]bool{false, false, true, false, true, false},
+		},
+	}
+
+	for _, d := range testdata {
+		f, err := d.Options.filter()
+		c.Assert(err, IsNil)
+		for i, frame := range s.Frames {
+			c.Check(f.match(&frame), Equals, d.Expected[i],
+				Commentf("options: %+v, frame %d: %s", d.Options, i, frameType(&frame)))
+		}
+	}
+}
+
+func (s *FilterSuite) TestUnknownMessage(c *C) {
+	for _, opts := range []Options{
+		{Messages: []string{"Zeus.Foo"}},
+		{ExcludeMessages: []string{"Zeus.Foo"}},
+	} {
+		_, err := opts.filter()
+		c.Check(err, ErrorMatches, "Unknown message class 'Zeus.Foo'")
+	}
+}
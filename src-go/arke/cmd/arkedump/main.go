@@ -1,24 +1,110 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
-	"syscall"
 	"time"
 
-	socketcan "github.com/atuleu/golang-socketcan"
 	"github.com/formicidae-tracker/libarke/src-go/arke"
 	"github.com/jessevdk/go-flags"
 	"golang.org/x/term"
 )
 
+type CaptureFormat arke.CaptureFormat
+
+func (f *CaptureFormat) UnmarshalFlag(value string) error {
+	return (*arke.CaptureFormat)(f).UnmarshalText([]byte(value))
+}
+
 type Options struct {
 	Args struct {
 		Intf arke.CANInterfaceName
-	} `positional-args:"yes" required:"yes"`
-	NoColor bool `long:"no-color"`
+	} `positional-args:"yes"`
+	NoColor bool           `long:"no-color"`
+	Write   flags.Filename `long:"write" short:"w" description:"Also record received frames to this capture file"`
+	Format  CaptureFormat  `long:"format" short:"f" default:"pcapng" choice:"candump" choice:"binary" choice:"pcapng" description:"Format of the recorded capture"`
+	Convert flags.Filename `long:"convert" description:"Convert this existing capture to the --write file instead of opening an interface"`
+
+	Read []flags.Filename `long:"read" short:"r" description:"Decode this capture instead of opening an interface, '-' reads the standard input. Accepts candump -l logs, bare IDT#DATA lines, binary and pcapng captures"`
+
+	Output      string        `long:"output-format" short:"O" default:"text" choice:"text" choice:"jsonl" choice:"csv" choice:"candump" description:"Format of the printed frames"`
+	Stats       bool          `long:"stats" short:"s" description:"Print per message rates, inter-arrival times, parse errors and bus load instead of frames, when interrupted or at the end of the captures"`
+	StatsPeriod time.Duration `long:"stats-period" description:"Also print the statistics every period while reading an interface, e.g. 10s"`
+
+	ErrorCatalogs []flags.Filename `long:"error-catalog" short:"E" description:"Describe error codes with this JSON catalog, a list of {Class, Code, Name, Severity, Description} objects"`
+
+	Classes         []NodeClass   `long:"class" short:"c" description:"Only print frames about this node class"`
+	IDs             []arke.NodeID `long:"id" short:"I" description:"Only print frames about this node ID"`
+	Messages        []string      `long:"message" short:"m" description:"Only print this message, e.g. Zeus.Report"`
+	Types           []string      `long:"type" short:"t" choice:"heartbeat" choice:"network" choice:"priority" choice:"standard" choice:"rtr" description:"Only print frames of this type"`
+	ExcludeClasses  []NodeClass   `long:"exclude-class" description:"Do not print frames about this node class"`
+	ExcludeIDs      []arke.NodeID `long:"exclude-id" description:"Do not print frames about this node ID"`
+	ExcludeMessages []string      `long:"exclude-message" description:"Do not print this message"`
+	ExcludeTypes    []string      `long:"exclude-type" choice:"heartbeat" choice:"network" choice:"priority" choice:"standard" choice:"rtr" description:"Do not print frames of this type"`
+}
+
+func (o *Options) recorder() (*arke.Recorder, func() error, error) {
+	file, err := os.Create(string(o.Write))
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := arke.NewRecorder(file, arke.CaptureFormat(o.Format))
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return r, func() error {
+		if err := r.Flush(); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}, nil
+}
+
+func (o *Options) loadErrorCatalogs() error {
+	for _, name := range o.ErrorCatalogs {
+		file, err := os.Open(string(name))
+		if err != nil {
+			return err
+		}
+		err = arke.LoadErrorCatalog(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func convert(opts *Options) error {
+	if len(opts.Write) == 0 {
+		return fmt.Errorf("--convert requires a --write file")
+	}
+	file, err := openCapture(string(opts.Convert))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	p, err := arke.NewPlayer(file)
+	if err != nil {
+		return err
+	}
+	r, closeRecorder, err := opts.recorder()
+	if err != nil {
+		return err
+	}
+	n, err := r.Copy(p)
+	if err != nil {
+		closeRecorder()
+		return fmt.Errorf("%s: %w", opts.Convert, err)
+	}
+	log.Printf("Converted %d frames from %s to %s", n, opts.Convert, opts.Write)
+	return closeRecorder()
 }
 
 func execute() error {
@@ -38,114 +124,136 @@ func execute() error {
 		return err
 	}
 
+	if len(opts.Convert) > 0 {
+		return convert(opts)
+	}
+	if len(opts.Args.Intf) == 0 && len(opts.Read) == 0 {
+		parser.WriteHelp(os.Stderr)
+		return nil
+	}
+
 	if opts.NoColor == true || term.IsTerminal(int(os.Stdout.Fd())) == false {
 		for k := range colorCodes {
 			colorCodes[k] = ""
 		}
 	}
 
-	intf, err := socketcan.NewRawInterface(string(opts.Args.Intf))
+	if err := opts.loadErrorCatalogs(); err != nil {
+		return err
+	}
+
+	filter, err := opts.filter()
 	if err != nil {
 		return err
 	}
+	var out output
+	if opts.Stats == true {
+		out = newStatsOutput(os.Stdout)
+	} else if out, err = newOutput(opts.Output, os.Stdout); err != nil {
+		return err
+	}
+
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt)
+
+	if len(opts.Read) > 0 {
+		return offline(opts, sigint, filter, out)
+	}
 
-	frames := make(chan socketcan.CanFrame, 10)
+	bus, err := arke.OpenSocketCANBus(string(opts.Args.Intf))
+	if err != nil {
+		return err
+	}
+
+	var recorder *arke.Recorder
+	closeRecorder := func() error { return nil }
+	if len(opts.Write) > 0 {
+		recorder, closeRecorder, err = opts.recorder()
+		if err != nil {
+			return err
+		}
+	}
+
+	records := make(chan arke.Record, 10)
 	go func() {
-		defer close(frames)
+		defer close(records)
 		for {
-			f, err := intf.Receive()
+			f, err := bus.Receive(context.Background())
 			if err != nil {
-				if errno, ok := err.(syscall.Errno); ok == true &&
-					(errno == syscall.EBADF ||
-						errno == syscall.ENETDOWN ||
-						errno == syscall.ENODEV) {
-					log.Printf("Closed CAN Interface: %s", err)
+				if errors.Is(err, arke.ErrBusClosed) == true {
+					log.Printf("Closed CAN Interface")
 					return
 				}
 				log.Printf("Could not receive CAN frame: %s", err)
 				continue
 			}
-			frames <- f
+			record := arke.Record{Time: time.Now(), Interface: string(opts.Args.Intf), Frame: f}
+			// filters only apply to the printed frames
+			if recorder != nil {
+				if err := recorder.Write(record); err != nil {
+					log.Printf("Could not record CAN frame: %s", err)
+				}
+			}
+			records <- record
 		}
 
 	}()
 
-	go func() {
-		sigint := make(chan os.Signal, 1)
-		signal.Notify(sigint, os.Interrupt)
-		<-sigint
-		os.Exit(0)
-	}()
-
-	for f := range frames {
-		m, _, err := arke.ParseMessage(&f)
-		if err != nil {
-			log.Printf("Could not parse CAN Frame: %s", err)
-		} else {
-			formatMessage(m, f.ID, f.RTR)
-		}
-	}
-	return nil
-}
-
-const (
-	HEARTBEAT_HEADER int = iota
-	HEARTBEAT
-	NETWORK_COMMAND_HEADER
-	NETWORK_COMMAND
-	STANDARD_MESSAGE_HEADER
-	STANDARD_MESSAGE
-	PRIORITY_MESSAGE_HEADER
-	PRIORITY_MESSAGE
-	REQUEST_HEADER
-	REQUEST
-)
-
-var colorCodes = map[int]string{
-	HEARTBEAT_HEADER:        "\033[30;45m",
-	HEARTBEAT:               "\033[35;49m",
-	NETWORK_COMMAND_HEADER:  "\033[30;46m",
-	NETWORK_COMMAND:         "\033[36;49m",
-	STANDARD_MESSAGE_HEADER: "\033[30;47m",
-	STANDARD_MESSAGE:        "\033[m",
-	PRIORITY_MESSAGE_HEADER: "\033[39;41m",
-	PRIORITY_MESSAGE:        "\033[31;49m",
-	REQUEST_HEADER:          "\033[30;43m",
-	REQUEST:                 "\033[33;49m",
-}
-
-func formatMessage(m arke.ReceivableMessage, idt uint32, RTR bool) {
-	now := time.Now().Format(time.RFC3339Nano)
-	tpe, cls, id := arke.ExtractCANIDT(idt)
-	var header, message int
-	switch tpe {
-	case arke.StandardMessage:
-		header, message = STANDARD_MESSAGE_HEADER, STANDARD_MESSAGE
-	case arke.HighPriorityMessage:
-		header, message = PRIORITY_MESSAGE_HEADER, PRIORITY_MESSAGE
-	default:
+	var refresh <-chan time.Time
+	if opts.Stats == true && opts.StatsPeriod > 0 {
+		ticker := time.NewTicker(opts.StatsPeriod)
+		defer ticker.Stop()
+		refresh = ticker.C
 	}
 
-	if RTR == true {
-		fmt.Printf("%s%s%s %s ID:%d\n", colorCodes[REQUEST_HEADER], now, colorCodes[REQUEST], cls, id)
-		return
-	}
-
-	if tpe == arke.NetworkControlCommand {
-		fmt.Printf("%s%s%s %s\n", colorCodes[NETWORK_COMMAND_HEADER], now, colorCodes[NETWORK_COMMAND], m)
-		return
+	err = dump(records, sigint, refresh, filter, out)
+	bus.Close()
+	if rerr := closeRecorder(); rerr != nil {
+		return fmt.Errorf("Could not write %s: %w", opts.Write, rerr)
 	}
+	return err
+}
 
-	if tpe == arke.HeartBeat {
-		fmt.Printf("%s%s%s %s\n", colorCodes[HEARTBEAT_HEADER], now, colorCodes[HEARTBEAT], m)
-		return
+// dump decodes and prints the records selected by filter, until
+// records is closed or an interrupt is received. Outputs implementing
+// refresher are refreshed on each tick of refresh.
+func dump(records <-chan arke.Record, interrupt <-chan os.Signal, refresh <-chan time.Time, filter *filter, out output) error {
+	decoder := arke.NewDecoder()
+	observer, _ := out.(busObserver)
+	for {
+		select {
+		case <-interrupt:
+			return out.Flush()
+		case now := <-refresh:
+			if r, ok := out.(refresher); ok == true {
+				if err := r.refresh(now); err != nil {
+					return err
+				}
+			}
+		case r, ok := <-records:
+			if ok == false {
+				return out.Flush()
+			}
+			if observer != nil {
+				observer.observe(&r)
+			}
+			if filter.match(&r.Frame) == false {
+				continue
+			}
+			m, _, err := decoder.Decode(&r.Frame)
+			if err := out.Write(&r, m, err); err != nil {
+				return err
+			}
+		}
 	}
-
-	fmt.Printf("%s%s%s ID:%d %s\n", colorCodes[header], now, colorCodes[message], id, m)
 }
 
 func main() {
 	if err := execute(); err != nil {
+		// go-flags already reports its own errors
+		if _, ok := err.(*flags.Error); ok == false {
+			log.Printf("%s", err)
+		}
 		os.Exit(1)
 	}
 }
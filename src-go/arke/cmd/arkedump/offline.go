@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/formicidae-tracker/libarke/src-go/arke"
+)
+
+// openCapture opens a capture file, or the standard input for "-".
+func openCapture(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
+
+// captureInterface names the interface of the records read from a
+// capture without one: "stdin" for "-", or the file name.
+func captureInterface(name string) string {
+	if name == "-" {
+		return "stdin"
+	}
+	return strings.Join(strings.Fields(filepath.Base(name)), "_")
+}
+
+// readCapture sends all the records of a capture. Bare IDT#DATA lines
+// are stamped with their reading time and named after the capture.
+func readCapture(name string, records chan<- arke.Record) error {
+	file, err := openCapture(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	p, err := arke.NewPlayer(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	for {
+		r, err := p.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if r.Time.IsZero() == true {
+			r.Time = time.Now()
+		}
+		if len(r.Interface) == 0 {
+			r.Interface = captureInterface(name)
+		}
+		records <- r
+	}
+}
+
+// offline prints the frames of the --read captures, in order.
+func offline(opts *Options, interrupt <-chan os.Signal, filter *filter, out output) error {
+	if len(opts.Args.Intf) > 0 {
+		return fmt.Errorf("Cannot read captures and interface %s at once", opts.Args.Intf)
+	}
+	if len(opts.Write) > 0 {
+		return fmt.Errorf("--write cannot be used with --read, use --convert instead")
+	}
+
+	records := make(chan arke.Record, 10)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(records)
+		for _, name := range opts.Read {
+			if err := readCapture(string(name), records); err != nil {
+				errs <- err
+				return
+			}
+		}
+		errs <- nil
+	}()
+
+	if err := dump(records, interrupt, nil, filter, out); err != nil {
+		return err
+	}
+	// the reader may still wait for its input when interrupted
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/formicidae-tracker/libarke/src-go/arke"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type OfflineSuite struct {
+	Capture string
+}
+
+var _ = Suite(&OfflineSuite{})
+
+func (s *OfflineSuite) SetUpTest(c *C) {
+	s.Capture = filepath.Join(c.MkDir(), "bare capture.log")
+	err := os.WriteFile(s.Capture, []byte("5C1#A40FA21F80\n5CA#R\n"), 0644)
+	c.Assert(err, IsNil)
+}
+
+func (s *OfflineSuite) read(c *C) []arke.Record {
+	records := make(chan arke.Record, 10)
+	c.Assert(readCapture(s.Capture, records), IsNil)
+	close(records)
+	var res []arke.Record
+	for r := range records {
+		res = append(res, r)
+	}
+	return res
+}
+
+func (s *OfflineSuite) TestCaptureInterface(c *C) {
+	c.Check(captureInterface("-"), Equals, "stdin")
+	c.Check(captureInterface("/tmp/can0.log"), Equals, "can0.log")
+	c.Check(captureInterface(s.Capture), Equals, "bare_capture.log")
+}
+
+func (s *OfflineSuite) TestBareLinesInAllFormats(c *C) {
+	records := s.read(c)
+	c.Assert(records, HasLen, 2)
+	for _, r := range records {
+		c.Check(r.Interface, Equals, "bare_capture.log")
+		c.Check(r.Time.IsZero(), Equals, false)
+	}
+
+	for _, format := range []string{"text", "jsonl", "csv", "candump", "stats"} {
+		comment := Commentf("format: %s", format)
+		var buf bytes.Buffer
+		var out output
+		if format == "stats" {
+			out = newStatsOutput(&buf)
+		} else {
+			var err error
+			out, err = newOutput(format, &buf)
+			c.Assert(err, IsNil, comment)
+		}
+		in := make(chan arke.Record, len(records))
+		for _, r := range records {
+			in <- r
+		}
+		close(in)
+		c.Check(dump(in, nil, nil, &filter{}, out), IsNil, comment)
+		c.Check(buf.Len() > 0, Equals, true, comment)
+	}
+}
+
+func (s *OfflineSuite) TestBareLinesToCandump(c *C) {
+	var buf bytes.Buffer
+	out, err := newOutput("candump", &buf)
+	c.Assert(err, IsNil)
+	for _, r := range s.read(c) {
+		c.Assert(out.Write(&r, nil, nil), IsNil)
+	}
+	c.Check(buf.String(), Matches,
+		`\(\d+\.\d{6}\) bare_capture\.log 5C1#A40FA21F80\n`+
+			`\(\d+\.\d{6}\) bare_capture\.log 5CA#R\n`)
+}
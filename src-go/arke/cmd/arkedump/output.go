@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/formicidae-tracker/libarke/src-go/arke"
+)
+
+// output prints received frames. m is the decoded message, or nil
+// with the decoding error.
+type output interface {
+	Write(r *arke.Record, m arke.ReceivableMessage, err error) error
+	Flush() error
+}
+
+// busObserver is implemented by outputs accounting for every frame
+// on the bus, before filtering.
+type busObserver interface {
+	observe(r *arke.Record)
+}
+
+// refresher is implemented by outputs printing periodically.
+type refresher interface {
+	refresh(now time.Time) error
+}
+
+func newOutput(format string, w io.Writer) (output, error) {
+	switch format {
+	case "text":
+		return &textOutput{w: w}, nil
+	case "jsonl":
+		return &jsonOutput{encoder: json.NewEncoder(w)}, nil
+	case "csv":
+		return newCSVOutput(w)
+	case "candump":
+		r, err := arke.NewRecorder(w, arke.CandumpFormat)
+		if err != nil {
+			return nil, err
+		}
+		return &candumpOutput{recorder: r}, nil
+	}
+	return nil, fmt.Errorf("Unknown output format '%s'", format)
+}
+
+const (
+	HEARTBEAT_HEADER int = iota
+	HEARTBEAT
+	NETWORK_COMMAND_HEADER
+	NETWORK_COMMAND
+	STANDARD_MESSAGE_HEADER
+	STANDARD_MESSAGE
+	PRIORITY_MESSAGE_HEADER
+	PRIORITY_MESSAGE
+	REQUEST_HEADER
+	REQUEST
+)
+
+var colorCodes = map[int]string{
+	HEARTBEAT_HEADER:        "\033[30;45m",
+	HEARTBEAT:               "\033[35;49m",
+	NETWORK_COMMAND_HEADER:  "\033[30;46m",
+	NETWORK_COMMAND:         "\033[36;49m",
+	STANDARD_MESSAGE_HEADER: "\033[30;47m",
+	STANDARD_MESSAGE:        "\033[m",
+	PRIORITY_MESSAGE_HEADER: "\033[39;41m",
+	PRIORITY_MESSAGE:        "\033[31;49m",
+	REQUEST_HEADER:          "\033[30;43m",
+	REQUEST:                 "\033[33;49m",
+}
+
+type textOutput struct {
+	w io.Writer
+}
+
+func (o *textOutput) Write(r *arke.Record, m arke.ReceivableMessage, err error) error {
+	if err != nil {
+		log.Printf("Could not parse CAN Frame: %s", err)
+		return nil
+	}
+	return formatMessage(o.w, r.Time, m, r.Frame.ID, r.Frame.RTR)
+}
+
+func (o *textOutput) Flush() error {
+	return nil
+}
+
+func formatMessage(w io.Writer, t time.Time, m arke.ReceivableMessage, idt uint32, RTR bool) error {
+	now := t.Format(time.RFC3339Nano)
+	tpe, cls, id := arke.ExtractCANIDT(idt)
+	var header, message int
+	switch tpe {
+	case arke.StandardMessage:
+		header, message = STANDARD_MESSAGE_HEADER, STANDARD_MESSAGE
+	case arke.HighPriorityMessage:
+		header, message = PRIORITY_MESSAGE_HEADER, PRIORITY_MESSAGE
+	default:
+	}
+
+	var err error
+	switch {
+	case RTR == true:
+		_, err = fmt.Fprintf(w, "%s%s%s %s ID:%d\n", colorCodes[REQUEST_HEADER], now, colorCodes[REQUEST], cls, id)
+	case tpe == arke.NetworkControlCommand:
+		_, err = fmt.Fprintf(w, "%s%s%s %s\n", colorCodes[NETWORK_COMMAND_HEADER], now, colorCodes[NETWORK_COMMAND], m)
+	case tpe == arke.HeartBeat:
+		_, err = fmt.Fprintf(w, "%s%s%s %s\n", colorCodes[HEARTBEAT_HEADER], now, colorCodes[HEARTBEAT], m)
+	default:
+		_, err = fmt.Fprintf(w, "%s%s%s ID:%d %s\n", colorCodes[header], now, colorCodes[message], id, m)
+	}
+	return err
+}
+
+// frameJSON is a line of the jsonl output.
+type frameJSON struct {
+	Time      time.Time
+	Interface string
+	IDT       uint32
+	Type      arke.MessageType
+	RTR       bool
+	Node      arke.NodeClass
+	ID        arke.NodeID
+	Data      string
+	Message   json.RawMessage `json:",omitempty"`
+	Error     string          `json:",omitempty"`
+}
+
+func frameData(r *arke.Record) string {
+	return hex.EncodeToString(r.Frame.Data[:min(int(r.Frame.Dlc), len(r.Frame.Data))])
+}
+
+func newFrameJSON(r *arke.Record, m arke.ReceivableMessage, err error) frameJSON {
+	tpe, _, _ := arke.ExtractCANIDT(r.Frame.ID)
+	node, ID := arke.FrameNode(&r.Frame)
+	res := frameJSON{
+		Time:      r.Time,
+		Interface: r.Interface,
+		IDT:       r.Frame.ID,
+		Type:      tpe,
+		RTR:       r.Frame.RTR,
+		Node:      node,
+		ID:        ID,
+		Data:      frameData(r),
+	}
+	if err == nil {
+		res.Message, err = arke.MarshalMessageJSON(m)
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+type jsonOutput struct {
+	encoder *json.Encoder
+}
+
+func (o *jsonOutput) Write(r *arke.Record, m arke.ReceivableMessage, err error) error {
+	return o.encoder.Encode(newFrameJSON(r, m, err))
+}
+
+func (o *jsonOutput) Flush() error {
+	return nil
+}
+
+// csvOutput writes a header, then one row per frame. The Message
+// column holds the message name and the Value column its JSON
+// encoded fields.
+type csvOutput struct {
+	w *csv.Writer
+}
+
+func newCSVOutput(w io.Writer) (*csvOutput, error) {
+	res := &csvOutput{w: csv.NewWriter(w)}
+	err := res.w.Write([]string{"Time", "Interface", "IDT", "Type", "RTR", "Node", "ID", "Data", "Message", "Value", "Error"})
+	if err != nil {
+		return nil, err
+	}
+	return res, res.Flush()
+}
+
+func (o *csvOutput) Write(r *arke.Record, m arke.ReceivableMessage, err error) error {
+	f := newFrameJSON(r, m, err)
+	var message struct {
+		Class string
+		Data  json.RawMessage
+	}
+	if len(f.Message) > 0 {
+		if err := json.Unmarshal(f.Message, &message); err != nil {
+			return err
+		}
+	}
+	err = o.w.Write([]string{
+		f.Time.Format(time.RFC3339Nano),
+		f.Interface,
+		fmt.Sprintf("0x%03x", f.IDT),
+		f.Type.String(),
+		strconv.FormatBool(f.RTR),
+		f.Node.String(),
+		strconv.Itoa(int(f.ID)),
+		f.Data,
+		message.Class,
+		string(message.Data),
+		f.Error,
+	})
+	if err != nil {
+		return err
+	}
+	return o.Flush()
+}
+
+func (o *csvOutput) Flush() error {
+	o.w.Flush()
+	return o.w.Error()
+}
+
+type candumpOutput struct {
+	recorder *arke.Recorder
+}
+
+func (o *candumpOutput) Write(r *arke.Record, _ arke.ReceivableMessage, _ error) error {
+	if err := o.recorder.Write(*r); err != nil {
+		return err
+	}
+	return o.recorder.Flush()
+}
+
+func (o *candumpOutput) Flush() error {
+	return o.recorder.Flush()
+}
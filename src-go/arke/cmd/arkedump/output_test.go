@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+	"github.com/formicidae-tracker/libarke/src-go/arke"
+	. "gopkg.in/check.v1"
+)
+
+type OutputSuite struct {
+	Records []arke.Record
+}
+
+var _ = Suite(&OutputSuite{})
+
+func (s *OutputSuite) SetUpSuite(c *C) {
+	sink := frameSink{}
+	report := &arke.ZeusReport{Humidity: 50, Temperature: [4]float32{21.5, 22, 23, 24}}
+	c.Assert(arke.SendMessage(&sink, report, false, 1), IsNil)
+	// a truncated Zeus.Report
+	sink.Send(socketcan.CanFrame{ID: arke.MakeCANIDT(arke.StandardMessage, arke.ZeusReportMessage, 1), Dlc: 1, Data: []byte{0x2a}})
+	c.Assert(arke.RequestMessage(&sink, &arke.ZeusReport{}, 2), IsNil)
+
+	start := time.Unix(1436509052, 249713000).UTC()
+	s.Records = nil
+	for i, f := range sink {
+		s.Records = append(s.Records, arke.Record{
+			Time:      start.Add(time.Duration(i) * time.Millisecond),
+			Interface: "can0",
+			Frame:     f,
+		})
+	}
+}
+
+func (s *OutputSuite) print(c *C, format string) string {
+	var buf bytes.Buffer
+	out, err := newOutput(format, &buf)
+	c.Assert(err, IsNil)
+	decoder := arke.NewDecoder()
+	for _, r := range s.Records {
+		m, _, err := decoder.Decode(&r.Frame)
+		c.Assert(out.Write(&r, m, err), IsNil)
+	}
+	c.Assert(out.Flush(), IsNil)
+	return buf.String()
+}
+
+func (s *OutputSuite) TestJSONL(c *C) {
+	c.Check(s.print(c, "jsonl"), Equals,
+		`{"Time":"2015-07-10T06:17:32.249713Z","Interface":"can0","IDT":1481,"Type":"Standard","RTR":false,"Node":"Zeus","ID":1,"Data":"ff9ff60516700118","Message":{"Class":"Zeus.Report","Data":{"Humidity":50,"Temperature":[21.499817,22,23,24]}}}`+"\n"+
+			`{"Time":"2015-07-10T06:17:32.250713Z","Interface":"can0","IDT":1481,"Type":"Standard","RTR":false,"Node":"Zeus","ID":1,"Data":"2a","Error":"Could not parse message data: Invalid buffer size 1, required: 8"}`+"\n"+
+			`{"Time":"2015-07-10T06:17:32.251713Z","Interface":"can0","IDT":1482,"Type":"Standard","RTR":true,"Node":"Zeus","ID":2,"Data":"","Message":{"Class":"arke.MessageRequest","Data":{"Class":57,"ID":2}}}`+"\n")
+}
+
+func (s *OutputSuite) TestCSV(c *C) {
+	c.Check(s.print(c, "csv"), Equals, `Time,Interface,IDT,Type,RTR,Node,ID,Data,Message,Value,Error
+2015-07-10T06:17:32.249713Z,can0,0x5c9,Standard,false,Zeus,1,ff9ff60516700118,Zeus.Report,"{""Humidity"":50,""Temperature"":[21.499817,22,23,24]}",
+2015-07-10T06:17:32.250713Z,can0,0x5c9,Standard,false,Zeus,1,2a,,,"Could not parse message data: Invalid buffer size 1, required: 8"
+2015-07-10T06:17:32.251713Z,can0,0x5ca,Standard,true,Zeus,2,,arke.MessageRequest,"{""Class"":57,""ID"":2}",
+`)
+}
+
+func (s *OutputSuite) TestCandump(c *C) {
+	c.Check(s.print(c, "candump"), Equals, `(1436509052.249713) can0 5C9#FF9FF60516700118
+(1436509052.250713) can0 5C9#2A
+(1436509052.251713) can0 5CA#R
+`)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/formicidae-tracker/libarke/src-go/arke"
+)
+
+// statsKey identifies a row of the statistics: all frames sharing an
+// IDT and RTR flag.
+type statsKey struct {
+	IDT uint32
+	RTR bool
+}
+
+type statsRow struct {
+	Name           string
+	Node           arke.NodeClass
+	ID             arke.NodeID
+	Count, Errors  int
+	Bits           int
+	Last           time.Time
+	MinGap, MaxGap time.Duration
+}
+
+// statsOutput accumulates statistics instead of printing frames. Rows
+// and error reports only account for the selected frames, but the bus
+// load accounts for every observed frame. They are printed on refresh
+// and on Flush.
+type statsOutput struct {
+	w io.Writer
+
+	rows       map[statsKey]*statsRow
+	start, end time.Time
+	selected   int
+	errors     int
+	reports    *arke.ErrorStats
+
+	// all the frames on the bus, selected or not
+	frames, bits int
+}
+
+func newStatsOutput(w io.Writer) *statsOutput {
+	return &statsOutput{
+		w:       w,
+		rows:    make(map[statsKey]*statsRow),
+		reports: arke.NewErrorStats(),
+	}
+}
+
+// frameName names the kind of a frame that could not be decoded.
+func frameName(r *arke.Record) string {
+	if r.Frame.RTR == true {
+		return "request"
+	}
+	tpe, cls, ID := arke.ExtractCANIDT(r.Frame.ID)
+	switch tpe {
+	case arke.NetworkControlCommand:
+		return fmt.Sprintf("network command %d", ID)
+	case arke.HeartBeat:
+		return "arke.HeartBeat"
+	}
+	return cls.String()
+}
+
+func (o *statsOutput) observe(r *arke.Record) {
+	if o.start.IsZero() == true {
+		o.start = r.Time
+	}
+	o.end = r.Time
+	o.frames++
+	o.bits += arke.FrameBits(&r.Frame)
+}
+
+func (o *statsOutput) Write(r *arke.Record, m arke.ReceivableMessage, err error) error {
+	key := statsKey{IDT: r.Frame.ID, RTR: r.Frame.RTR}
+	row, ok := o.rows[key]
+	if ok == false {
+		row = &statsRow{Name: frameName(r)}
+		row.Node, row.ID = arke.FrameNode(&r.Frame)
+		o.rows[key] = row
+	}
+	if err == nil && r.Frame.RTR == false {
+		if name, err := arke.MessageName(m); err == nil {
+			row.Name = name
+		}
+	}
+	if report, ok := m.(*arke.ErrorReportData); ok == true && err == nil {
+		o.reports.Add(report, r.Time)
+	}
+
+	if row.Count > 0 {
+		gap := r.Time.Sub(row.Last)
+		if row.Count == 1 || gap < row.MinGap {
+			row.MinGap = gap
+		}
+		row.MaxGap = max(row.MaxGap, gap)
+	}
+	row.Count++
+	row.Bits += arke.FrameBits(&r.Frame)
+	row.Last = r.Time
+	o.selected++
+	if err != nil {
+		row.Errors++
+		o.errors++
+	}
+	return nil
+}
+
+// refresh prints the statistics up to now, so rates decrease while
+// the bus is silent.
+func (o *statsOutput) refresh(now time.Time) error {
+	if o.start.IsZero() == false && now.After(o.end) == true {
+		o.end = now
+	}
+	return o.print()
+}
+
+func (o *statsOutput) Flush() error {
+	return o.print()
+}
+
+func formatGap(row *statsRow, gap time.Duration) string {
+	if row.Count < 2 {
+		return "-"
+	}
+	return gap.Round(time.Microsecond).String()
+}
+
+func (o *statsOutput) print() error {
+	elapsed := o.end.Sub(o.start)
+	seconds := elapsed.Seconds()
+
+	keys := make([]statsKey, 0, len(o.rows))
+	for k := range o.rows {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b statsKey) int {
+		if a.IDT != b.IDT {
+			return int(a.IDT) - int(b.IDT)
+		}
+		if a.RTR == b.RTR {
+			return 0
+		}
+		if a.RTR == true {
+			return 1
+		}
+		return -1
+	})
+
+	rate := func(count int) string {
+		if seconds <= 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.2f", float64(count)/seconds)
+	}
+	load := func(bits int) string {
+		if seconds <= 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.2f%%", 100*float64(bits)/(seconds*arke.Bitrate))
+	}
+
+	tw := tabwriter.NewWriter(o.w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "IDT\tMessage\tNode\tID\tFrames\tRate (/s)\tMin Gap\tMax Gap\tSilent For\tErrors\tLoad\n")
+	for _, k := range keys {
+		row := o.rows[k]
+		fmt.Fprintf(tw, "0x%03x\t%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			k.IDT, row.Name, row.Node, row.ID, row.Count, rate(row.Count),
+			formatGap(row, row.MinGap), formatGap(row, row.MaxGap),
+			o.end.Sub(row.Last).Round(time.Millisecond), row.Errors, load(row.Bits))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(o.w, "%d frames (%d selected), %d parse errors in %s, bus load %s at %d kbit/s\n",
+		o.frames, o.selected, o.errors, elapsed.Round(time.Millisecond), load(o.bits), arke.Bitrate/1000)
+	if err != nil {
+		return err
+	}
+	if counts := o.reports.Counts(); len(counts) > 0 {
+		fmt.Fprintf(o.w, "Error reports:\n")
+		for _, c := range counts {
+			fmt.Fprintf(o.w, "  %s\n", c)
+		}
+	}
+	_, err = fmt.Fprintf(o.w, "\n")
+	return err
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/formicidae-tracker/libarke/src-go/arke"
+	"github.com/jessevdk/go-flags"
+)
+
+type NodeClass arke.NodeClass
+
+func (c *NodeClass) UnmarshalFlag(value string) error {
+	return (*arke.NodeClass)(c).UnmarshalText([]byte(value))
+}
+
+type IDRewrite struct {
+	Old, New arke.NodeID
+}
+
+func (r *IDRewrite) UnmarshalFlag(value string) error {
+	old, new, ok := strings.Cut(value, ":")
+	if ok == false {
+		return fmt.Errorf("Invalid ID rewrite '%s', expected OLD:NEW", value)
+	}
+	for _, v := range []struct {
+		Text string
+		ID   *arke.NodeID
+	}{{old, &r.Old}, {new, &r.New}} {
+		ID, err := strconv.ParseUint(v.Text, 10, 8)
+		if err != nil || ID > 7 {
+			return fmt.Errorf("Invalid node ID '%s' in '%s'", v.Text, value)
+		}
+		*v.ID = arke.NodeID(ID)
+	}
+	return nil
+}
+
+type Options struct {
+	Speed    float64       `long:"speed" short:"s" default:"1.0" description:"Replay speed factor, 0 replays as fast as possible"`
+	Loop     bool          `long:"loop" short:"l" description:"Replay the capture until interrupted"`
+	Source   []string      `long:"source" description:"Only replay frames recorded on this interface"`
+	Classes  []NodeClass   `long:"class" short:"c" description:"Only replay frames about this node class"`
+	IDs      []arke.NodeID `long:"id" short:"I" description:"Only replay frames about this node ID, before rewrite"`
+	Messages []string      `long:"message" short:"m" description:"Only replay this message, e.g. Zeus.Report"`
+	Rewrite  []IDRewrite   `long:"rewrite-id" short:"r" description:"Rewrite node ID OLD to NEW, as OLD:NEW"`
+	Verbose  bool          `long:"verbose" short:"v" description:"Print replayed frames"`
+	Args     struct {
+		Capture flags.Filename        `description:"capture file, in candump -l, binary or pcapng format"`
+		Intf    arke.CANInterfaceName `description:"CAN interface to replay onto"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func (o *Options) transform() (func(*arke.Record) bool, error) {
+	filter := arke.FrameFilter{IDs: o.IDs, Messages: o.Messages}
+	for _, c := range o.Classes {
+		filter.Classes = append(filter.Classes, arke.NodeClass(c))
+	}
+	for _, name := range o.Messages {
+		if _, err := arke.NewMessageByName(name); err != nil {
+			return nil, err
+		}
+	}
+	ids := make(map[arke.NodeID]arke.NodeID)
+	for _, r := range o.Rewrite {
+		ids[r.Old] = r.New
+	}
+
+	return func(r *arke.Record) bool {
+		if len(o.Source) > 0 && slices.Contains(o.Source, r.Interface) == false {
+			return false
+		}
+		if filter.Match(&r.Frame) == false {
+			return false
+		}
+		if len(ids) > 0 {
+			r.Frame = arke.RewriteNodeIDs(r.Frame, ids)
+		}
+		if o.Verbose == true {
+			log.Printf("%s %03X [%d] % X", r.Interface, r.Frame.ID, r.Frame.Dlc, r.Frame.Data)
+		}
+		return true
+	}, nil
+}
+
+func (o *Options) replay(ctx context.Context, bus arke.Bus, transform func(*arke.Record) bool) (int, error) {
+	file, err := os.Open(string(o.Args.Capture))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	player, err := arke.NewPlayer(file)
+	if err != nil {
+		return 0, err
+	}
+	n, err := arke.Replay(ctx, player, bus, o.Speed, transform)
+	log.Printf("Replayed %d frames from %s capture '%s'", n, player.Format(), o.Args.Capture)
+	return n, err
+}
+
+func execute() error {
+	opts := &Options{}
+
+	parser := flags.NewParser(opts, flags.Default)
+	_, err := parser.Parse()
+	if flags.WroteHelp(err) == true {
+		return nil
+	}
+	if ferr, ok := err.(*flags.Error); ok == true && ferr.Type == flags.ErrRequired {
+		parser.WriteHelp(os.Stderr)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if opts.Speed < 0 {
+		return fmt.Errorf("Invalid negative speed %g", opts.Speed)
+	}
+
+	transform, err := opts.transform()
+	if err != nil {
+		return err
+	}
+
+	bus, err := arke.OpenSocketCANBus(string(opts.Args.Intf))
+	if err != nil {
+		return err
+	}
+	defer bus.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	for {
+		n, err := opts.replay(ctx, bus, transform)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil || opts.Loop == false {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("No frame to replay")
+		}
+	}
+}
+
+func main() {
+	if err := execute(); err != nil {
+		// go-flags already reports its own errors
+		if _, ok := err.(*flags.Error); ok == false {
+			log.Printf("%s", err)
+		}
+		os.Exit(1)
+	}
+}
@@ -9,18 +9,20 @@ import (
 
 type NodeClassName string
 
-var nodeClassName = map[string]arke.NodeClass{
-	"broadcast": arke.BroadcastClass,
-	"zeus":      arke.ZeusClass,
-	"helios":    arke.HeliosClass,
-	"celaeno":   arke.CelaenoClass,
-	"notus":     arke.NotusClass,
+func nodeClassNames() []string {
+	classes := arke.NodeClasses()
+	res := make([]string, 0, len(classes))
+	for _, c := range classes {
+		res = append(res, strings.ToLower(arke.ClassName(c)))
+	}
+	return res
 }
 
 func (c *NodeClassName) Complete(match string) []flags.Completion {
 	match = strings.ToLower(match)
-	completions := make([]flags.Completion, 0, len(nodeClassName))
-	for name, _ := range nodeClassName {
+	names := nodeClassNames()
+	completions := make([]flags.Completion, 0, len(names))
+	for _, name := range names {
 		if strings.HasPrefix(name, match) == true {
 			completions = append(completions, flags.Completion{
 				Item: name,
@@ -31,7 +33,7 @@ func (c *NodeClassName) Complete(match string) []flags.Completion {
 }
 
 func (c *NodeClassName) Class() arke.NodeClass {
-	if c, ok := nodeClassName[string(*c)]; ok == true {
+	if c, err := arke.Class(string(*c)); err == nil {
 		return c
 	}
 	return arke.NodeClass(arke.NodeClassMask)
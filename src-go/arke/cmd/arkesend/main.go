@@ -58,13 +58,13 @@ func (cmd *ArkeCommand[M]) Execute([]string) error {
 }
 
 func (o *Options) Send(frame socketcan.CanFrame) error {
-	intf, err := socketcan.NewRawInterface(string(o.Interface))
+	bus, err := arke.OpenSocketCANBus(string(o.Interface))
 	if err != nil {
 		return fmt.Errorf("opening CAN interface '%s': %s", o.Interface, err)
 	}
-	defer intf.Close()
+	defer bus.Close()
 
-	return intf.Send(frame)
+	return bus.Send(frame)
 
 }
 
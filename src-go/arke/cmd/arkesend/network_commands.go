@@ -54,23 +54,13 @@ func (cmd *ChangeIDCommand) Execute([]string) error {
 		arke.NodeID(cmd.Args.Old), arke.NodeID(cmd.Args.New)))
 }
 
-func keys[K comparable, V any](m map[K]V) []K {
-	res := make([]K, len(m))
-	i := 0
-	for k := range m {
-		res[i] = k
-		i++
-	}
-	return res
-}
-
 func init() {
 	networkCommand := MustAddCommand(parser.Command,
 		"network",
 		"Network command group",
 		"A collection of commands generic to each node, to modify ID or ping devices.",
 		network)
-	networkCommand.FindOptionByLongName("class").Choices = keys(nodeClassName)
+	networkCommand.FindOptionByLongName("class").Choices = nodeClassNames()
 
 	MustAddCommand(networkCommand, "reset",
 		"Sends a reset command",
@@ -0,0 +1,47 @@
+// arkewireshark writes a Wireshark Lua dissector for the Arke
+// protocol, generated from the message registry.
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+
+	"github.com/formicidae-tracker/libarke/src-go/arke"
+	"github.com/jessevdk/go-flags"
+)
+
+type Options struct {
+	Output flags.Filename `long:"output" short:"o" description:"File to write the dissector to, instead of the standard output"`
+}
+
+func execute() error {
+	opts := &Options{}
+	if _, err := flags.Parse(opts); err != nil {
+		if flags.WroteHelp(err) == true {
+			return nil
+		}
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if len(opts.Output) > 0 {
+		file, err := os.Create(string(opts.Output))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return arke.WriteWiresharkDissector(w)
+}
+
+func main() {
+	if err := execute(); err != nil {
+		// go-flags already reports its own errors
+		if _, ok := err.(*flags.Error); ok == false {
+			log.Printf("%s", err)
+		}
+		os.Exit(1)
+	}
+}
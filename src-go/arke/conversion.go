@@ -20,7 +20,9 @@ func humidityFloatToBinary(value float32) uint16 {
 		return hih6030Max
 	}
 
-	return uint16((value / 100.0) * hih6030Max)
+	// rounds to the nearest value, so encoding a decoded reading is
+	// exact.
+	return uint16(math.Round(float64(value) / 100.0 * hih6030Max))
 }
 
 func hih6030TemperatureBinaryToFloat(value uint16) float32 {
@@ -37,7 +39,7 @@ func hih6030TemperatureFloatToBinary(value float32) uint16 {
 		return hih6030Max
 	}
 
-	return uint16(((value + 40.0) / 165.0) * hih6030Max)
+	return uint16(math.Round((float64(value) + 40.0) / 165.0 * hih6030Max))
 }
 
 func tmp1075BinaryToFloat(value uint16) float32 {
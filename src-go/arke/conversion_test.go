@@ -88,3 +88,13 @@ func (s *ConversionSuite) TestTmp1075Temperature(c *C) {
 		c.Check(tmp1075FloatToBinaray(d.FloatValue), Equals, d.BinaryValue, comment)
 	}
 }
+
+func (s *ConversionSuite) TestBinaryRoundTrip(c *C) {
+	for v := uint16(0); v <= hih6030Max; v++ {
+		c.Assert(humidityFloatToBinary(humidityBinaryToFloat(v)), Equals, v)
+		c.Assert(hih6030TemperatureFloatToBinary(hih6030TemperatureBinaryToFloat(v)), Equals, v)
+	}
+	for v := uint16(0); v < 1<<12; v++ {
+		c.Assert(tmp1075FloatToBinaray(tmp1075BinaryToFloat(v)), Equals, v)
+	}
+}
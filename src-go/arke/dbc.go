@@ -0,0 +1,297 @@
+package arke
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// dbcValue describes a raw value of a signal.
+type dbcValue struct {
+	Raw  int
+	Name string
+}
+
+// dbcSignal is a little endian signal of a DBC message.
+type dbcSignal struct {
+	Name         string
+	Offset, Size int
+	Signed       bool
+	Factor, Bias float64
+	Min, Max     float64
+	Unit         string
+	Values       []dbcValue
+}
+
+// dbcMessage is a DBC message, i.e. a message class sent with a given
+// IDT.
+type dbcMessage struct {
+	IDT         uint32
+	Name        string
+	Size        int
+	Transmitter string
+	Comment     string
+	Signals     []dbcSignal
+}
+
+func enumerate(n int, name func(v int) string) []dbcValue {
+	res := make([]dbcValue, n)
+	for v := range res {
+		res[v] = dbcValue{Raw: v, Name: name(v)}
+	}
+	return res
+}
+
+// dbcValueTables describes the raw values of the fields of some types.
+var dbcValueTables = map[reflect.Type]func() []dbcValue{
+	reflect.TypeOf(ZeusStatusValue(0)): func() []dbcValue {
+		return enumerate(16, func(v int) string { return ZeusStatusValue(v).String() })
+	},
+	reflect.TypeOf(WaterLevelStatus(0)): func() []dbcValue {
+		return enumerate(8, func(v int) string { return WaterLevelStatus(v).String() })
+	},
+	nodeClassType: func() []dbcValue {
+		var res []dbcValue
+		for _, c := range NodeClasses() {
+			res = append(res, dbcValue{Raw: int(c), Name: ClassName(c)})
+		}
+		return res
+	},
+}
+
+// dbcName returns a DBC identifier for a field path,
+// e.g. "Temperature_1" for "Temperature[1]".
+func dbcName(path string) string {
+	return strings.NewReplacer("[", "_", "]", "", ".", "_").Replace(path)
+}
+
+// dbcSignals returns the signals of a message implementing
+// Describable. FanStatusAndRPM fields are split in their RPM and
+// status.
+func dbcSignals(m any) []dbcSignal {
+	d, ok := m.(Describable)
+	if ok == false {
+		return nil
+	}
+	var res []dbcSignal
+	for _, f := range d.Fields() {
+		t := fieldType(m, f.Name)
+		if t == fanStatusAndRPMType && f.BitSize == 16 {
+			res = append(res,
+				dbcSignal{
+					Name:   dbcName(f.Name) + "_RPM",
+					Offset: f.BitOffset, Size: 14,
+					Factor: 1, Max: 0x3fff,
+					Unit: "rpm",
+				},
+				dbcSignal{
+					Name:   dbcName(f.Name) + "_Status",
+					Offset: f.BitOffset + 14, Size: 2,
+					Factor: 1, Max: 3,
+					Values: enumerate(4, func(v int) string { return FanStatusAndRPM(v << 14).Status().String() }),
+				})
+			continue
+		}
+		s := dbcSignal{
+			Name:   dbcName(f.Name),
+			Offset: f.BitOffset,
+			Size:   f.BitSize,
+			Signed: f.Signed,
+			Factor: f.Resolution,
+			Bias:   f.Bias,
+			Min:    f.Min,
+			Max:    f.Max,
+			Unit:   f.Unit,
+		}
+		if values, ok := dbcValueTables[t]; ok == true {
+			s.Values = values()
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+func dbcSize(signals []dbcSignal) int {
+	res := 0
+	for _, s := range signals {
+		res = max(res, (s.Offset+s.Size+7)/8)
+	}
+	return res
+}
+
+// dbcMessages lists the standard and high priority messages of all
+// registered message classes for every node ID, the heartbeats of every node, and the
+// network commands for every node class.
+func dbcMessages() []dbcMessage {
+	var res []dbcMessage
+	nodes := slices.DeleteFunc(NodeClasses(), func(c NodeClass) bool { return c == BroadcastClass })
+
+	for _, c := range MessageClasses() {
+		var signals []dbcSignal
+		if m, err := NewMessage(c); err == nil {
+			signals = dbcSignals(m)
+		}
+		size := dbcSize(signals)
+		if len(signals) == 0 {
+			// undefined payload
+			size = 8
+		}
+		name := c.String()
+		transmitter := "Vector__XXX"
+		if node := c.NodeClass(); node != BroadcastClass {
+			transmitter = node.String()
+		}
+		for ID := NodeID(0); ID <= 7; ID++ {
+			res = append(res, dbcMessage{
+				IDT:         MakeCANIDT(StandardMessage, c, ID),
+				Name:        fmt.Sprintf("%s_%d", dbcName(name), ID),
+				Size:        size,
+				Transmitter: transmitter,
+				Comment:     fmt.Sprintf("%s, node ID %d", name, ID),
+				Signals:     signals,
+			})
+		}
+		for ID := NodeID(0); ID <= 7; ID++ {
+			res = append(res, dbcMessage{
+				IDT:         MakeCANIDT(HighPriorityMessage, c, ID),
+				Name:        fmt.Sprintf("%s_HP_%d", dbcName(name), ID),
+				Size:        size,
+				Transmitter: transmitter,
+				Comment:     fmt.Sprintf("%s, node ID %d, high priority", name, ID),
+				Signals:     signals,
+			})
+		}
+	}
+
+	heartbeat := dbcSignals(&HeartBeatData{})
+	for _, c := range nodes {
+		for ID := NodeID(1); ID <= 7; ID++ {
+			res = append(res, dbcMessage{
+				IDT:         MakeCANIDT(HeartBeat, MessageClass(c), ID),
+				Name:        fmt.Sprintf("%s_HeartBeat_%d", c, ID),
+				Size:        dbcSize(heartbeat),
+				Transmitter: c.String(),
+				Comment:     fmt.Sprintf("arke.HeartBeat of %s node ID %d, without payload when answering a ping", c, ID),
+				Signals:     heartbeat,
+			})
+		}
+	}
+
+	for _, command := range networkCommands() {
+		names := networkCommandPayloads[command]
+		if len(names) == 0 {
+			names = []string{fmt.Sprintf("arke.Command%d", int(command))}
+		}
+		// commands sharing an IDT use the largest payload
+		name := names[len(names)-1]
+		var signals []dbcSignal
+		if creator, ok := builtinMessages[name]; ok == true {
+			signals = dbcSignals(creator())
+		}
+		for _, c := range append([]NodeClass{BroadcastClass}, nodes...) {
+			// error reports always use the broadcast class
+			if command == ErrorReport && c != BroadcastClass {
+				continue
+			}
+			res = append(res, dbcMessage{
+				IDT:         MakeCANIDT(NetworkControlCommand, MessageClass(c), NodeID(command)),
+				Name:        fmt.Sprintf("%s_%s", c, strings.TrimPrefix(name, "arke.")),
+				Size:        dbcSize(signals),
+				Transmitter: "Vector__XXX",
+				Comment:     fmt.Sprintf("%s for %s nodes", strings.Join(names, " or "), c),
+				Signals:     signals,
+			})
+		}
+	}
+	return res
+}
+
+func dbcFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// latin1 encodes s in ISO-8859-1, the usual encoding of DBC files.
+func latin1(s string) []byte {
+	res := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r <= 0xff {
+			res = append(res, byte(r))
+		} else {
+			res = append(res, '?')
+		}
+	}
+	return res
+}
+
+const dbcHeader = `VERSION ""
+
+
+NS_ :
+	NS_DESC_
+	CM_
+	BA_DEF_
+	BA_
+	VAL_
+	BA_DEF_DEF_
+	VAL_TABLE_
+	SIG_VALTYPE_
+
+BS_:
+
+`
+
+// WriteDBC writes a DBC database of the Arke protocol, listing the
+// messages of all registered message classes for every node ID, the
+// heartbeats and the network commands. Signals and their scaling are
+// generated from the messages implementing Describable.
+func WriteDBC(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(dbcHeader)
+
+	b.WriteString("BU_:")
+	for _, c := range NodeClasses() {
+		if c != BroadcastClass {
+			b.WriteString(" " + c.String())
+		}
+	}
+	b.WriteString("\n\n")
+
+	messages := dbcMessages()
+	for _, m := range messages {
+		fmt.Fprintf(&b, "BO_ %d %s: %d %s\n", m.IDT, m.Name, m.Size, m.Transmitter)
+		for _, s := range m.Signals {
+			sign := "+"
+			if s.Signed == true {
+				sign = "-"
+			}
+			fmt.Fprintf(&b, " SG_ %s : %d|%d@1%s (%s,%s) [%s|%s] %q Vector__XXX\n",
+				s.Name, s.Offset, s.Size, sign, dbcFloat(s.Factor), dbcFloat(s.Bias),
+				dbcFloat(s.Min), dbcFloat(s.Max), s.Unit)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	for _, m := range messages {
+		fmt.Fprintf(&b, "CM_ BO_ %d %q;\n", m.IDT, m.Comment)
+	}
+
+	for _, m := range messages {
+		for _, s := range m.Signals {
+			if len(s.Values) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "VAL_ %d %s", m.IDT, s.Name)
+			for _, v := range s.Values {
+				fmt.Fprintf(&b, " %d %q", v.Raw, v.Name)
+			}
+			b.WriteString(" ;\n")
+		}
+	}
+
+	_, err := w.Write(latin1(b.String()))
+	return err
+}
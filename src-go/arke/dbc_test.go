@@ -0,0 +1,106 @@
+package arke
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type DBCSuite struct{}
+
+var _ = Suite(&DBCSuite{})
+
+const dbcPath = "../../specs/arke.dbc"
+
+func (s *DBCSuite) TestDBCIsUpToDate(c *C) {
+	expected, err := os.ReadFile(dbcPath)
+	c.Assert(err, IsNil)
+	var b bytes.Buffer
+	c.Assert(WriteDBC(&b), IsNil)
+	c.Check(bytes.Equal(b.Bytes(), expected), Equals, true,
+		Commentf("%s is outdated, run go generate", dbcPath))
+}
+
+var dbcSignalLine = regexp.MustCompile(`^ SG_ (\w+) : (\d+)\|(\d+)@1([+-]) \(([-0-9.]+),([-0-9.]+)\)`)
+
+// decodeDBC decodes a payload with the signals of the message with
+// the given IDT, as a generic DBC tool would.
+func decodeDBC(c *C, dbc string, IDT uint32, data []byte) map[string]float64 {
+	res := make(map[string]float64)
+	_, message, found := strings.Cut(dbc, "BO_ "+strconv.Itoa(int(IDT))+" ")
+	c.Assert(found, Equals, true)
+	message, _, _ = strings.Cut(message, "\n\n")
+	var buffer [8]byte
+	copy(buffer[:], data)
+	payload := binary.LittleEndian.Uint64(buffer[:])
+	for _, line := range strings.Split(message, "\n")[1:] {
+		m := dbcSignalLine.FindStringSubmatch(line)
+		c.Assert(m, NotNil, Commentf("line '%s'", line))
+		offset, _ := strconv.Atoi(m[2])
+		size, _ := strconv.Atoi(m[3])
+		factor, _ := strconv.ParseFloat(m[5], 64)
+		bias, _ := strconv.ParseFloat(m[6], 64)
+		raw := (payload >> offset) & (1<<size - 1)
+		value := float64(raw)
+		if m[4] == "-" && raw >= 1<<(size-1) {
+			value -= math.Exp2(float64(size))
+		}
+		res[m[1]] = value*factor + bias
+	}
+	return res
+}
+
+func (s *DBCSuite) TestSignalsDecodeMessages(c *C) {
+	var b bytes.Buffer
+	c.Assert(WriteDBC(&b), IsNil)
+	dbc := b.String()
+
+	report := &ZeusReport{Humidity: 45.5, Temperature: [4]float32{21.25, -3.5, 26.0625, 120}}
+	data := make([]byte, 8)
+	_, err := report.Marshal(data)
+	c.Assert(err, IsNil)
+	decoded := &ZeusReport{}
+	c.Assert(decoded.Unmarshal(data), IsNil)
+	expected := map[string]float32{"Humidity": decoded.Humidity}
+	for i, t := range decoded.Temperature {
+		expected["Temperature_"+strconv.Itoa(i)] = t
+	}
+	for _, t := range []MessageType{StandardMessage, HighPriorityMessage} {
+		values := decodeDBC(c, dbc, MakeCANIDT(t, ZeusReportMessage, 1), data)
+		c.Check(values, HasLen, len(expected))
+		for name, v := range expected {
+			c.Check(math.Abs(values[name]-float64(v)) < 1e-4, Equals, true,
+				Commentf("%s %s: %g, expected %g", t, name, values[name], v))
+		}
+	}
+	c.Check(strings.Contains(dbc, "BO_ "+strconv.Itoa(int(MakeCANIDT(HighPriorityMessage, ZeusReportMessage, 1)))+" Zeus_Report_HP_1: 8 Zeus"), Equals, true)
+
+	fan, err := MakeFanStatusAndRPM(FanAging, 1234)
+	c.Assert(err, IsNil)
+	status := &CelaenoStatus{WaterLevel: CelaenoWaterWarning, Fan: fan}
+	_, err = status.Marshal(data)
+	c.Assert(err, IsNil)
+	IDT := MakeCANIDT(StandardMessage, CelaenoStatusMessage, 2)
+	values := decodeDBC(c, dbc, IDT, data)
+	c.Check(values, DeepEquals, map[string]float64{"WaterLevel": 1, "Fan_RPM": 1234, "Fan_Status": 1})
+	c.Check(strings.Contains(dbc, "VAL_ "+strconv.Itoa(int(IDT))+` Fan_Status 0 "OK" 1 "Aging" 2 "Stalled" 3 "Stalled" ;`), Equals, true)
+
+	reply := MakeSynchronisationReply(HeliosClass, 3, 42, 0x123456789a)
+	values = decodeDBC(c, dbc, reply.ID, reply.Data)
+	c.Check(values, DeepEquals, map[string]float64{"Sequence": 42, "ID": 3, "Timestamp": 0x123456789a / 1000})
+}
+
+func (s *DBCSuite) TestValueTables(c *C) {
+	var b bytes.Buffer
+	c.Assert(WriteDBC(&b), IsNil)
+	IDT := strconv.Itoa(int(MakeCANIDT(StandardMessage, ZeusStatusMessage, 1)))
+	c.Check(strings.Contains(b.String(), "VAL_ "+IDT+` Status 0 "idle" 1 "active" 2 "climate-uncontrolled|idle" 3 "sensor-issue" `), Equals, true)
+	c.Check(strings.Contains(b.String(), `VAL_ 3 Class 0 "Broadcast" 44 "Notus" 48 "Celaeno" 52 "Helios" 56 "Zeus" ;`), Equals, true)
+	c.Check(strings.Contains(b.String(), " SG_ Temperature_0 : 14|14@1+ (0.010072030277133439,-40) [-40|125] \"\xb0C\" Vector__XXX\n"), Equals, true)
+}
@@ -0,0 +1,83 @@
+package arke
+
+import (
+	socketcan "github.com/atuleu/golang-socketcan"
+)
+
+// messageSet provides the instances frames are decoded into. Unless
+// reuse is set, each request returns a new instance, as ParseMessage
+// needs.
+type messageSet struct {
+	reuse    bool
+	messages map[MessageClass]Message
+
+	request                *MessageRequestData
+	resetRequest           *ResetRequestData
+	heartBeatRequest       *HeartBeatRequestData
+	synchronisationRequest *SynchronisationRequestData
+	synchronisationReply   *SynchronisationReplyData
+	idChangeRequest        *IDChangeRequestData
+	errorReport            *ErrorReportData
+	heartBeat              *HeartBeatData
+	raw                    *RawMessage
+}
+
+// acquire returns the instance of *p, allocating it if needed or if s
+// does not reuse instances.
+func acquire[T any](s *messageSet, p **T) *T {
+	if s.reuse == false || *p == nil {
+		*p = new(T)
+	}
+	return *p
+}
+
+func (s *messageSet) message(c MessageClass, creator messageCreator) Message {
+	if s.reuse == false {
+		return creator()
+	}
+	m, ok := s.messages[c]
+	if ok == false {
+		m = creator()
+		s.messages[c] = m
+	}
+	return m
+}
+
+func (s *messageSet) rawMessage(t MessageType, c MessageClass, ID NodeID, data []byte) *RawMessage {
+	if s.reuse == false {
+		return newRawMessage(t, c, ID, data)
+	}
+	if s.raw == nil {
+		s.raw = &RawMessage{Data: make([]byte, 0, 8)}
+	}
+	s.raw.Type = t
+	s.raw.Class = c
+	s.raw.ID = ID
+	s.raw.Data = append(s.raw.Data[:0], data...)
+	return s.raw
+}
+
+// Decoder decodes frames like ParseMessage, but without allocating: a
+// single instance of each kind of message is reused for all frames. A
+// returned message is therefore only valid until the next frame of
+// the same kind is decoded, and must be copied to be retained. A
+// Decoder is not safe for concurrent use.
+type Decoder struct {
+	set messageSet
+}
+
+// NewDecoder returns a Decoder ready to use.
+func NewDecoder() *Decoder {
+	return &Decoder{
+		set: messageSet{
+			reuse:    true,
+			messages: make(map[MessageClass]Message),
+		},
+	}
+}
+
+// Decode decodes a frame, with the same results and errors than
+// ParseMessage. Only errors allocate.
+func (d *Decoder) Decode(f *socketcan.CanFrame) (ReceivableMessage, NodeID, error) {
+	return parseFrame(f, &d.set)
+}
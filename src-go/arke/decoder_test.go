@@ -0,0 +1,146 @@
+package arke
+
+import (
+	"reflect"
+	"testing"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+	. "gopkg.in/check.v1"
+)
+
+type DecoderSuite struct {
+	Frames []socketcan.CanFrame
+}
+
+var _ = Suite(&DecoderSuite{})
+
+func loadBenchmarkFrames() ([]socketcan.CanFrame, error) {
+	golden, err := LoadGoldenFrames(goldenFramesPath)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]socketcan.CanFrame, 0, len(golden))
+	for _, g := range golden {
+		res = append(res, g.Frame)
+	}
+	return res, nil
+}
+
+func (s *DecoderSuite) SetUpSuite(c *C) {
+	var err error
+	s.Frames, err = loadBenchmarkFrames()
+	c.Assert(err, IsNil)
+	s.Frames = append(s.Frames,
+		// unknown class and command
+		socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, 0x01, 1), Dlc: 2, Data: []byte{1, 2}},
+		socketcan.CanFrame{ID: MakeCANIDT(NetworkControlCommand, MessageClass(ZeusClass), 5), Dlc: 1, Data: []byte{3}},
+		// invalid payloads
+		socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusSetPointMessage, 1), Dlc: 2, Data: []byte{1, 2}},
+		socketcan.CanFrame{ID: MakeCANIDT(HeartBeat, MessageClass(ZeusClass), 1), Dlc: 1, Data: []byte{1}},
+		socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusSetPointMessage, 1), Dlc: 9, Data: make([]byte, 9)},
+	)
+}
+
+func (s *DecoderSuite) TestDecodesLikeParseMessage(c *C) {
+	decoder := NewDecoder()
+	// decoding twice checks that no value leaks from a previous frame.
+	for i := 0; i < 2*len(s.Frames); i++ {
+		f := s.Frames[(i*7)%len(s.Frames)]
+		expected, expectedID, expectedErr := ParseMessage(&f)
+		m, ID, err := decoder.Decode(&f)
+		comment := Commentf("frame %v", f)
+		c.Check(ID, Equals, expectedID, comment)
+		c.Check(reflect.DeepEqual(m, expected), Equals, true, Commentf("frame %v: got %v, expected %v", f, m, expected))
+		if expectedErr == nil {
+			c.Check(err, IsNil, comment)
+		} else {
+			if c.Check(err, Not(IsNil), comment) == true {
+				c.Check(err.Error(), Equals, expectedErr.Error(), comment)
+			}
+		}
+	}
+}
+
+func (s *DecoderSuite) TestReusesMessages(c *C) {
+	decoder := NewDecoder()
+	first, _, err := decoder.Decode(&s.Frames[0])
+	c.Assert(err, IsNil)
+	second, _, err := decoder.Decode(&s.Frames[1])
+	c.Assert(err, IsNil)
+	c.Check(second, Equals, first)
+
+	parsed, _, err := ParseMessage(&s.Frames[0])
+	c.Assert(err, IsNil)
+	c.Check(parsed, Not(Equals), first)
+}
+
+func (s *DecoderSuite) TestDoesNotAllocate(c *C) {
+	decoder := NewDecoder()
+	frames := s.Frames[:len(s.Frames)-5]
+	allocs := testing.AllocsPerRun(10, func() {
+		for i := range frames {
+			decoder.Decode(&frames[i])
+		}
+	})
+	c.Check(allocs, Equals, 0.0)
+}
+
+func (s *DecoderSuite) TestMarshalDoesNotAllocate(c *C) {
+	buf := make([]byte, 8)
+	for _, f := range s.Frames[:len(s.Frames)-5] {
+		m, _, err := ParseMessage(&f)
+		c.Assert(err, IsNil)
+		sendable, ok := m.(SendableMessage)
+		if ok == false {
+			continue
+		}
+		allocs := testing.AllocsPerRun(10, func() { sendable.Marshal(buf) })
+		c.Check(allocs, Equals, 0.0, Commentf("%T", m))
+	}
+}
+
+func BenchmarkParseMessage(b *testing.B) {
+	frames, err := loadBenchmarkFrames()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ParseMessage(&frames[i%len(frames)])
+	}
+}
+
+func BenchmarkDecoder(b *testing.B) {
+	frames, err := loadBenchmarkFrames()
+	if err != nil {
+		b.Fatal(err)
+	}
+	decoder := NewDecoder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		decoder.Decode(&frames[i%len(frames)])
+	}
+}
+
+func BenchmarkZeusReportMarshal(b *testing.B) {
+	report := ZeusReport{Humidity: 50, Temperature: [4]float32{25, 21.5, -4.25, 0}}
+	buf := make([]byte, 8)
+	if allocs := testing.AllocsPerRun(10, func() { report.Marshal(buf) }); allocs != 0 {
+		b.Fatalf("Marshal() does %g allocations", allocs)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		report.Marshal(buf)
+	}
+}
+
+func BenchmarkZeusReportUnmarshal(b *testing.B) {
+	buf := []byte{0xff, 0x9f, 0x4d, 0x86, 0x15, 0xbc, 0x0f, 0x00}
+	report := ZeusReport{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		report.Unmarshal(buf)
+	}
+}
@@ -0,0 +1,167 @@
+package arke
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultDiscoveryWindow is used by Discover when the given context
+// has no deadline.
+var DefaultDiscoveryWindow = 500 * time.Millisecond
+
+type nodeKey struct {
+	Class NodeClass
+	ID    NodeID
+}
+
+type NodeInfo struct {
+	Class     NodeClass
+	ID        NodeID
+	Version   FirmwareVersion
+	FirstSeen time.Time
+	// Heartbeats is the number of heartbeats received from this node
+	// during the discovery window.
+	Heartbeats int
+	// Collision is set if this class and ID pair answered more than
+	// once to a single ping, or with different firmware versions.
+	Collision bool
+}
+
+func (n NodeInfo) String() string {
+	res := fmt.Sprintf("%s.%d", n.Class, n.ID)
+	if n.Version.IsZero() == false {
+		res += " v" + n.Version.String()
+	}
+	if n.Collision == true {
+		res += " (ID collision)"
+	}
+	return res
+}
+
+type Inventory []NodeInfo
+
+func (inv Inventory) Collisions() []NodeInfo {
+	res := []NodeInfo{}
+	for _, n := range inv {
+		if n.Collision == true {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+func (inv Inventory) Find(c NodeClass, ID NodeID) (NodeInfo, bool) {
+	for _, n := range inv {
+		if n.Class == c && n.ID == ID {
+			return n, true
+		}
+	}
+	return NodeInfo{}, false
+}
+
+type inventoryBuilder struct {
+	nodes     map[nodeKey]*NodeInfo
+	versioned map[nodeKey]int
+}
+
+func newInventoryBuilder() *inventoryBuilder {
+	return &inventoryBuilder{
+		nodes:     make(map[nodeKey]*NodeInfo),
+		versioned: make(map[nodeKey]int),
+	}
+}
+
+func (b *inventoryBuilder) add(h *HeartBeatData, now time.Time) {
+	key := nodeKey{h.Class, h.ID}
+	n, ok := b.nodes[key]
+	if ok == false {
+		n = &NodeInfo{Class: h.Class, ID: h.ID, FirstSeen: now}
+		b.nodes[key] = n
+	}
+	n.Heartbeats += 1
+
+	// Only answers to a ping carry a version, periodic heartbeats
+	// cannot be used to detect collisions.
+	version := h.Version()
+	if version.IsZero() == true {
+		return
+	}
+	b.versioned[key] += 1
+	if b.versioned[key] > 1 || (n.Version.IsZero() == false && n.Version != version) {
+		n.Collision = true
+	}
+	n.Version = version
+}
+
+func (b *inventoryBuilder) inventory() Inventory {
+	res := make(Inventory, 0, len(b.nodes))
+	for _, n := range b.nodes {
+		res = append(res, *n)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Class != res[j].Class {
+			return res[i].Class > res[j].Class
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// Discover pings each of the given node classes, or all nodes if none
+// is given or if BroadcastClass is given, and collects their heartbeats until the context is
+// done. Like RequestAll, any other received frame is discarded.
+func Discover(ctx context.Context, bus Bus, classes ...NodeClass) (Inventory, error) {
+	ctx, cancel := withDefaultTimeout(ctx, DefaultDiscoveryWindow)
+	defer cancel()
+
+	if len(classes) == 0 {
+		classes = []NodeClass{BroadcastClass}
+	}
+	// each node must be pinged only once, or it would answer twice
+	// and be reported as a collision.
+	wanted := make(map[NodeClass]bool)
+	pinged := []NodeClass{}
+	for _, c := range classes {
+		if wanted[c] == false {
+			pinged = append(pinged, c)
+		}
+		wanted[c] = true
+	}
+	if wanted[BroadcastClass] == true {
+		pinged = []NodeClass{BroadcastClass}
+	}
+	for _, c := range pinged {
+		if err := bus.Send(MakePing(c)); err != nil {
+			return nil, err
+		}
+	}
+
+	builder := newInventoryBuilder()
+	for {
+		f, err := bus.Receive(ctx)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) == true {
+				return builder.inventory(), nil
+			}
+			return builder.inventory(), err
+		}
+		if f.RTR == true || f.Extended == true {
+			continue
+		}
+		if mType, _, _ := ExtractCANIDT(f.ID); mType != HeartBeat {
+			continue
+		}
+		m, _, err := ParseMessage(&f)
+		if err != nil {
+			continue
+		}
+		h := m.(*HeartBeatData)
+		if wanted[BroadcastClass] == false && wanted[h.Class] == false {
+			continue
+		}
+		builder.add(h, time.Now())
+	}
+}
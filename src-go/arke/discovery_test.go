@@ -0,0 +1,146 @@
+package arke
+
+import (
+	"context"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type DiscoverySuite struct {
+	loopback *Loopback
+	host     Bus
+}
+
+var _ = Suite(&DiscoverySuite{})
+
+func (s *DiscoverySuite) SetUpTest(c *C) {
+	s.loopback = NewLoopback()
+	s.host = s.loopback.Endpoint()
+}
+
+func (s *DiscoverySuite) TearDownTest(c *C) {
+	s.loopback.Close()
+}
+
+func (s *DiscoverySuite) discover(c *C, classes ...NodeClass) Inventory {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	res, err := Discover(ctx, s.host, classes...)
+	c.Assert(err, IsNil)
+	for _, n := range res {
+		c.Check(n.FirstSeen.Before(start), Equals, false)
+	}
+	return res
+}
+
+func (s *DiscoverySuite) TestDiscover(c *C) {
+	nodes := []*fakeNode{
+		startClassedFakeNode(s.loopback, ZeusClass, 2, FirmwareVersion{1, 2, 3, 0}),
+		startClassedFakeNode(s.loopback, ZeusClass, 1, FirmwareVersion{1, 2, 0, 0}),
+		startClassedFakeNode(s.loopback, CelaenoClass, 1, FirmwareVersion{0, 4, 0, 0}),
+		startClassedFakeNode(s.loopback, HeliosClass, 3, FirmwareVersion{2, 0, 0, 1}),
+	}
+	for _, n := range nodes {
+		defer n.stop()
+	}
+
+	res := s.discover(c)
+	c.Assert(res, HasLen, 4)
+	expected := []struct {
+		Class   NodeClass
+		ID      NodeID
+		Version FirmwareVersion
+	}{
+		{ZeusClass, 1, FirmwareVersion{1, 2, 0, 0}},
+		{ZeusClass, 2, FirmwareVersion{1, 2, 3, 0}},
+		{HeliosClass, 3, FirmwareVersion{2, 0, 0, 1}},
+		{CelaenoClass, 1, FirmwareVersion{0, 4, 0, 0}},
+	}
+	for i, e := range expected {
+		c.Check(res[i].Class, Equals, e.Class)
+		c.Check(res[i].ID, Equals, e.ID)
+		c.Check(res[i].Version, Equals, e.Version)
+		c.Check(res[i].Heartbeats, Equals, 1)
+		c.Check(res[i].Collision, Equals, false)
+	}
+	c.Check(res.Collisions(), HasLen, 0)
+	c.Check(res[1].String(), Equals, "Zeus.2 v1.2.3.0")
+
+	res = s.discover(c, CelaenoClass, HeliosClass)
+	c.Assert(res, HasLen, 2)
+	c.Check(res[0].Class, Equals, HeliosClass)
+	c.Check(res[1].Class, Equals, CelaenoClass)
+
+	_, ok := res.Find(HeliosClass, 3)
+	c.Check(ok, Equals, true)
+	_, ok = res.Find(ZeusClass, 1)
+	c.Check(ok, Equals, false)
+}
+
+func (s *DiscoverySuite) TestDiscoverCollision(c *C) {
+	nodes := []*fakeNode{
+		startClassedFakeNode(s.loopback, NotusClass, 1, FirmwareVersion{1, 0, 0, 0}),
+		startClassedFakeNode(s.loopback, NotusClass, 1, FirmwareVersion{1, 0, 0, 0}),
+		startClassedFakeNode(s.loopback, NotusClass, 2, FirmwareVersion{1, 0, 0, 0}),
+	}
+	for _, n := range nodes {
+		defer n.stop()
+	}
+
+	res := s.discover(c, NotusClass)
+	c.Assert(res, HasLen, 2)
+	collisions := res.Collisions()
+	c.Assert(collisions, HasLen, 1)
+	c.Check(collisions[0].ID, Equals, NodeID(1))
+	c.Check(collisions[0].Heartbeats, Equals, 2)
+	c.Check(collisions[0].String(), Equals, "Notus.1 v1.0.0.0 (ID collision)")
+}
+
+func (s *DiscoverySuite) TestDiscoverOverlappingClasses(c *C) {
+	nodes := []*fakeNode{
+		startClassedFakeNode(s.loopback, ZeusClass, 1, FirmwareVersion{1, 0, 0, 0}),
+		startClassedFakeNode(s.loopback, HeliosClass, 2, FirmwareVersion{1, 0, 0, 0}),
+	}
+	for _, n := range nodes {
+		defer n.stop()
+	}
+
+	for _, classes := range [][]NodeClass{
+		{BroadcastClass, ZeusClass},
+		{ZeusClass, BroadcastClass},
+		{HeliosClass, ZeusClass, HeliosClass},
+	} {
+		comment := Commentf("classes: %v", classes)
+		res := s.discover(c, classes...)
+		c.Assert(res, HasLen, 2, comment)
+		for _, n := range res {
+			c.Check(n.Heartbeats, Equals, 1, comment)
+			c.Check(n.Collision, Equals, false, comment)
+		}
+	}
+}
+
+func (s *DiscoverySuite) TestInventoryBuilder(c *C) {
+	b := newInventoryBuilder()
+	now := time.Now()
+	// periodic heartbeats are not collisions
+	b.add(&HeartBeatData{Class: ZeusClass, ID: 1}, now)
+	b.add(&HeartBeatData{Class: ZeusClass, ID: 1}, now.Add(time.Second))
+	b.add(&HeartBeatData{Class: ZeusClass, ID: 1, MajorVersion: 1}, now.Add(2*time.Second))
+	// different versions are
+	b.add(&HeartBeatData{Class: HeliosClass, ID: 1, MajorVersion: 1}, now)
+	b.add(&HeartBeatData{Class: HeliosClass, ID: 1, MajorVersion: 2}, now)
+
+	res := b.inventory()
+	c.Assert(res, HasLen, 2)
+	c.Check(res[0], DeepEquals, NodeInfo{
+		Class:      ZeusClass,
+		ID:         1,
+		Version:    FirmwareVersion{Major: 1},
+		FirstSeen:  now,
+		Heartbeats: 3,
+	})
+	c.Check(res[1].Collision, Equals, true)
+}
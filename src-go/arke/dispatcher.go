@@ -0,0 +1,268 @@
+package arke
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+)
+
+type OverflowPolicy int
+
+const (
+	// Block makes the dispatcher wait for the subscriber to consume
+	// its messages, applying backpressure to every other subscriber.
+	Block OverflowPolicy = iota
+	// DropNewest discards the incoming message when the buffer is full.
+	DropNewest
+	// DropOldest discards the oldest buffered message when the buffer
+	// is full. Its buffer holds at least one message.
+	DropOldest
+)
+
+type Envelope[T ReceivableMessage] struct {
+	Time      time.Time
+	Type      MessageType
+	NodeClass NodeClass
+	ID        NodeID
+	Message   T
+}
+
+// SubscribeOptions selects the messages delivered to a
+// Subscription. Empty filters match everything.
+type SubscribeOptions struct {
+	Types       []MessageType
+	Classes     []MessageClass
+	NodeClasses []NodeClass
+	IDs         []NodeID
+	Buffer      int
+	Policy      OverflowPolicy
+}
+
+func (o *SubscribeOptions) matches(e *Envelope[ReceivableMessage]) bool {
+	if len(o.Types) > 0 && slices.Contains(o.Types, e.Type) == false {
+		return false
+	}
+	if len(o.Classes) > 0 && slices.Contains(o.Classes, e.Message.MessageClassID()) == false {
+		return false
+	}
+	if len(o.NodeClasses) > 0 && slices.Contains(o.NodeClasses, e.NodeClass) == false {
+		return false
+	}
+	if len(o.IDs) > 0 && slices.Contains(o.IDs, e.ID) == false {
+		return false
+	}
+	return true
+}
+
+type subscriber interface {
+	deliver(ctx context.Context, e *Envelope[ReceivableMessage])
+	terminate()
+}
+
+type Subscription[T ReceivableMessage] struct {
+	parent    *Dispatcher
+	options   SubscribeOptions
+	c         chan Envelope[T]
+	done      chan struct{}
+	stopOnce  sync.Once
+	closeOnce sync.Once
+	dropped   atomic.Uint64
+}
+
+func (s *Subscription[T]) C() <-chan Envelope[T] {
+	return s.c
+}
+
+// Dropped returns the number of messages discarded because of the
+// OverflowPolicy.
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops the delivery of messages and closes C(). It is safe to
+// call concurrently with the termination of the Dispatcher.
+func (s *Subscription[T]) Close() {
+	// unblocks a pending delivery, before waiting for the dispatcher
+	s.stop()
+	s.parent.unsubscribe(s)
+	s.terminate()
+}
+
+func (s *Subscription[T]) stop() {
+	s.stopOnce.Do(func() { close(s.done) })
+}
+
+// terminate closes the subscription. It must be called once no
+// delivery can be in progress.
+func (s *Subscription[T]) terminate() {
+	s.stop()
+	s.closeOnce.Do(func() { close(s.c) })
+}
+
+func (s *Subscription[T]) deliver(ctx context.Context, e *Envelope[ReceivableMessage]) {
+	if s.options.matches(e) == false {
+		return
+	}
+	m, ok := e.Message.(T)
+	if ok == false {
+		return
+	}
+	typed := Envelope[T]{
+		Time:      e.Time,
+		Type:      e.Type,
+		NodeClass: e.NodeClass,
+		ID:        e.ID,
+		Message:   m,
+	}
+
+	switch s.options.Policy {
+	case DropNewest:
+		select {
+		case s.c <- typed:
+		default:
+			s.dropped.Add(1)
+		}
+	case DropOldest:
+		for {
+			select {
+			case s.c <- typed:
+				return
+			default:
+			}
+			select {
+			case <-s.c:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case s.c <- typed:
+		case <-s.done:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// Dispatcher owns the receive loop of a Bus and fans out parsed
+// messages to its subscriptions.
+type Dispatcher struct {
+	bus         Bus
+	mx          sync.RWMutex
+	subscribers map[subscriber]struct{}
+	terminated  bool
+	parseErrors atomic.Uint64
+}
+
+func NewDispatcher(bus Bus) *Dispatcher {
+	return &Dispatcher{
+		bus:         bus,
+		subscribers: make(map[subscriber]struct{}),
+	}
+}
+
+// Subscribe creates a new subscription on d, receiving only the
+// messages of type T matching the options.
+func Subscribe[T ReceivableMessage](d *Dispatcher, options SubscribeOptions) *Subscription[T] {
+	buffer := max(options.Buffer, 0)
+	if options.Policy == DropOldest {
+		// an unbuffered channel has no oldest message to drop
+		buffer = max(buffer, 1)
+	}
+	res := &Subscription[T]{
+		parent:  d,
+		options: options,
+		c:       make(chan Envelope[T], buffer),
+		done:    make(chan struct{}),
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if d.terminated == true {
+		res.terminate()
+	} else {
+		d.subscribers[res] = struct{}{}
+	}
+	return res
+}
+
+func (d *Dispatcher) unsubscribe(s subscriber) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	delete(d.subscribers, s)
+}
+
+// ParseErrors returns the number of received frames that could not be
+// parsed.
+func (d *Dispatcher) ParseErrors() uint64 {
+	return d.parseErrors.Load()
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, f *socketcan.CanFrame, now time.Time) {
+	m, ID, err := ParseMessage(f)
+	if err != nil {
+		d.parseErrors.Add(1)
+		return
+	}
+	mType, mClass, _ := ExtractCANIDT(f.ID)
+	e := &Envelope[ReceivableMessage]{
+		Time:      now,
+		Type:      mType,
+		NodeClass: messageNodeClass(m, mType, mClass),
+		ID:        ID,
+		Message:   m,
+	}
+
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	for s := range d.subscribers {
+		s.deliver(ctx, e)
+	}
+}
+
+func messageNodeClass(m ReceivableMessage, t MessageType, c MessageClass) NodeClass {
+	switch mm := m.(type) {
+	case *HeartBeatData:
+		return mm.Class
+	case *ResetRequestData:
+		return mm.Class
+	case *HeartBeatRequestData:
+		return mm.Class
+	case *IDChangeRequestData:
+		return mm.Class
+	case *ErrorReportData:
+		return mm.Class
+	}
+	if t == NetworkControlCommand || t == HeartBeat {
+		return NodeClass(c)
+	}
+	return c.NodeClass()
+}
+
+// Run receives and dispatches frames until the context is done or the
+// Bus fails. All subscriptions are closed when it returns.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	defer func() {
+		d.mx.Lock()
+		defer d.mx.Unlock()
+		d.terminated = true
+		for s := range d.subscribers {
+			s.terminate()
+		}
+		clear(d.subscribers)
+	}()
+
+	for {
+		f, err := d.bus.Receive(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		d.dispatch(ctx, &f, time.Now())
+	}
+}
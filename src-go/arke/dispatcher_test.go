@@ -0,0 +1,276 @@
+package arke
+
+import (
+	"context"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+	. "gopkg.in/check.v1"
+)
+
+type DispatcherSuite struct {
+	loopback   *Loopback
+	node       Bus
+	dispatcher *Dispatcher
+	cancel     context.CancelFunc
+	errs       chan error
+}
+
+var _ = Suite(&DispatcherSuite{})
+
+func (s *DispatcherSuite) SetUpTest(c *C) {
+	s.loopback = NewLoopback()
+	s.node = s.loopback.Endpoint()
+	s.dispatcher = NewDispatcher(s.loopback.Endpoint())
+}
+
+func (s *DispatcherSuite) start() {
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(context.Background())
+	s.errs = make(chan error)
+	go func() { s.errs <- s.dispatcher.Run(ctx) }()
+}
+
+func (s *DispatcherSuite) TearDownTest(c *C) {
+	if s.cancel != nil {
+		s.cancel()
+		c.Check(<-s.errs, IsNil)
+		s.cancel = nil
+	}
+	s.loopback.Close()
+}
+
+func receiveEnvelope[T ReceivableMessage](c *C, s *Subscription[T]) (Envelope[T], bool) {
+	select {
+	case e, ok := <-s.C():
+		return e, ok
+	case <-time.After(time.Second):
+		c.Fatalf("no message received")
+	}
+	return Envelope[T]{}, false
+}
+
+func checkNoEnvelope[T ReceivableMessage](c *C, s *Subscription[T]) {
+	select {
+	case e := <-s.C():
+		c.Errorf("unexpected message %s", e.Message)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func (s *DispatcherSuite) TestTypedSubscription(c *C) {
+	reports := Subscribe[*ZeusReport](s.dispatcher, SubscribeOptions{IDs: []NodeID{2}, Buffer: 10})
+	all := Subscribe[ReceivableMessage](s.dispatcher, SubscribeOptions{Buffer: 10})
+	heartbeats := Subscribe[ReceivableMessage](s.dispatcher, SubscribeOptions{
+		Types:       []MessageType{HeartBeat},
+		NodeClasses: []NodeClass{HeliosClass},
+		Buffer:      10,
+	})
+	celaeno := Subscribe[ReceivableMessage](s.dispatcher, SubscribeOptions{
+		NodeClasses: []NodeClass{CelaenoClass},
+		Classes:     []MessageClass{CelaenoStatusMessage},
+		Buffer:      10,
+	})
+	s.start()
+
+	report := &ZeusReport{Humidity: 0, Temperature: [4]float32{-40, 0, 0, 0}}
+	SendMessage(s.node, report, false, 1)
+	SendMessage(s.node, &ZeusSetPoint{Temperature: -40}, false, 2)
+	SendMessage(s.node, report, false, 2)
+	s.node.Send(makeHeartBeat(HeliosClass, 1, FirmwareVersion{}))
+	s.node.Send(makeHeartBeat(ZeusClass, 1, FirmwareVersion{}))
+	SendMessage(s.node, &CelaenoStatus{}, true, 4)
+
+	r, ok := receiveEnvelope(c, reports)
+	c.Assert(ok, Equals, true)
+	c.Check(r.Message, DeepEquals, report)
+	c.Check(r.ID, Equals, NodeID(2))
+	c.Check(r.NodeClass, Equals, ZeusClass)
+	c.Check(r.Type, Equals, StandardMessage)
+	checkNoEnvelope(c, reports)
+
+	for _, class := range []MessageClass{ZeusReportMessage, ZeusSetPointMessage, ZeusReportMessage, HeartBeatMessage, HeartBeatMessage, CelaenoStatusMessage} {
+		e, ok := receiveEnvelope(c, all)
+		c.Assert(ok, Equals, true)
+		c.Check(e.Message.MessageClassID(), Equals, class)
+	}
+
+	h, ok := receiveEnvelope(c, heartbeats)
+	c.Assert(ok, Equals, true)
+	c.Check(h.Message, DeepEquals, &HeartBeatData{Class: HeliosClass, ID: 1})
+	checkNoEnvelope(c, heartbeats)
+
+	st, ok := receiveEnvelope(c, celaeno)
+	c.Assert(ok, Equals, true)
+	c.Check(st.Type, Equals, HighPriorityMessage)
+	c.Check(st.ID, Equals, NodeID(4))
+}
+
+func (s *DispatcherSuite) TestDropPolicies(c *C) {
+	newest := Subscribe[*CelaenoSetPoint](s.dispatcher, SubscribeOptions{Buffer: 2, Policy: DropNewest})
+	oldest := Subscribe[*CelaenoSetPoint](s.dispatcher, SubscribeOptions{Buffer: 2, Policy: DropOldest})
+	sync := Subscribe[*HeartBeatData](s.dispatcher, SubscribeOptions{})
+	s.start()
+
+	for i := 1; i <= 4; i++ {
+		SendMessage(s.node, &CelaenoSetPoint{Power: uint8(i)}, false, 1)
+	}
+	// ensures all previous frames are dispatched
+	s.node.Send(makeHeartBeat(CelaenoClass, 1, FirmwareVersion{}))
+	_, ok := receiveEnvelope(c, sync)
+	c.Assert(ok, Equals, true)
+
+	for _, d := range []struct {
+		S        *Subscription[*CelaenoSetPoint]
+		Expected []uint8
+	}{
+		{newest, []uint8{1, 2}},
+		{oldest, []uint8{3, 4}},
+	} {
+		c.Check(d.S.Dropped(), Equals, uint64(2))
+		for _, p := range d.Expected {
+			e, ok := receiveEnvelope(c, d.S)
+			c.Assert(ok, Equals, true)
+			c.Check(e.Message.Power, Equals, p)
+		}
+	}
+}
+
+func (s *DispatcherSuite) TestUnbufferedDropOldest(c *C) {
+	oldest := Subscribe[*CelaenoSetPoint](s.dispatcher, SubscribeOptions{Buffer: 0, Policy: DropOldest})
+	sync := Subscribe[*HeartBeatData](s.dispatcher, SubscribeOptions{})
+	s.start()
+
+	for i := 1; i <= 3; i++ {
+		SendMessage(s.node, &CelaenoSetPoint{Power: uint8(i)}, false, 1)
+	}
+	s.node.Send(makeHeartBeat(CelaenoClass, 1, FirmwareVersion{}))
+	_, ok := receiveEnvelope(c, sync)
+	c.Assert(ok, Equals, true)
+
+	c.Check(oldest.Dropped(), Equals, uint64(2))
+	e, ok := receiveEnvelope(c, oldest)
+	c.Assert(ok, Equals, true)
+	c.Check(e.Message.Power, Equals, uint8(3))
+	oldest.Close()
+}
+
+func (s *DispatcherSuite) TestBlockingSubscription(c *C) {
+	blocking := Subscribe[*NotusSetPoint](s.dispatcher, SubscribeOptions{})
+	s.start()
+
+	for i := 1; i <= 3; i++ {
+		SendMessage(s.node, &NotusSetPoint{Power: uint8(i)}, false, 1)
+	}
+	for i := 1; i <= 3; i++ {
+		e, ok := receiveEnvelope(c, blocking)
+		c.Assert(ok, Equals, true)
+		c.Check(e.Message.Power, Equals, uint8(i))
+	}
+	c.Check(blocking.Dropped(), Equals, uint64(0))
+
+	// closing a blocked subscription does not stall the dispatcher
+	other := Subscribe[*NotusSetPoint](s.dispatcher, SubscribeOptions{Buffer: 2})
+	SendMessage(s.node, &NotusSetPoint{Power: 4}, false, 1)
+	time.Sleep(10 * time.Millisecond)
+	blocking.Close()
+	SendMessage(s.node, &NotusSetPoint{Power: 5}, false, 1)
+	for i := 4; i <= 5; i++ {
+		e, ok := receiveEnvelope(c, other)
+		c.Assert(ok, Equals, true)
+		c.Check(e.Message.Power, Equals, uint8(i))
+	}
+	_, ok := <-blocking.C()
+	c.Check(ok, Equals, false)
+}
+
+func (s *DispatcherSuite) TestTermination(c *C) {
+	sub := Subscribe[ReceivableMessage](s.dispatcher, SubscribeOptions{Buffer: 1})
+	s.start()
+	s.node.Send(socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusReportMessage, 1), Dlc: 1, Data: []byte{0}})
+	s.node.Send(makeHeartBeat(CelaenoClass, 1, FirmwareVersion{}))
+	_, ok := receiveEnvelope(c, sub)
+	c.Check(ok, Equals, true)
+	c.Check(s.dispatcher.ParseErrors(), Equals, uint64(1))
+
+	s.cancel()
+	c.Check(<-s.errs, IsNil)
+	s.cancel = nil
+	_, ok = <-sub.C()
+	c.Check(ok, Equals, false)
+	sub.Close()
+
+	late := Subscribe[ReceivableMessage](s.dispatcher, SubscribeOptions{})
+	_, ok = <-late.C()
+	c.Check(ok, Equals, false)
+}
+
+func (s *DispatcherSuite) TestCloseWhileTerminating(c *C) {
+	sub := Subscribe[ReceivableMessage](s.dispatcher, SubscribeOptions{})
+	s.start()
+	// makes Run() wait for the dispatcher lock before Close() does
+	s.dispatcher.mx.RLock()
+	s.cancel()
+	time.Sleep(10 * time.Millisecond)
+	closed := make(chan struct{})
+	go func() {
+		sub.Close()
+		close(closed)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.dispatcher.mx.RUnlock()
+	s.cancel = nil
+
+	for _, done := range []<-chan struct{}{closed, s.waitRun(c)} {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			c.Fatalf("Close() and Run() termination deadlocked")
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		s.dispatcher = NewDispatcher(s.loopback.Endpoint())
+		subs := make([]*Subscription[ReceivableMessage], 8)
+		for j := range subs {
+			subs[j] = Subscribe[ReceivableMessage](s.dispatcher, SubscribeOptions{Buffer: j % 2})
+		}
+		s.start()
+		s.node.Send(makeHeartBeat(CelaenoClass, 1, FirmwareVersion{}))
+
+		ready := make(chan struct{})
+		closed := make(chan struct{}, len(subs))
+		for _, sub := range subs {
+			go func() {
+				<-ready
+				sub.Close()
+				closed <- struct{}{}
+			}()
+		}
+		close(ready)
+		s.cancel()
+		for range subs {
+			select {
+			case <-closed:
+			case <-time.After(time.Second):
+				c.Fatalf("Close() deadlocked with Run() termination")
+			}
+		}
+		select {
+		case <-s.waitRun(c):
+		case <-time.After(time.Second):
+			c.Fatalf("Run() deadlocked with Close()")
+		}
+		s.cancel = nil
+	}
+}
+
+// waitRun returns a channel closed once Run() returned without error.
+func (s *DispatcherSuite) waitRun(c *C) <-chan struct{} {
+	res := make(chan struct{})
+	go func() {
+		c.Check(<-s.errs, IsNil)
+		close(res)
+	}()
+	return res
+}
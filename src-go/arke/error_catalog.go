@@ -0,0 +1,218 @@
+package arke
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+type ErrorSeverity int
+
+const (
+	SeverityInfo ErrorSeverity = iota
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+func (s ErrorSeverity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	}
+	return "<unknown>"
+}
+
+func (s ErrorSeverity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *ErrorSeverity) UnmarshalText(text []byte) error {
+	for candidate := SeverityInfo; candidate <= SeverityCritical; candidate++ {
+		if candidate.String() == string(text) {
+			*s = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown error severity '%s'", text)
+}
+
+// ErrorCodeInfo describes an error code reported by the firmware of a
+// node class. Codes registered for BroadcastClass are shared by all
+// classes.
+type ErrorCodeInfo struct {
+	Class       NodeClass
+	Code        uint16
+	Name        string
+	Severity    ErrorSeverity
+	Description string
+}
+
+func (i ErrorCodeInfo) String() string {
+	return fmt.Sprintf("%s (%s)", i.Name, i.Severity)
+}
+
+type errorCodeKey struct {
+	Class NodeClass
+	Code  uint16
+}
+
+// errorCatalog is protected by registryMx.
+var errorCatalog = make(map[errorCodeKey]ErrorCodeInfo)
+
+// RegisterErrorCode adds an error code to the catalog used to decode
+// ErrorReportData. It fails if the code is already registered for the
+// class.
+func RegisterErrorCode(info ErrorCodeInfo) error {
+	if len(info.Name) == 0 {
+		return fmt.Errorf("Error code 0x%04x of %s needs a name", info.Code, info.Class)
+	}
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	key := errorCodeKey{info.Class, info.Code}
+	if existing, ok := errorCatalog[key]; ok == true {
+		return fmt.Errorf("Error code 0x%04x of %s is already registered as '%s'",
+			info.Code, nameByClass[info.Class], existing.Name)
+	}
+	errorCatalog[key] = info
+	return nil
+}
+
+// LoadErrorCatalog registers the error codes of a JSON catalog, an
+// array of ErrorCodeInfo objects, e.g.:
+//
+//	[{"Class":"Zeus","Code":66,"Name":"Overheat","Severity":"critical"}]
+//
+// Codes are registered in order, until the first one that fails to
+// register.
+func LoadErrorCatalog(r io.Reader) error {
+	var infos []ErrorCodeInfo
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&infos); err != nil {
+		return fmt.Errorf("Could not decode error catalog: %w", err)
+	}
+	for _, info := range infos {
+		if err := RegisterErrorCode(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LookupErrorCode returns the description of an error code reported
+// by a node class, falling back on the codes shared by all classes.
+func LookupErrorCode(c NodeClass, code uint16) (ErrorCodeInfo, bool) {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	for _, class := range []NodeClass{c, BroadcastClass} {
+		if info, ok := errorCatalog[errorCodeKey{class, code}]; ok == true {
+			return info, true
+		}
+	}
+	return ErrorCodeInfo{}, false
+}
+
+// ErrorCodes returns all the error codes registered for a node class,
+// by increasing code.
+func ErrorCodes(c NodeClass) []ErrorCodeInfo {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	var res []ErrorCodeInfo
+	for key, info := range errorCatalog {
+		if key.Class == c {
+			res = append(res, info)
+		}
+	}
+	slices.SortFunc(res, func(a, b ErrorCodeInfo) int { return int(a.Code) - int(b.Code) })
+	return res
+}
+
+// Describe looks up the reported error code in the catalog.
+func (d *ErrorReportData) Describe() (ErrorCodeInfo, bool) {
+	return LookupErrorCode(d.Class, d.ErrorCode)
+}
+
+// ErrorCount aggregates the reports of an error code by a node.
+type ErrorCount struct {
+	Class       NodeClass
+	ID          NodeID
+	Code        uint16
+	Count       int
+	First, Last time.Time
+}
+
+func (c ErrorCount) String() string {
+	code := fmt.Sprintf("0x%04x", c.Code)
+	if info, ok := LookupErrorCode(c.Class, c.Code); ok == true {
+		code += " " + info.String()
+	}
+	return fmt.Sprintf("%s.%d: %s x%d (last %s)", c.Class, c.ID, code, c.Count, c.Last.Format(time.RFC3339))
+}
+
+type errorCountKey struct {
+	nodeKey
+	Code uint16
+}
+
+// ErrorStats counts the error reports received for each node and
+// code. It is safe for concurrent use.
+type ErrorStats struct {
+	mx     sync.Mutex
+	counts map[errorCountKey]*ErrorCount
+}
+
+func NewErrorStats() *ErrorStats {
+	return &ErrorStats{counts: make(map[errorCountKey]*ErrorCount)}
+}
+
+// Add records an error report received at t.
+func (s *ErrorStats) Add(r *ErrorReportData, t time.Time) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	key := errorCountKey{nodeKey{r.Class, r.ID}, r.ErrorCode}
+	c, ok := s.counts[key]
+	if ok == false {
+		c = &ErrorCount{Class: r.Class, ID: r.ID, Code: r.ErrorCode, First: t}
+		s.counts[key] = c
+	}
+	c.Count += 1
+	c.Last = t
+}
+
+// Counts returns the aggregated counts, sorted by node like an
+// Inventory, then by code.
+func (s *ErrorStats) Counts() []ErrorCount {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	res := make([]ErrorCount, 0, len(s.counts))
+	for _, c := range s.counts {
+		res = append(res, *c)
+	}
+	slices.SortFunc(res, func(a, b ErrorCount) int {
+		if a.Class != b.Class {
+			return int(b.Class) - int(a.Class)
+		}
+		if a.ID != b.ID {
+			return int(a.ID) - int(b.ID)
+		}
+		return int(a.Code) - int(b.Code)
+	})
+	return res
+}
+
+// Node returns the aggregated counts of a single node.
+func (s *ErrorStats) Node(c NodeClass, ID NodeID) []ErrorCount {
+	return slices.DeleteFunc(s.Counts(), func(e ErrorCount) bool {
+		return e.Class != c || e.ID != ID
+	})
+}
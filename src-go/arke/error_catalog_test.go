@@ -0,0 +1,90 @@
+package arke
+
+import (
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ErrorCatalogSuite struct{}
+
+var _ = Suite(&ErrorCatalogSuite{})
+
+func (s *ErrorCatalogSuite) TearDownTest(c *C) {
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	clear(errorCatalog)
+}
+
+func (s *ErrorCatalogSuite) TestCatalog(c *C) {
+	overheat := ErrorCodeInfo{
+		Class:       ZeusClass,
+		Code:        0x0042,
+		Name:        "Overheat",
+		Severity:    SeverityCritical,
+		Description: "test code",
+	}
+	shared := ErrorCodeInfo{Class: BroadcastClass, Code: 0x0001, Name: "Watchdog", Severity: SeverityWarning}
+	c.Assert(RegisterErrorCode(overheat), IsNil)
+	c.Assert(RegisterErrorCode(shared), IsNil)
+	c.Check(RegisterErrorCode(overheat), ErrorMatches, "Error code 0x0042 of Zeus is already registered as 'Overheat'")
+	c.Check(RegisterErrorCode(ErrorCodeInfo{Class: ZeusClass, Code: 3}), ErrorMatches, "Error code 0x0003 of Zeus needs a name")
+
+	info, ok := LookupErrorCode(ZeusClass, 0x42)
+	c.Check(ok, Equals, true)
+	c.Check(info, DeepEquals, overheat)
+	_, ok = LookupErrorCode(HeliosClass, 0x42)
+	c.Check(ok, Equals, false)
+	info, ok = LookupErrorCode(HeliosClass, 0x01)
+	c.Check(ok, Equals, true)
+	c.Check(info.Name, Equals, "Watchdog")
+	c.Check(ErrorCodes(ZeusClass), DeepEquals, []ErrorCodeInfo{overheat})
+
+	c.Check((&ErrorReportData{Class: ZeusClass, ID: 1, ErrorCode: 0x42}).String(), Equals,
+		"arke.ErrorReport{Class: Zeus, ID: 1, ErrorCode: 0x0042 Overheat (critical)}")
+	c.Check((&ErrorReportData{Class: ZeusClass, ID: 1, ErrorCode: 0x43}).String(), Equals,
+		"arke.ErrorReport{Class: Zeus, ID: 1, ErrorCode: 0x0043}")
+}
+
+func (s *ErrorCatalogSuite) TestLoadCatalog(c *C) {
+	catalog := `[
+	{"Class": "Zeus", "Code": 66, "Name": "Overheat", "Severity": "critical", "Description": "test code"},
+	{"Class": "0x00", "Code": 1, "Name": "Watchdog"}
+]`
+	c.Assert(LoadErrorCatalog(strings.NewReader(catalog)), IsNil)
+	info, ok := LookupErrorCode(ZeusClass, 0x42)
+	c.Check(ok, Equals, true)
+	c.Check(info, DeepEquals, ErrorCodeInfo{ZeusClass, 0x42, "Overheat", SeverityCritical, "test code"})
+	info, ok = LookupErrorCode(HeliosClass, 1)
+	c.Check(ok, Equals, true)
+	c.Check(info.Severity, Equals, SeverityInfo)
+
+	for _, d := range []struct {
+		Catalog, Error string
+	}{
+		{`[{"Class": "Zeus", "Code": 66, "Name": "Again"}]`, "Error code 0x0042 of Zeus is already registered as 'Overheat'"},
+		{`[{"Class": "Zeus", "Code": 2, "Name": "A", "Severity": "fatal"}]`, "Could not decode error catalog: Unknown error severity 'fatal'"},
+		{`[{"Class": "Nope", "Code": 2, "Name": "A"}]`, "Could not decode error catalog: Unknown node class 'Nope'"},
+		{`[{"Class": "Zeus", "Code": 2, "Name": "A", "Level": 1}]`, "Could not decode error catalog: json: unknown field \"Level\""},
+	} {
+		c.Check(LoadErrorCatalog(strings.NewReader(d.Catalog)), ErrorMatches, d.Error)
+	}
+}
+
+func (s *ErrorCatalogSuite) TestStats(c *C) {
+	stats := NewErrorStats()
+	start := time.Now()
+	stats.Add(&ErrorReportData{Class: HeliosClass, ID: 1, ErrorCode: 2}, start)
+	stats.Add(&ErrorReportData{Class: ZeusClass, ID: 2, ErrorCode: 1}, start)
+	stats.Add(&ErrorReportData{Class: HeliosClass, ID: 1, ErrorCode: 2}, start.Add(time.Second))
+	stats.Add(&ErrorReportData{Class: HeliosClass, ID: 1, ErrorCode: 1}, start.Add(2*time.Second))
+
+	c.Check(stats.Counts(), DeepEquals, []ErrorCount{
+		{ZeusClass, 2, 1, 1, start, start},
+		{HeliosClass, 1, 1, 1, start.Add(2 * time.Second), start.Add(2 * time.Second)},
+		{HeliosClass, 1, 2, 2, start, start.Add(time.Second)},
+	})
+	c.Check(stats.Node(ZeusClass, 2), HasLen, 1)
+	c.Check(stats.Node(ZeusClass, 1), HasLen, 0)
+}
@@ -0,0 +1,79 @@
+package arke
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+)
+
+var (
+	// ErrShortBuffer is wrapped by errors caused by a payload or a
+	// buffer too small for a message.
+	ErrShortBuffer = errors.New("Invalid buffer size")
+	// ErrUnknownMessageClass is returned when no message is registered
+	// for a class.
+	ErrUnknownMessageClass = errors.New("Unknown message class")
+	// ErrInvalidSensorValue is wrapped by errors caused by a payload
+	// holding a sensor reading flagged as invalid.
+	ErrInvalidSensorValue = errors.New("Invalid sensor value")
+	// ErrDurationOverflow is returned when a duration does not fit in
+	// the protocol 16-bit millisecond representation.
+	ErrDurationOverflow = errors.New("Time constant overflow")
+
+	errExtendedIDT   = errors.New("Arke does not support extended IDT")
+	errRTRPayload    = errors.New("RTR frame with a payload")
+	errRTRNetworkCmd = errors.New("Unauthorized network command RTR frame")
+	errInvalidDlc    = errors.New("Invalid DLC (max is 8)")
+)
+
+func shortBufferError(size, required int) error {
+	return fmt.Errorf("%w %d, required: %d", ErrShortBuffer, size, required)
+}
+
+// sensorValueError reports an invalid reading for a message field. It
+// matches ErrInvalidSensorValue.
+type sensorValueError struct {
+	field string
+}
+
+func (e sensorValueError) Error() string {
+	return fmt.Sprintf("Invalid %s value", strings.ToLower(e.field))
+}
+
+func (e sensorValueError) Is(target error) bool {
+	return target == ErrInvalidSensorValue
+}
+
+// ParseError is returned by ParseMessage when a frame cannot be
+// decoded. Field names the offending part of the frame: "IDT", "RTR",
+// "Dlc", "Data" or the name of the decoded message field.
+type ParseError struct {
+	Frame socketcan.CanFrame
+	Field string
+	Err   error
+}
+
+func newParseError(f *socketcan.CanFrame, field string, err error) *ParseError {
+	res := &ParseError{Frame: *f, Field: field, Err: err}
+	var sensorErr sensorValueError
+	if errors.As(err, &sensorErr) == true {
+		res.Field = sensorErr.field
+	} else if errors.Is(err, ErrShortBuffer) == true {
+		res.Field = "Dlc"
+	}
+	return res
+}
+
+func (e *ParseError) Error() string {
+	switch e.Field {
+	case "IDT", "RTR":
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("Could not parse message data: %s", e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,76 @@
+package arke
+
+import (
+	"errors"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+	. "gopkg.in/check.v1"
+)
+
+type ErrorsSuite struct{}
+
+var _ = Suite(&ErrorsSuite{})
+
+func (s *ErrorsSuite) TestParseErrors(c *C) {
+	testdata := []struct {
+		F     socketcan.CanFrame
+		Field string
+		Err   error
+	}{
+		{socketcan.CanFrame{Extended: true}, "IDT", nil},
+		{socketcan.CanFrame{RTR: true, Dlc: 1}, "RTR", nil},
+		{
+			socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusReportMessage, 1), Dlc: 1, Data: []byte{0}},
+			"Dlc", ErrShortBuffer,
+		},
+		{
+			socketcan.CanFrame{ID: MakeCANIDT(HeartBeat, MessageClass(ZeusClass), 1), Dlc: 1, Data: []byte{0}},
+			"Dlc", ErrShortBuffer,
+		},
+		{
+			socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusSetPointMessage, 1), Dlc: 5, Data: []byte{0xff, 0xff, 0, 0, 0}},
+			"Humidity", ErrInvalidSensorValue,
+		},
+		{
+			socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusReportMessage, 1), Dlc: 8, Data: []byte{0x00, 0xc0, 0xff, 0x0f, 0, 0, 0, 0}},
+			"Temperature", ErrInvalidSensorValue,
+		},
+	}
+
+	for _, d := range testdata {
+		_, _, err := ParseMessage(&d.F)
+		var perr *ParseError
+		if c.Check(errors.As(err, &perr), Equals, true) == false {
+			continue
+		}
+		c.Check(perr.Field, Equals, d.Field)
+		c.Check(perr.Frame.ID, Equals, d.F.ID)
+		if d.Err != nil {
+			c.Check(errors.Is(err, d.Err), Equals, true)
+		}
+	}
+}
+
+func (s *ErrorsSuite) TestSentinels(c *C) {
+	_, err := NewMessage(0x12)
+	c.Check(errors.Is(err, ErrUnknownMessageClass), Equals, true)
+	c.Check(err, ErrorMatches, "Unknown message class 0x12")
+	m, err := NewMessage(ZeusReportMessage)
+	c.Check(err, IsNil)
+	c.Check(m, DeepEquals, &ZeusReport{})
+
+	err = SendMessage(newFakeRawInterface(), &CelaenoConfig{RampUpTime: 100 * time.Second}, false, 1)
+	c.Check(errors.Is(err, ErrDurationOverflow), Equals, true)
+
+	_, err = (&ZeusStatus{}).Marshal(make([]byte, 2))
+	c.Check(errors.Is(err, ErrShortBuffer), Equals, true)
+
+	err = PDConfig{DividerPower: 4, DividerPowerIntegral: 16}.marshall(make([]byte, 4))
+	c.Check(errors.Is(err, ErrOutOfRange), Equals, true)
+	c.Check(err, ErrorMatches, `DividerPowerIntegral 16 is out of range \[0, 15\]`)
+	err = PDConfig{DividerPower: 16}.marshall(make([]byte, 4))
+	var rangeErr *RangeError
+	c.Assert(errors.As(err, &rangeErr), Equals, true)
+	c.Check(rangeErr.Field.Name, Equals, "DividerPower")
+}
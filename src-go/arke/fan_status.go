@@ -1,6 +1,9 @@
 package arke
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 type FanStatus uint8
 
@@ -37,5 +40,78 @@ func (s FanStatus) String() string {
 }
 
 func (s FanStatusAndRPM) String() string {
+	if s&0xc000 == 0xc000 {
+		// a stalled fan may also be aging
+		return fmt.Sprintf("{Status: %s, Aging: true, RPM: %d}", s.Status(), s.RPM())
+	}
 	return fmt.Sprintf("{Status: %s, RPM: %d}", s.Status(), s.RPM())
 }
+
+func (s FanStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *FanStatus) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "OK":
+		*s = FanOK
+	case "Aging":
+		*s = FanAging
+	case "Stalled":
+		*s = FanStalled
+	default:
+		return fmt.Errorf("Invalid fan status '%s'", text)
+	}
+	return nil
+}
+
+// MakeFanStatusAndRPM packs a fan status and its RPM, which must fit
+// on 14 bits.
+func MakeFanStatusAndRPM(status FanStatus, rpm uint16) (FanStatusAndRPM, error) {
+	if rpm > 0x3fff {
+		return 0, fmt.Errorf("Fan RPM %d is too large (max is %d)", rpm, 0x3fff)
+	}
+	res := FanStatusAndRPM(rpm)
+	switch status {
+	case FanAging:
+		res |= 0x4000
+	case FanStalled:
+		res |= 0x8000
+	}
+	return res, nil
+}
+
+// fanStatusAndRPMJSON encodes the two status flags independently, as
+// a stalled fan may also be aging.
+type fanStatusAndRPMJSON struct {
+	Aging   bool
+	Stalled bool
+	RPM     uint16
+}
+
+func (s FanStatusAndRPM) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fanStatusAndRPMJSON{
+		Aging:   s&0x4000 != 0,
+		Stalled: s&0x8000 != 0,
+		RPM:     s.RPM(),
+	})
+}
+
+func (s *FanStatusAndRPM) UnmarshalJSON(data []byte) error {
+	var v fanStatusAndRPMJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	res, err := MakeFanStatusAndRPM(FanOK, v.RPM)
+	if err != nil {
+		return err
+	}
+	if v.Aging == true {
+		res |= 0x4000
+	}
+	if v.Stalled == true {
+		res |= 0x8000
+	}
+	*s = res
+	return nil
+}
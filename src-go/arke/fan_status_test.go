@@ -26,4 +26,6 @@ func (s *FanStatusSuite) TestFanStatus(c *C) {
 		c.Check(fan.Status(), Equals, d.Status)
 	}
 
+	c.Check(FanStatusAndRPM(0xc005).String(), Equals, "{Status: Stalled, Aging: true, RPM: 5}")
+	c.Check(FanStatusAndRPM(0x8005).String(), Equals, "{Status: Stalled, RPM: 5}")
 }
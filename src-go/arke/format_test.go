@@ -144,6 +144,14 @@ func (s *FormatSuite) TestFormatting(c *C) {
 			},
 			"arke.IDChangeRequest{Class: Celaeno, OldID: 1, NewID: 2}",
 		},
+		{
+			&SynchronisationRequestData{Class: BroadcastClass, Sequence: 3},
+			"arke.SynchronisationRequest{Class: Broadcast, Node: All, Sequence: 3}",
+		},
+		{
+			&SynchronisationReplyData{Class: NotusClass, ID: 2, Sequence: 3, Timestamp: 1500 * time.Millisecond},
+			"arke.SynchronisationReply{Class: Notus, ID: 2, Sequence: 3, Timestamp: 1.5s}",
+		},
 		{
 			&ErrorReportData{
 				Class:     ZeusClass,
@@ -170,6 +178,20 @@ func (s *FormatSuite) TestFormatting(c *C) {
 
 	c.Assert(len(testdata) >= len(messageFactory), Equals, true)
 
+	testdata = append(testdata, []struct {
+		M ReceivableMessage
+		E string
+	}{
+		{
+			&RawMessage{StandardMessage, ZeusVibrationReportMessage, 1, []byte{1, 0x2a}},
+			"arke.RawMessage{Type: Standard, Class: 0x3a (Zeus.VibrationReport), ID: 1, Data: [01 2a]}",
+		},
+		{
+			&RawMessage{NetworkControlCommand, MessageClass(ZeusClass), 6, nil},
+			"arke.RawMessage{Type: NetworkCommand, Class: 0x38, ID: 6, Data: []}",
+		},
+	}...)
+
 	for _, d := range testdata {
 		c.Check(d.M.String(), Equals, d.E)
 	}
@@ -0,0 +1,10 @@
+package arke
+
+// The message classes, payload types and their registration, as well
+// as include/arke.h and specs/specs.md, are generated from
+// specs/protocol.json. The Wireshark dissector in specs/arke.lua is
+// then generated from the message registry, as well as the DBC
+// database in specs/arke.dbc.
+//go:generate go run ./internal/arkegen -root ../..
+//go:generate go run ./cmd/arkewireshark -o ../../specs/arke.lua
+//go:generate go run ./cmd/arkedbc -o ../../specs/arke.dbc
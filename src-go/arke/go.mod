@@ -7,6 +7,7 @@ toolchain go1.23.7
 require (
 	github.com/atuleu/golang-socketcan v0.2.2
 	github.com/jessevdk/go-flags v1.6.1
+	golang.org/x/term v0.30.0
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c
 )
 
@@ -14,5 +15,4 @@ require (
 	github.com/kr/pretty v0.2.1 // indirect
 	github.com/kr/text v0.1.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
-	golang.org/x/term v0.30.0 // indirect
 )
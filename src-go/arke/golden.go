@@ -0,0 +1,121 @@
+package arke
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+)
+
+// GoldenField is an expected decoded field of a GoldenFrame. Path is
+// resolved like FieldInfo.Name, and Value is expressed in the field
+// unit.
+type GoldenField struct {
+	Path  string
+	Value float64
+}
+
+// GoldenFrame is a reference frame of the corpus shared by the Go and
+// C test suites, in specs/golden-frames.txt.
+type GoldenFrame struct {
+	Line    int
+	Frame   socketcan.CanFrame
+	Message string
+	ID      NodeID
+	Fields  []GoldenField
+}
+
+// ReadGoldenFrames parses a golden frames corpus. Each non-empty line
+// not starting with '#' holds whitespace separated columns:
+//
+//	<IDT>#<payload or R> <message name> <node ID> [<path>=<value> ...]
+//
+// e.g. "5c1#a40fa21f80 Zeus.SetPoint 1 Humidity=24.4415 Wind=128".
+// The message name is the one returned by MessageName.
+func ReadGoldenFrames(r io.Reader) ([]GoldenFrame, error) {
+	var res []GoldenFrame
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line += 1
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+		g, err := parseGoldenFrame(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		g.Line = line
+		res = append(res, g)
+	}
+	return res, scanner.Err()
+}
+
+// LoadGoldenFrames reads a golden frames corpus file.
+func LoadGoldenFrames(path string) ([]GoldenFrame, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadGoldenFrames(file)
+}
+
+func parseGoldenFrame(text string) (GoldenFrame, error) {
+	columns := strings.Fields(text)
+	if len(columns) < 3 {
+		return GoldenFrame{}, fmt.Errorf("expected at least 3 columns, got %d", len(columns))
+	}
+	res := GoldenFrame{Message: columns[1]}
+
+	idt, payload, ok := strings.Cut(columns[0], "#")
+	if ok == false {
+		return GoldenFrame{}, fmt.Errorf("invalid frame '%s'", columns[0])
+	}
+	ID, err := strconv.ParseUint(idt, 16, 11)
+	if err != nil {
+		return GoldenFrame{}, fmt.Errorf("invalid IDT '%s'", idt)
+	}
+	res.Frame.ID = uint32(ID)
+	if payload == "R" {
+		res.Frame.RTR = true
+	} else {
+		res.Frame.Data, err = hex.DecodeString(payload)
+		if err != nil || len(res.Frame.Data) > 8 {
+			return GoldenFrame{}, fmt.Errorf("invalid payload '%s'", payload)
+		}
+	}
+	res.Frame.Dlc = uint8(len(res.Frame.Data))
+
+	node, err := strconv.ParseUint(columns[2], 0, 3)
+	if err != nil {
+		return GoldenFrame{}, fmt.Errorf("invalid node ID '%s'", columns[2])
+	}
+	res.ID = NodeID(node)
+
+	for _, column := range columns[3:] {
+		path, value, ok := strings.Cut(column, "=")
+		if ok == false {
+			return GoldenFrame{}, fmt.Errorf("invalid field '%s'", column)
+		}
+		v, err := parseGoldenValue(value)
+		if err != nil {
+			return GoldenFrame{}, fmt.Errorf("invalid value for field '%s': %w", path, err)
+		}
+		res.Fields = append(res.Fields, GoldenField{Path: path, Value: v})
+	}
+	return res, nil
+}
+
+func parseGoldenValue(s string) (float64, error) {
+	if v, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return float64(v), nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
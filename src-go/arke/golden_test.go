@@ -0,0 +1,161 @@
+package arke
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+	. "gopkg.in/check.v1"
+)
+
+type GoldenSuite struct {
+	Frames []GoldenFrame
+}
+
+var _ = Suite(&GoldenSuite{})
+
+const goldenFramesPath = "../../specs/golden-frames.txt"
+
+func (s *GoldenSuite) SetUpSuite(c *C) {
+	var err error
+	s.Frames, err = LoadGoldenFrames(goldenFramesPath)
+	c.Assert(err, IsNil)
+}
+
+// encodeGolden re-encodes a decoded message with the functions a host
+// or a node would use to emit it.
+func encodeGolden(m ReceivableMessage, original socketcan.CanFrame, ID NodeID) (socketcan.CanFrame, error) {
+	switch d := m.(type) {
+	case *MessageRequestData:
+		return makeRequestFrame(d.Class, d.ID), nil
+	case *ResetRequestData:
+		return MakeResetRequest(d.Class, d.ID), nil
+	case *HeartBeatRequestData:
+		if d.Period == 0 {
+			return MakePing(d.Class), nil
+		}
+		return MakeHeartBeatRequest(d.Class, d.Period), nil
+	case *SynchronisationRequestData:
+		return MakeSynchronisationRequest(d.Class, d.Sequence), nil
+	case *SynchronisationReplyData:
+		return MakeSynchronisationReply(d.Class, d.ID, d.Sequence, d.Timestamp), nil
+	case *IDChangeRequestData:
+		return MakeIDChangeRequest(d.Class, d.Old, d.New), nil
+	case *ErrorReportData:
+		return MakeErrorReport(d.Class, d.ID, d.ErrorCode), nil
+	case *HeartBeatData:
+		return MakeHeartBeat(d.Class, d.ID, d.Version()), nil
+	case *RawMessage:
+		res := socketcan.CanFrame{ID: d.IDT(), Data: make([]byte, 8)}
+		n, err := d.Marshal(res.Data)
+		res.Dlc = uint8(n)
+		return res, err
+	case Message:
+		mType, _, _ := ExtractCANIDT(original.ID)
+		res := socketcan.CanFrame{
+			ID:   MakeCANIDT(mType, d.MessageClassID(), ID),
+			Data: make([]byte, 8),
+		}
+		n, err := d.Marshal(res.Data)
+		res.Dlc = uint8(n)
+		return res, err
+	}
+	return socketcan.CanFrame{}, nil
+}
+
+// frameText formats a frame like the corpus does.
+func frameText(f socketcan.CanFrame) string {
+	if f.RTR == true {
+		return fmt.Sprintf("%03x#R", f.ID)
+	}
+	return fmt.Sprintf("%03x#%s", f.ID, hex.EncodeToString(f.Data[:f.Dlc]))
+}
+
+func (s *GoldenSuite) TestDecodeAndEncode(c *C) {
+	for _, g := range s.Frames {
+		comment := Commentf("%s:%d", goldenFramesPath, g.Line)
+		m, ID, err := ParseMessage(&g.Frame)
+		if c.Check(err, IsNil, comment) == false {
+			continue
+		}
+		name, err := MessageName(m)
+		c.Check(err, IsNil, comment)
+		c.Check(name, Equals, g.Message, comment)
+		c.Check(ID, Equals, g.ID, comment)
+
+		fields := map[string]FieldInfo{}
+		if d, ok := m.(Describable); ok == true {
+			for _, f := range d.Fields() {
+				fields[f.Name] = f
+			}
+		}
+		v := reflect.Indirect(reflect.ValueOf(m))
+		expected := map[string]bool{}
+		for _, gf := range g.Fields {
+			expected[gf.Path] = true
+			fv, err := fieldByPath(v, gf.Path)
+			if c.Check(err, IsNil, comment) == false {
+				continue
+			}
+			value, err := fieldValue(fv, fields[gf.Path].Unit)
+			c.Check(err, IsNil, comment)
+			// values are written with a limited number of digits,
+			// they should still designate the encoded raw value.
+			tolerance := 0.0
+			if f, ok := fields[gf.Path]; ok == true && f.Resolution != 1 {
+				tolerance = f.Resolution / 2
+			}
+			c.Check(math.Abs(value-gf.Value) <= tolerance, Equals, true,
+				Commentf("%s:%d %s: got %g, expected %g", goldenFramesPath, g.Line, gf.Path, value, gf.Value))
+		}
+		for _, path := range leafFields(v.Type(), "") {
+			c.Check(expected[path], Equals, true, Commentf("%s:%d is missing %s", goldenFramesPath, g.Line, path))
+		}
+
+		encoded, err := encodeGolden(m, g.Frame, ID)
+		c.Check(err, IsNil, comment)
+		c.Check(frameText(encoded), Equals, frameText(g.Frame), comment)
+	}
+}
+
+func (s *GoldenSuite) TestCoversAllMessages(c *C) {
+	covered := map[string]bool{}
+	for _, g := range s.Frames {
+		covered[g.Message] = true
+	}
+	for _, mc := range MessageClasses() {
+		if _, err := NewMessage(mc); err != nil {
+			continue
+		}
+		c.Check(covered[mc.String()], Equals, true, Commentf("%s has no golden frame", mc))
+	}
+	for name := range builtinMessages {
+		if name == "arke.RawMessage" {
+			continue
+		}
+		c.Check(covered[name], Equals, true, Commentf("%s has no golden frame", name))
+	}
+}
+
+func (s *GoldenSuite) TestReadErrors(c *C) {
+	testdata := []struct {
+		Text  string
+		Error string
+	}{
+		{"5c1#a4 Zeus.SetPoint", "line 1: expected at least 3 columns, got 2"},
+		{"# comment\n5c1a4 Zeus.SetPoint 1", "line 2: invalid frame '5c1a4'"},
+		{"fc1#a4 Zeus.SetPoint 1", "line 1: invalid IDT 'fc1'"},
+		{"5c1#a4g Zeus.SetPoint 1", "line 1: invalid payload 'a4g'"},
+		{"5c1#000000000000000000 Zeus.SetPoint 1", "line 1: invalid payload '000000000000000000'"},
+		{"5c1#a4 Zeus.SetPoint 8", "line 1: invalid node ID '8'"},
+		{"5c1#a4 Zeus.SetPoint 1 Wind", "line 1: invalid field 'Wind'"},
+		{"5c1#a4 Zeus.SetPoint 1 Wind=x", "line 1: invalid value for field 'Wind': .*"},
+	}
+	for _, d := range testdata {
+		_, err := ReadGoldenFrames(strings.NewReader(d.Text))
+		c.Check(err, ErrorMatches, d.Error)
+	}
+}
@@ -0,0 +1,29 @@
+// arkegen generates the Go message types, the C header and the
+// specification tables from specs/protocol.json. With -check, it only
+// reports the generated files that disagree with the description.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/formicidae-tracker/libarke/src-go/arke/internal/protocol"
+)
+
+func main() {
+	root := flag.String("root", ".", "root of the libarke repository")
+	check := flag.Bool("check", false, "check that generated files are up to date instead of writing them")
+	flag.Parse()
+
+	var err error
+	if *check == true {
+		err = protocol.Check(*root)
+	} else {
+		err = protocol.Write(*root)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "arkegen: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,112 @@
+package protocol
+
+import (
+	"fmt"
+	"strings"
+)
+
+type cEntry struct {
+	Name, Value string
+}
+
+// cEnum renders aligned enumerators, followed by the extra name and
+// value pairs given by the template.
+func cEnum(entries []cEntry, extra ...string) string {
+	entries = append([]cEntry{}, entries...)
+	for i := 0; i+1 < len(extra); i += 2 {
+		entries = append(entries, cEntry{Name: extra[i], Value: extra[i+1]})
+	}
+	width := 0
+	for _, e := range entries {
+		width = max(width, len(e.Name))
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\t%-*s = %s,\n", width, e.Name, e.Value)
+	}
+	return b.String()
+}
+
+type headerData struct {
+	NodeClasses     []cEntry
+	NetworkCommands []cEntry
+	Messages        []cEntry
+	Structs         string
+}
+
+func (p *Protocol) cType(f Field) string {
+	if t, ok := p.lookupType(f.Type); ok == true {
+		return t.C
+	}
+	if len(f.CType) > 0 {
+		return f.CType
+	}
+	if p.isBitField(f) {
+		if f.Bits <= 8 {
+			return "uint8_t"
+		}
+		return "uint16_t"
+	}
+	if f.Signed == true {
+		return fmt.Sprintf("int%d_t", f.Bits)
+	}
+	return fmt.Sprintf("uint%d_t", f.Bits)
+}
+
+func (p *Protocol) writeCStruct(b *strings.Builder, name string, fields []Field) {
+	// groups were validated when loading the description.
+	groups, _ := groupFields(fields, Field.CName)
+	width := 0
+	for _, g := range groups {
+		width = max(width, len(p.cType(g.Fields[0])))
+	}
+	fmt.Fprintf(b, "struct %s_t {\n", name)
+	for _, g := range groups {
+		f := g.Fields[0]
+		fmt.Fprintf(b, "\t%-*s %s", width, p.cType(f), g.Name)
+		if g.Array == true {
+			fmt.Fprintf(b, "[%d]", len(g.Fields))
+		} else if p.isBitField(f) {
+			fmt.Fprintf(b, " : %d", f.Bits)
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("} __attribute__((packed));\n")
+	fmt.Fprintf(b, "typedef struct %s_t %s;\n", name, name)
+}
+
+func (p *Protocol) headerData() headerData {
+	res := headerData{}
+	for _, n := range p.NodeClasses {
+		res.NodeClasses = append(res.NodeClasses, cEntry{
+			Name:  "ARKE_" + strings.ToUpper(n.Name),
+			Value: fmt.Sprintf("0x%02x", n.Class),
+		})
+	}
+	for _, c := range p.NetworkCommands {
+		res.NetworkCommands = append(res.NetworkCommands, cEntry{
+			Name:  c.C,
+			Value: fmt.Sprintf("0x%02x", c.Code),
+		})
+	}
+	var structs []string
+	for _, t := range p.Types {
+		var b strings.Builder
+		p.writeCStruct(&b, t.C, t.Fields)
+		structs = append(structs, b.String())
+	}
+	for _, m := range p.Messages {
+		res.Messages = append(res.Messages, cEntry{
+			Name:  m.CEnum(),
+			Value: fmt.Sprintf("0x%02x", m.Class),
+		})
+		if m.Undefined == true {
+			continue
+		}
+		var b strings.Builder
+		p.writeCStruct(&b, m.CTypeName(), m.Fields)
+		structs = append(structs, b.String())
+	}
+	res.Structs = strings.Join(structs, "\n")
+	return res
+}
@@ -0,0 +1,105 @@
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Paths of the description, the templates and the generated files,
+// relative to the repository root.
+const (
+	Definition     = "specs/protocol.json"
+	HeaderTemplate = "specs/templates/arke.h.tmpl"
+	SpecTemplate   = "specs/templates/specs.md.tmpl"
+	GoOutput       = "src-go/arke/protocol_gen.go"
+	HeaderOutput   = "include/arke.h"
+	SpecOutput     = "specs/specs.md"
+)
+
+// File is a generated file.
+type File struct {
+	Path    string
+	Content []byte
+}
+
+// Generate renders all the files derived from the description in the
+// repository root.
+func Generate(root string) ([]File, error) {
+	p, err := Load(root)
+	if err != nil {
+		return nil, err
+	}
+	goSource, err := p.renderGo()
+	if err != nil {
+		return nil, err
+	}
+	header, err := p.renderTemplate(root, HeaderTemplate, p.headerData())
+	if err != nil {
+		return nil, err
+	}
+	spec, err := p.renderTemplate(root, SpecTemplate, p.specData())
+	if err != nil {
+		return nil, err
+	}
+	return []File{
+		{Path: GoOutput, Content: goSource},
+		{Path: HeaderOutput, Content: header},
+		{Path: SpecOutput, Content: spec},
+	}, nil
+}
+
+// Write generates and writes all files in the repository root.
+func Write(root string) error {
+	files, err := Generate(root)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(root, f.Path), f.Content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check returns an error listing the generated files that disagree
+// with the description.
+func Check(root string) error {
+	files, err := Generate(root)
+	if err != nil {
+		return err
+	}
+	var outdated []string
+	for _, f := range files {
+		existing, err := os.ReadFile(filepath.Join(root, f.Path))
+		if err != nil || bytes.Equal(existing, f.Content) == false {
+			outdated = append(outdated, f.Path)
+		}
+	}
+	if len(outdated) > 0 {
+		return fmt.Errorf("%s disagree with %s, run go generate", strings.Join(outdated, ", "), Definition)
+	}
+	return nil
+}
+
+func (p *Protocol) renderTemplate(root, path string, data any) ([]byte, error) {
+	text, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		return nil, err
+	}
+	t, err := template.New(filepath.Base(path)).Funcs(template.FuncMap{
+		"enum": cEnum,
+	}).Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,235 @@
+package protocol
+
+import (
+	"fmt"
+	"go/format"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func goFloat(expr string, def float64) string {
+	return strconv.FormatFloat(evaluateOr(expr, def), 'g', -1, 64)
+}
+
+// goFieldInfo returns the expression building the FieldInfo of a leaf.
+func goFieldInfo(l Leaf) string {
+	res := evaluateOr(l.Resolution, 1)
+	bias := evaluateOr(l.Bias, 0)
+	var expr string
+	if len(l.Unit) == 0 && l.Signed == false && res == 1 && bias == 0 {
+		expr = fmt.Sprintf("uintField(%q, %d, %d)", l.Path, l.Offset, l.Bits)
+	} else {
+		expr = fmt.Sprintf("field(%q, %q, %d, %d, %t, %s, %s)",
+			l.Path, l.Unit, l.Offset, l.Bits, l.Signed,
+			goFloat(l.Resolution, 1), goFloat(l.Bias, 0))
+	}
+	if len(l.Min) > 0 {
+		expr += fmt.Sprintf(".limit(%s, %s)", goFloat(l.Min, 0), goFloat(l.Max, 0))
+	}
+	return expr
+}
+
+func (p *Protocol) goType(f Field) string {
+	if t, ok := p.lookupType(f.Type); ok == true {
+		return t.Go
+	}
+	return f.Go
+}
+
+// goDurationUnits are the time.Duration constants of the units of
+// duration fields.
+var goDurationUnits = map[string]string{
+	"s":  "time.Second",
+	"ms": "time.Millisecond",
+	"µs": "time.Microsecond",
+}
+
+// goFieldValue returns the expression of the value of a leaf of m,
+// expressed in its unit, as checked by Validate().
+func (p *Protocol) goFieldValue(l Leaf) string {
+	if p.goType(l.Field) == "time.Duration" {
+		return fmt.Sprintf("float64(m.%s) / float64(%s)", l.Path, goDurationUnits[l.Unit])
+	}
+	return fmt.Sprintf("float64(m.%s)", l.Path)
+}
+
+// goIntegerRanges are the ranges of the Go integer types of scalar
+// fields, including the hand-written types of the arke package.
+var goIntegerRanges = map[string][2]float64{
+	"uint8":            {0, math.MaxUint8},
+	"int8":             {math.MinInt8, math.MaxInt8},
+	"uint16":           {0, math.MaxUint16},
+	"int16":            {math.MinInt16, math.MaxInt16},
+	"uint32":           {0, math.MaxUint32},
+	"int32":            {math.MinInt32, math.MaxInt32},
+	"FanStatusAndRPM":  {0, math.MaxUint16},
+	"WaterLevelStatus": {0, math.MaxUint8},
+	"ZeusStatusValue":  {0, math.MaxUint8},
+}
+
+// leafRange returns the valid range of a leaf, as computed by its
+// FieldInfo.
+func leafRange(l Leaf) (float64, float64) {
+	if len(l.Min) > 0 {
+		return evaluateOr(l.Min, 0), evaluateOr(l.Max, 0)
+	}
+	rawMin, rawMax := 0.0, math.Exp2(float64(l.Bits))-1
+	if l.Signed == true {
+		rawMin, rawMax = -math.Exp2(float64(l.Bits-1)), math.Exp2(float64(l.Bits-1))-1
+	}
+	res, bias := evaluateOr(l.Resolution, 1), evaluateOr(l.Bias, 0)
+	return rawMin*res + bias, rawMax*res + bias
+}
+
+// goNeedsCheck returns false if every value of the Go type of a leaf
+// is within its valid range, so checking it can never fail.
+func (p *Protocol) goNeedsCheck(l Leaf) bool {
+	typeRange, ok := goIntegerRanges[p.goType(l.Field)]
+	if ok == false {
+		return true
+	}
+	min, max := leafRange(l)
+	return min > typeRange[0] || max < typeRange[1]
+}
+
+// writeGoValidate writes a Validate() method comparing each leaf to
+// the range of its FieldInfo, cached in a package variable so that
+// validation does not allocate. Leaves whose range covers their Go
+// type are not checked.
+func (p *Protocol) writeGoValidate(b *strings.Builder, name string, fields []Field) {
+	infos := strings.ToLower(name[:1]) + name[1:] + "Fields"
+	var checks []string
+	for i, l := range p.Leaves(fields) {
+		if p.goNeedsCheck(l) == true {
+			checks = append(checks, fmt.Sprintf("checkRange(&%s[%d], %s)", infos, i, p.goFieldValue(l)))
+		}
+	}
+	if len(checks) == 0 {
+		fmt.Fprintf(b, "func (m %s) Validate() error {\n// every value of the fields is valid\nreturn nil\n}\n\n", name)
+		return
+	}
+	fmt.Fprintf(b, "var %s = %s{}.Fields()\n\n", infos, name)
+	fmt.Fprintf(b, "func (m %s) Validate() error {\n", name)
+	for _, check := range checks[:len(checks)-1] {
+		fmt.Fprintf(b, "if err := %s; err != nil {\nreturn err\n}\n", check)
+	}
+	fmt.Fprintf(b, "return %s\n}\n\n", checks[len(checks)-1])
+}
+
+func (p *Protocol) writeGoStruct(b *strings.Builder, name string, fields []Field) error {
+	groups, err := groupFields(fields, func(f Field) string { return f.Name })
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	for _, g := range groups {
+		typ := p.goType(g.Fields[0])
+		if g.Array == true {
+			typ = fmt.Sprintf("[%d]%s", len(g.Fields), typ)
+		}
+		fmt.Fprintf(b, "\t%s %s", g.Name, typ)
+		if len(g.Fields[0].Tag) > 0 {
+			fmt.Fprintf(b, " `%s`", g.Fields[0].Tag)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n\n")
+	return nil
+}
+
+func (p *Protocol) usesTime() bool {
+	uses := func(fields []Field) bool {
+		for _, f := range fields {
+			if strings.HasPrefix(f.Go, "time.") {
+				return true
+			}
+		}
+		return false
+	}
+	for _, t := range p.Types {
+		if uses(t.Fields) {
+			return true
+		}
+	}
+	for _, m := range p.Messages {
+		if uses(m.Fields) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Protocol) renderGo() ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by arkegen from %s. DO NOT EDIT.\n\n", Definition)
+	b.WriteString("package arke\n\n")
+	if p.usesTime() {
+		b.WriteString("import \"time\"\n\n")
+	}
+
+	b.WriteString("const (\n")
+	for _, n := range p.NodeClasses {
+		fmt.Fprintf(&b, "%sClass NodeClass = 0x%02x\n", n.Name, n.Class)
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("const (\n")
+	for _, c := range p.NetworkCommands {
+		fmt.Fprintf(&b, "%s MessageClass = 0x%02x\n", c.Name, c.Code)
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("const (\n")
+	for _, c := range p.NetworkCommands {
+		fmt.Fprintf(&b, "%sMessage MessageClass = MessageClass(0x7f8 | %s)\n", c.Name, c.Name)
+	}
+	for _, m := range p.Messages {
+		fmt.Fprintf(&b, "%sMessage MessageClass = 0x%02x\n", m.GoName(), m.Class)
+	}
+	b.WriteString(")\n\n")
+
+	for _, t := range p.Types {
+		if err := p.writeGoStruct(&b, t.Go, t.Fields); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, m := range p.Messages {
+		if m.Undefined == true {
+			continue
+		}
+		name := m.GoName()
+		fmt.Fprintf(&b, "// %s is the payload of %s messages (0x%02x).\n", name, m.Name, m.Class)
+		if err := p.writeGoStruct(&b, name, m.Fields); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "func (m *%s) MessageClassID() MessageClass {\nreturn %sMessage\n}\n\n", name, name)
+		fmt.Fprintf(&b, "func (m %s) Fields() []FieldInfo {\nreturn []FieldInfo{\n", name)
+		for _, l := range p.Leaves(m.Fields) {
+			fmt.Fprintf(&b, "%s,\n", goFieldInfo(l))
+		}
+		b.WriteString("}\n}\n\n")
+		p.writeGoValidate(&b, name, m.Fields)
+	}
+
+	b.WriteString("func init() {\n")
+	for _, n := range p.NodeClasses {
+		fmt.Fprintf(&b, "mustRegisterNodeClass(%sClass, %q)\n", n.Name, n.Name)
+	}
+	for _, m := range p.Messages {
+		if m.Undefined == true {
+			fmt.Fprintf(&b, "mustRegisterMessage(%sMessage, %q, nil)\n", m.GoName(), m.Name)
+			continue
+		}
+		fmt.Fprintf(&b, "mustRegisterMessage(%sMessage, %q, func() Message { return &%s{} })\n",
+			m.GoName(), m.Name, m.GoName())
+	}
+	b.WriteString("}\n")
+
+	res, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("could not format generated go code: %w", err)
+	}
+	return res, nil
+}
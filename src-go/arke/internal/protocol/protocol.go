@@ -0,0 +1,476 @@
+// Package protocol loads the machine-readable description of the Arke
+// protocol in specs/protocol.json, and renders the Go message types,
+// the C header and the specification tables derived from it.
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Code is a class or command value. In the description it is written
+// as a string, e.g. "0x38" or "0b001".
+type Code uint8
+
+func (c *Code) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("code must be a string: %w", err)
+	}
+	v, err := strconv.ParseUint(s, 0, 8)
+	if err != nil {
+		return fmt.Errorf("invalid code '%s': %w", s, err)
+	}
+	*c = Code(v)
+	return nil
+}
+
+type NodeClass struct {
+	Name  string `json:"name"`
+	Class Code   `json:"class"`
+}
+
+type NetworkCommand struct {
+	Name           string `json:"name"`
+	Code           Code   `json:"code"`
+	C              string `json:"c"`
+	Title          string `json:"title"`
+	Implementation string `json:"implementation"`
+	Payload        string `json:"payload"`
+}
+
+// Field is a payload field. Fields are packed little endian in their
+// declaration order. A field either has a scalar Go type and a bit
+// size, or refers to a compound Type. Names ending with an index, like
+// "Temperature[1]", are gathered into arrays in the Go struct, and
+// likewise for C names.
+type Field struct {
+	Name        string   `json:"name"`
+	Go          string   `json:"go"`
+	Tag         string   `json:"tag"`
+	Type        string   `json:"type"`
+	C           string   `json:"c"`
+	CType       string   `json:"cType"`
+	Bits        int      `json:"bits"`
+	Signed      bool     `json:"signed"`
+	Unit        string   `json:"unit"`
+	Resolution  string   `json:"resolution"`
+	Bias        string   `json:"bias"`
+	Min         string   `json:"min"`
+	Max         string   `json:"max"`
+	Description string   `json:"description"`
+	Notes       []string `json:"notes"`
+}
+
+// CName returns the name of the field in the C struct.
+func (f Field) CName() string {
+	if len(f.C) > 0 {
+		return f.C
+	}
+	return f.Name
+}
+
+// Type is a compound type, reused by several message fields.
+type Type struct {
+	Name   string  `json:"name"`
+	Go     string  `json:"go"`
+	C      string  `json:"c"`
+	Fields []Field `json:"fields"`
+}
+
+type Message struct {
+	Name        string  `json:"name"`
+	Class       Code    `json:"class"`
+	C           string  `json:"c"`
+	CType       string  `json:"cType"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Access      string  `json:"access"`
+	Periodic    string  `json:"periodic"`
+	Undefined   bool    `json:"undefined"`
+	Fields      []Field `json:"fields"`
+}
+
+// Node returns the name of the node class handling the message.
+func (m Message) Node() string {
+	node, _, _ := strings.Cut(m.Name, ".")
+	return node
+}
+
+// GoName returns the name of the Go payload type, e.g. "ZeusSetPoint".
+func (m Message) GoName() string {
+	return strings.ReplaceAll(m.Name, ".", "")
+}
+
+// CEnum returns the name of the C message class enumerator,
+// e.g. "ARKE_ZEUS_SET_POINT".
+func (m Message) CEnum() string {
+	if len(m.C) > 0 {
+		return m.C
+	}
+	return "ARKE_" + screamingSnake(m.GoName())
+}
+
+// CTypeName returns the name of the C payload struct typedef.
+func (m Message) CTypeName() string {
+	if len(m.CType) > 0 {
+		return m.CType
+	}
+	return "Arke" + m.GoName()
+}
+
+func screamingSnake(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToUpper(b.String())
+}
+
+// Protocol is the whole protocol description.
+type Protocol struct {
+	NodeClasses     []NodeClass      `json:"nodeClasses"`
+	NetworkCommands []NetworkCommand `json:"networkCommands"`
+	Types           []Type           `json:"types"`
+	Messages        []Message        `json:"messages"`
+}
+
+// Leaf is a scalar field of a message, with its absolute path and
+// position in the payload.
+type Leaf struct {
+	Field
+	Path   string
+	Offset int
+}
+
+// Parse decodes and validates a protocol description.
+func Parse(data []byte) (*Protocol, error) {
+	p := &Protocol{}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(p); err != nil {
+		return nil, fmt.Errorf("could not decode protocol: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Load reads the description at Definition in the repository root.
+func Load(root string) (*Protocol, error) {
+	data, err := os.ReadFile(filepath.Join(root, Definition))
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func (p *Protocol) lookupType(name string) (Type, bool) {
+	idx := slices.IndexFunc(p.Types, func(t Type) bool { return t.Name == name })
+	if idx < 0 {
+		return Type{}, false
+	}
+	return p.Types[idx], true
+}
+
+func (p *Protocol) nodeClass(name string) (NodeClass, bool) {
+	idx := slices.IndexFunc(p.NodeClasses, func(n NodeClass) bool { return n.Name == name })
+	if idx < 0 {
+		return NodeClass{}, false
+	}
+	return p.NodeClasses[idx], true
+}
+
+// nodeRange returns the highest message class handled by a node class,
+// i.e. the one before the next node class, or 0x3f.
+func (p *Protocol) nodeRange(n NodeClass) Code {
+	end := Code(0x3f)
+	for _, other := range p.NodeClasses {
+		if other.Class > n.Class && other.Class <= end {
+			end = other.Class - 1
+		}
+	}
+	return end
+}
+
+// Bits returns the size of a field in bits.
+func (p *Protocol) Bits(f Field) int {
+	if t, ok := p.lookupType(f.Type); ok == true {
+		res := 0
+		for _, sub := range t.Fields {
+			res += p.Bits(sub)
+		}
+		return res
+	}
+	return f.Bits
+}
+
+// Size returns the payload length of a message in bytes.
+func (p *Protocol) Size(m Message) int {
+	bits := 0
+	for _, f := range m.Fields {
+		bits += p.Bits(f)
+	}
+	return bits / 8
+}
+
+// Leaves flattens fields into their scalar components.
+func (p *Protocol) Leaves(fields []Field) []Leaf {
+	var res []Leaf
+	offset := 0
+	for _, f := range fields {
+		res = append(res, p.leaves(f, "", offset)...)
+		offset += p.Bits(f)
+	}
+	return res
+}
+
+func (p *Protocol) leaves(f Field, prefix string, offset int) []Leaf {
+	t, ok := p.lookupType(f.Type)
+	if ok == false {
+		return []Leaf{{Field: f, Path: prefix + f.Name, Offset: offset}}
+	}
+	var res []Leaf
+	for _, sub := range t.Fields {
+		res = append(res, p.leaves(sub, prefix+f.Name+".", offset)...)
+		offset += p.Bits(sub)
+	}
+	return res
+}
+
+var indexedName = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(?:\[([0-9]+)\])?$`)
+
+// splitIndex splits "Temperature[1]" into "Temperature" and 1. The
+// index is -1 for plain names.
+func splitIndex(name string) (string, int, error) {
+	m := indexedName.FindStringSubmatch(name)
+	if m == nil {
+		return "", 0, fmt.Errorf("invalid field name '%s'", name)
+	}
+	if len(m[2]) == 0 {
+		return m[1], -1, nil
+	}
+	idx, _ := strconv.Atoi(m[2])
+	return m[1], idx, nil
+}
+
+// group is a struct member made of one or several consecutive
+// fields sharing a base name.
+type group struct {
+	Name   string
+	Fields []Field
+	Array  bool
+}
+
+// groupFields gathers consecutive indexed names in arrays. name
+// selects either the Go or the C name of the fields.
+func groupFields(fields []Field, name func(Field) string) ([]group, error) {
+	var res []group
+	for _, f := range fields {
+		base, idx, err := splitIndex(name(f))
+		if err != nil {
+			return nil, err
+		}
+		if idx < 0 {
+			res = append(res, group{Name: base, Fields: []Field{f}})
+			continue
+		}
+		if len(res) > 0 && res[len(res)-1].Array == true && res[len(res)-1].Name == base {
+			last := &res[len(res)-1]
+			if idx != len(last.Fields) {
+				return nil, fmt.Errorf("field '%s' is not the next element of '%s'", name(f), base)
+			}
+			last.Fields = append(last.Fields, f)
+			continue
+		}
+		if idx != 0 {
+			return nil, fmt.Errorf("array '%s' must start at index 0", base)
+		}
+		res = append(res, group{Name: base, Fields: []Field{f}, Array: true})
+	}
+	seen := make(map[string]bool)
+	for _, g := range res {
+		if seen[g.Name] == true {
+			return nil, fmt.Errorf("duplicated field '%s'", g.Name)
+		}
+		seen[g.Name] = true
+	}
+	return res, nil
+}
+
+func (p *Protocol) validateFields(context string, fields []Field) error {
+	for _, f := range fields {
+		if len(f.Type) > 0 {
+			if _, ok := p.lookupType(f.Type); ok == false {
+				return fmt.Errorf("%s: field '%s' has unknown type '%s'", context, f.Name, f.Type)
+			}
+			if len(f.Go) > 0 || f.Bits != 0 {
+				return fmt.Errorf("%s: field '%s' cannot have both a type and a go type or size", context, f.Name)
+			}
+			continue
+		}
+		if len(f.Go) == 0 {
+			return fmt.Errorf("%s: field '%s' needs a go type", context, f.Name)
+		}
+		if f.Bits <= 0 || f.Bits > 32 {
+			return fmt.Errorf("%s: field '%s' has invalid size %d", context, f.Name, f.Bits)
+		}
+		for _, expr := range []string{f.Resolution, f.Bias, f.Min, f.Max} {
+			if len(expr) == 0 {
+				continue
+			}
+			if _, err := evaluate(expr); err != nil {
+				return fmt.Errorf("%s: field '%s': %w", context, f.Name, err)
+			}
+		}
+		if (len(f.Min) == 0) != (len(f.Max) == 0) {
+			return fmt.Errorf("%s: field '%s' needs both min and max", context, f.Name)
+		}
+	}
+	groups, err := groupFields(fields, func(f Field) string { return f.Name })
+	if err != nil {
+		return fmt.Errorf("%s: %w", context, err)
+	}
+	if err := checkArrays(groups, p.goType); err != nil {
+		return fmt.Errorf("%s: %w", context, err)
+	}
+	groups, err = groupFields(fields, Field.CName)
+	if err != nil {
+		return fmt.Errorf("%s: %w", context, err)
+	}
+	if err := checkArrays(groups, p.cType); err != nil {
+		return fmt.Errorf("%s: %w", context, err)
+	}
+	for _, g := range groups {
+		if g.Array == true && p.isBitField(g.Fields[0]) {
+			return fmt.Errorf("%s: C array '%s' cannot be a bit field", context, g.Name)
+		}
+	}
+	return nil
+}
+
+// checkArrays ensures that all elements of an array share the same
+// type.
+func checkArrays(groups []group, typeOf func(Field) string) error {
+	for _, g := range groups {
+		for _, f := range g.Fields[1:] {
+			if typeOf(f) != typeOf(g.Fields[0]) {
+				return fmt.Errorf("elements of array '%s' must share the same type", g.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Protocol) isBitField(f Field) bool {
+	if len(f.Type) > 0 {
+		return false
+	}
+	return f.Bits != 8 && f.Bits != 16 && f.Bits != 32
+}
+
+func (p *Protocol) validate() error {
+	names := make(map[string]bool)
+	classes := make(map[Code]string)
+	for _, n := range p.NodeClasses {
+		if n.Class > 0x3f {
+			return fmt.Errorf("node class %s: invalid class 0x%02x", n.Name, n.Class)
+		}
+		if names[n.Name] == true || len(classes[n.Class]) > 0 {
+			return fmt.Errorf("node class %s: duplicated name or class", n.Name)
+		}
+		names[n.Name] = true
+		classes[n.Class] = n.Name
+	}
+
+	codes := make(map[Code]bool)
+	for _, c := range p.NetworkCommands {
+		if c.Code > 0x07 || codes[c.Code] == true {
+			return fmt.Errorf("network command %s: invalid or duplicated code %d", c.Name, c.Code)
+		}
+		codes[c.Code] = true
+	}
+
+	for _, t := range p.Types {
+		if len(t.Go) == 0 || len(t.C) == 0 {
+			return fmt.Errorf("type %s: needs a go and a C type", t.Name)
+		}
+		if err := p.validateFields("type "+t.Name, t.Fields); err != nil {
+			return err
+		}
+		if p.Bits(Field{Type: t.Name})%8 != 0 {
+			return fmt.Errorf("type %s: size is not a whole number of bytes", t.Name)
+		}
+	}
+
+	messages := make(map[Code]string)
+	for _, m := range p.Messages {
+		node, ok := p.nodeClass(m.Node())
+		if ok == false {
+			return fmt.Errorf("message %s: unknown node class '%s'", m.Name, m.Node())
+		}
+		if m.Class < node.Class || m.Class > p.nodeRange(node) || node.Class == 0 {
+			return fmt.Errorf("message %s: class 0x%02x is outside of %s range", m.Name, m.Class, node.Name)
+		}
+		if other, ok := messages[m.Class]; ok == true {
+			return fmt.Errorf("message %s: class 0x%02x is already used by %s", m.Name, m.Class, other)
+		}
+		messages[m.Class] = m.Name
+		if m.Undefined == true {
+			if len(m.Fields) > 0 {
+				return fmt.Errorf("message %s: undefined payload cannot have fields", m.Name)
+			}
+			continue
+		}
+		if len(m.Fields) == 0 {
+			return fmt.Errorf("message %s: needs fields or an undefined payload", m.Name)
+		}
+		if err := p.validateFields("message "+m.Name, m.Fields); err != nil {
+			return err
+		}
+		bits := 0
+		for _, f := range m.Fields {
+			bits += p.Bits(f)
+		}
+		if bits%8 != 0 || bits > 64 {
+			return fmt.Errorf("message %s: invalid payload size of %d bits", m.Name, bits)
+		}
+	}
+	return nil
+}
+
+// evaluate computes a constant of the description, either a number or
+// a ratio like "100/16382".
+func evaluate(expr string) (float64, error) {
+	num, den, ratio := strings.Cut(expr, "/")
+	res, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid constant '%s'", expr)
+	}
+	if ratio == false {
+		return res, nil
+	}
+	d, err := strconv.ParseFloat(strings.TrimSpace(den), 64)
+	if err != nil || d == 0 {
+		return 0, fmt.Errorf("invalid constant '%s'", expr)
+	}
+	return res / d, nil
+}
+
+func evaluateOr(expr string, def float64) float64 {
+	if len(expr) == 0 {
+		return def
+	}
+	res, _ := evaluate(expr)
+	return res
+}
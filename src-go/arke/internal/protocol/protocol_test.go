@@ -0,0 +1,128 @@
+package protocol
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type ProtocolSuite struct{}
+
+var _ = Suite(&ProtocolSuite{})
+
+const repositoryRoot = "../../../.."
+
+func (s *ProtocolSuite) TestRejectsInconsistentDescriptions(c *C) {
+	message := func(class, fields string) string {
+		return `{"nodeClasses":[{"name":"Zeus","class":"0x38"},{"name":"Helios","class":"0x34"}],` +
+			`"messages":[{"name":"Zeus.Foo","class":"` + class + `","fields":[` + fields + `]}]}`
+	}
+	testdata := []struct {
+		Description string
+		Error       string
+	}{
+		{message("0x38", `{"name":"A","go":"uint8","bits":8}`), ""},
+		{message("0x37", `{"name":"A","go":"uint8","bits":8}`), "message Zeus.Foo: class 0x37 is outside of Zeus range"},
+		{message("0x38", `{"name":"A","go":"uint8","bits":4}`), "message Zeus.Foo: invalid payload size of 4 bits"},
+		{message("0x38", `{"name":"A","bits":8}`), "message Zeus.Foo: field 'A' needs a go type"},
+		{message("0x38", `{"name":"A","type":"Foo"}`), "message Zeus.Foo: field 'A' has unknown type 'Foo'"},
+		{message("0x38", `{"name":"A","go":"uint8","bits":8,"resolution":"x"}`), "message Zeus.Foo: field 'A': invalid constant 'x'"},
+		{message("0x38", `{"name":"A[1]","go":"uint8","bits":8}`), "message Zeus.Foo: array 'A' must start at index 0"},
+		{message("0x38", `{"name":"A[0]","go":"uint8","bits":8},{"name":"A[1]","go":"int8","bits":8}`),
+			"message Zeus.Foo: elements of array 'A' must share the same type"},
+		{message("0x38", `{"name":"A","go":"uint8","bits":4,"c":"B[0]"},{"name":"C","go":"uint8","bits":4,"c":"B[1]"}`),
+			"message Zeus.Foo: C array 'B' cannot be a bit field"},
+		{message("0x38", `{"name":"A","go":"uint8","bits":8,"unknown":1}`), "could not decode protocol: .*unknown field.*"},
+	}
+	for _, d := range testdata {
+		_, err := Parse([]byte(d.Description))
+		if len(d.Error) == 0 {
+			c.Check(err, IsNil)
+		} else {
+			c.Check(err, ErrorMatches, d.Error)
+		}
+	}
+}
+
+func (s *ProtocolSuite) TestLayout(c *C) {
+	p, err := Load(repositoryRoot)
+	c.Assert(err, IsNil)
+	sizes := map[string]int{
+		"Zeus.SetPoint":    5,
+		"Zeus.Config":      8,
+		"Helios.PulseMode": 2,
+		"Celaeno.Status":   3,
+	}
+	for _, m := range p.Messages {
+		if expected, ok := sizes[m.Name]; ok == true {
+			c.Check(p.Size(m), Equals, expected, Commentf("%s", m.Name))
+		}
+		if m.Name != "Zeus.Config" {
+			continue
+		}
+		leaves := p.Leaves(m.Fields)
+		c.Assert(leaves, HasLen, 10)
+		c.Check(leaves[9].Path, Equals, "Temperature.DividerPowerIntegral")
+		c.Check(leaves[9].Offset, Equals, 60)
+	}
+}
+
+func (s *ProtocolSuite) TestGoNeedsCheck(c *C) {
+	p := &Protocol{}
+	testdata := []struct {
+		Field    Field
+		Expected bool
+	}{
+		{Field{Go: "uint8", Bits: 8}, false},
+		{Field{Go: "uint8", Bits: 4}, true},
+		{Field{Go: "uint8", Bits: 8, Min: "0", Max: "7"}, true},
+		{Field{Go: "int16", Bits: 16, Signed: true}, false},
+		{Field{Go: "int16", Bits: 16}, true},
+		{Field{Go: "uint16", Bits: 16, Resolution: "100"}, false},
+		{Field{Go: "uint16", Bits: 16, Bias: "-40"}, true},
+		{Field{Go: "FanStatusAndRPM", Bits: 16}, false},
+		{Field{Go: "float32", Bits: 16, Signed: true}, true},
+		{Field{Go: "time.Duration", Bits: 16, Unit: "ms"}, true},
+	}
+	for _, d := range testdata {
+		c.Check(p.goNeedsCheck(Leaf{Field: d.Field}), Equals, d.Expected, Commentf("%+v", d.Field))
+	}
+}
+
+func copyFile(c *C, root, path string) {
+	data, err := os.ReadFile(filepath.Join(repositoryRoot, path))
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(root, path), data, 0644), IsNil)
+}
+
+func (s *ProtocolSuite) TestCheckDetectsDrift(c *C) {
+	root := c.MkDir()
+	for _, path := range []string{Definition, HeaderTemplate, SpecTemplate} {
+		copyFile(c, root, path)
+	}
+	c.Assert(os.MkdirAll(filepath.Join(root, "include"), 0755), IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(root, "src-go/arke"), 0755), IsNil)
+
+	c.Check(Check(root), ErrorMatches, "src-go/arke/protocol_gen.go, include/arke.h, specs/specs.md disagree with specs/protocol.json, run go generate")
+	c.Assert(Write(root), IsNil)
+	c.Check(Check(root), IsNil)
+
+	path := filepath.Join(root, Definition)
+	data, err := os.ReadFile(path)
+	c.Assert(err, IsNil)
+	modified := strings.Replace(string(data), `"name": "Wind",
+					"go": "uint8",
+					"tag": "positional-arg-name:\"wind\" required:\"yes\"",
+					"bits": 8,`, `"name": "Wind",
+					"go": "uint16",
+					"bits": 16,`, 1)
+	c.Assert(modified, Not(Equals), string(data))
+	c.Assert(os.WriteFile(path, []byte(modified), 0644), IsNil)
+	c.Check(Check(root), ErrorMatches, "src-go/arke/protocol_gen.go, include/arke.h, specs/specs.md disagree.*")
+}
@@ -0,0 +1,236 @@
+package protocol
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+type specData struct {
+	CategoryTable  string
+	NodeClassTable string
+	Messages       string
+	CommandTable   string
+}
+
+// markdownTable renders an aligned table. right selects the right
+// aligned columns.
+func markdownTable(headers []string, right []bool, rows [][]string) string {
+	widths := make([]int, len(headers))
+	for _, row := range append([][]string{headers}, rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	pad := func(s string, i int) string {
+		padding := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(s))
+		if right[i] == true {
+			return padding + s
+		}
+		return s + padding
+	}
+	var b strings.Builder
+	writeRow := func(row []string) {
+		for i, cell := range row {
+			fmt.Fprintf(&b, "| %s ", pad(cell, i))
+		}
+		b.WriteString("|\n")
+	}
+	writeRow(headers)
+	for i := range headers {
+		if right[i] == true {
+			fmt.Fprintf(&b, "|%s:", strings.Repeat("-", widths[i]+1))
+		} else {
+			fmt.Fprintf(&b, "|:%s", strings.Repeat("-", widths[i]+1))
+		}
+	}
+	b.WriteString("|\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+	return b.String()
+}
+
+func classRange(low, high Code) string {
+	if low == high {
+		return fmt.Sprintf("0x%02x", low)
+	}
+	return fmt.Sprintf("0x%02x-0x%02x", low, high)
+}
+
+// nodes returns the node classes in decreasing class order, without
+// the broadcast class.
+func (p *Protocol) nodes() []NodeClass {
+	res := slices.DeleteFunc(slices.Clone(p.NodeClasses), func(n NodeClass) bool { return n.Class == 0 })
+	slices.SortFunc(res, func(a, b NodeClass) int { return int(b.Class) - int(a.Class) })
+	return res
+}
+
+func (p *Protocol) categoryTable() string {
+	byClass := make(map[Code]Message)
+	for _, m := range p.Messages {
+		byClass[m.Class] = m
+	}
+	var rows [][]string
+	lowest := Code(0x40)
+	for _, n := range p.nodes() {
+		nodeClass := fmt.Sprintf("0x%02x", n.Class)
+		reservedEnd := Code(0)
+		flush := func(low Code) {
+			if reservedEnd != 0 {
+				rows = append(rows, []string{classRange(low, reservedEnd), "Reserved (" + n.Name + ")", n.Name, nodeClass})
+				reservedEnd = 0
+			}
+		}
+		for c := p.nodeRange(n); c >= n.Class; c-- {
+			m, ok := byClass[c]
+			if ok == false {
+				if reservedEnd == 0 {
+					reservedEnd = c
+				}
+				continue
+			}
+			flush(c + 1)
+			rows = append(rows, []string{fmt.Sprintf("0x%02x", c), m.Title, n.Name, nodeClass})
+		}
+		flush(n.Class)
+		lowest = n.Class
+	}
+	if lowest > 1 {
+		rows = append(rows, []string{classRange(1, lowest-1), "Reserved for Future Use", "n.a", "n.a"})
+	}
+	rows = append(rows, []string{"0x00", "Reserved for broadcast", "all", "n.a."})
+	return markdownTable(
+		[]string{"Message Category", "Name", "Node", "Node Class ID (without subID)"},
+		[]bool{true, false, false, false},
+		rows)
+}
+
+func (p *Protocol) nodeClassTable() string {
+	var rows [][]string
+	for _, n := range p.nodes() {
+		rows = append(rows, []string{n.Name, fmt.Sprintf("0x%02x", n.Class)})
+	}
+	return markdownTable([]string{"Node Name", "Device Class ID"}, []bool{true, false}, rows)
+}
+
+func (p *Protocol) commandTable() string {
+	var rows [][]string
+	for _, c := range p.NetworkCommands {
+		rows = append(rows, []string{fmt.Sprintf("0b%03b", c.Code), c.Title, c.Implementation, c.Payload})
+	}
+	return markdownTable(
+		[]string{"Code", "Command", "Implementation", "Payload"},
+		[]bool{false, false, false, false},
+		rows)
+}
+
+func position(offset, bits int) string {
+	if offset%8 == 0 && bits%8 == 0 {
+		if bits == 8 {
+			return fmt.Sprintf("Byte %d", offset/8)
+		}
+		return fmt.Sprintf("Bytes %d-%d", offset/8, (offset+bits)/8-1)
+	}
+	if bits == 1 {
+		return fmt.Sprintf("Bit %d", offset)
+	}
+	return fmt.Sprintf("Bits %d-%d", offset, offset+bits-1)
+}
+
+// fieldSpec describes a scalar field, e.g. "Target temperature [°C],
+// little endian, x -> x*165/16382-40, range -40 to 125".
+func fieldSpec(f Field) string {
+	res := f.Description
+	if len(f.Unit) > 0 {
+		res += " [" + f.Unit + "]"
+	}
+	var details []string
+	if f.Signed == true {
+		details = append(details, "signed")
+	}
+	if f.Bits > 8 {
+		details = append(details, "little endian")
+	}
+	scaled := len(f.Resolution) > 0 && evaluateOr(f.Resolution, 1) != 1
+	biased := len(f.Bias) > 0 && evaluateOr(f.Bias, 0) != 0
+	if scaled || biased {
+		conversion := "x -> x"
+		if scaled {
+			conversion += "*" + f.Resolution
+		}
+		if biased {
+			if strings.HasPrefix(f.Bias, "-") == false {
+				conversion += "+"
+			}
+			conversion += f.Bias
+		}
+		details = append(details, conversion)
+	}
+	if len(f.Min) > 0 {
+		details = append(details, fmt.Sprintf("range %s to %s", f.Min, f.Max))
+	}
+	if len(details) > 0 {
+		res += ", " + strings.Join(details, ", ")
+	}
+	return res
+}
+
+func (p *Protocol) writeFieldSpec(b *strings.Builder, indent string, f Field, offset int) {
+	bits := p.Bits(f)
+	t, compound := p.lookupType(f.Type)
+	if compound == false {
+		fmt.Fprintf(b, "%s* %s: %s\n", indent, position(offset, bits), fieldSpec(f))
+	} else {
+		fmt.Fprintf(b, "%s* %s: %s\n", indent, position(offset, bits), f.Description)
+	}
+	for _, note := range f.Notes {
+		fmt.Fprintf(b, "%s  * %s\n", indent, note)
+	}
+	if compound == false {
+		return
+	}
+	for _, sub := range t.Fields {
+		p.writeFieldSpec(b, indent+"  ", sub, offset)
+		offset += p.Bits(sub)
+	}
+}
+
+func (p *Protocol) messageSection(m Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#### 0x%02x %s\n\n", m.Class, m.Title)
+	if len(m.Description) > 0 {
+		fmt.Fprintf(&b, "%s\n\n", m.Description)
+	}
+	fmt.Fprintf(&b, "* Host Access: %s\n", m.Access)
+	fmt.Fprintf(&b, "* Periodically emitted by node: %s\n", m.Periodic)
+	if m.Undefined == true {
+		b.WriteString("* Payload: Undefined\n")
+		return b.String()
+	}
+	b.WriteString("* Payload:\n")
+	fmt.Fprintf(&b, "  * Data Length: %d\n", p.Size(m))
+	b.WriteString("  * Data fields:\n")
+	offset := 0
+	for _, f := range m.Fields {
+		p.writeFieldSpec(&b, "\t", f, offset)
+		offset += p.Bits(f)
+	}
+	return b.String()
+}
+
+func (p *Protocol) specData() specData {
+	messages := slices.Clone(p.Messages)
+	slices.SortFunc(messages, func(a, b Message) int { return int(a.Class) - int(b.Class) })
+	sections := make([]string, 0, len(messages))
+	for _, m := range messages {
+		sections = append(sections, p.messageSection(m))
+	}
+	return specData{
+		CategoryTable:  p.categoryTable(),
+		NodeClassTable: p.nodeClassTable(),
+		Messages:       strings.Join(sections, "\n"),
+		CommandTable:   p.commandTable(),
+	}
+}
@@ -0,0 +1,202 @@
+package arke
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// builtinMessages names the messages that are not registered with a
+// message class, after the prefix of their String() representation.
+var builtinMessages = map[string]func() ReceivableMessage{
+	"arke.HeartBeat":              func() ReceivableMessage { return &HeartBeatData{} },
+	"arke.ResetRequest":           func() ReceivableMessage { return &ResetRequestData{} },
+	"arke.HeartBeatRequest":       func() ReceivableMessage { return &HeartBeatRequestData{} },
+	"arke.IDChangeRequest":        func() ReceivableMessage { return &IDChangeRequestData{} },
+	"arke.ErrorReport":            func() ReceivableMessage { return &ErrorReportData{} },
+	"arke.SynchronisationRequest": func() ReceivableMessage { return &SynchronisationRequestData{} },
+	"arke.SynchronisationReply":   func() ReceivableMessage { return &SynchronisationReplyData{} },
+	"arke.MessageRequest":         func() ReceivableMessage { return &MessageRequestData{} },
+	"arke.RawMessage":             func() ReceivableMessage { return &RawMessage{} },
+}
+
+var builtinNames = func() map[reflect.Type]string {
+	res := make(map[reflect.Type]string, len(builtinMessages))
+	for name, creator := range builtinMessages {
+		res[reflect.TypeOf(creator())] = name
+	}
+	return res
+}()
+
+// MessageName returns the name identifying the type of a message in
+// its JSON and text encodings, e.g. "Zeus.SetPoint" or
+// "arke.HeartBeat".
+func MessageName(m ReceivableMessage) (string, error) {
+	if name, ok := builtinNames[reflect.TypeOf(m)]; ok == true {
+		return name, nil
+	}
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	if name, ok := messagesName[m.MessageClassID()]; ok == true {
+		return name, nil
+	}
+	return "", fmt.Errorf("%w 0x%02x", ErrUnknownMessageClass, int(m.MessageClassID()))
+}
+
+// NewMessageByName returns a new zero message from a name returned by
+// MessageName.
+func NewMessageByName(name string) (ReceivableMessage, error) {
+	if creator, ok := builtinMessages[name]; ok == true {
+		return creator(), nil
+	}
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	for c, n := range messagesName {
+		if n != name {
+			continue
+		}
+		if creator, ok := messageFactory[c]; ok == true {
+			return creator(), nil
+		}
+	}
+	return nil, fmt.Errorf("%w '%s'", ErrUnknownMessageClass, name)
+}
+
+type messageJSON struct {
+	Class string
+	Data  json.RawMessage
+}
+
+// MarshalMessageJSON encodes a message as a JSON object with the
+// message name as discriminator, e.g.:
+//
+//	{"Class":"Helios.PulseMode","Data":{"Period":"1s"}}
+//
+// Durations are encoded as strings with units, and fan statuses as
+// {"Status","RPM"} objects.
+func MarshalMessageJSON(m ReceivableMessage) ([]byte, error) {
+	name, err := MessageName(m)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Cannot encode %T to JSON", m)
+	}
+	data, err := json.Marshal(encodeJSONValue(v.Elem()))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(messageJSON{Class: name, Data: data})
+}
+
+// UnmarshalMessageJSON decodes a message encoded by
+// MarshalMessageJSON. Unknown fields are rejected.
+func UnmarshalMessageJSON(data []byte) (ReceivableMessage, error) {
+	var envelope messageJSON
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+	m, err := NewMessageByName(envelope.Class)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Data) == 0 {
+		return m, nil
+	}
+	if err := decodeJSONValue(envelope.Data, reflect.ValueOf(m).Elem()); err != nil {
+		return nil, fmt.Errorf("Could not decode %s: %w", envelope.Class, err)
+	}
+	return m, nil
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func encodeJSONValue(v reflect.Value) any {
+	t := v.Type()
+	if t == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		res := make(map[string]any, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() == true {
+				res[f.Name] = encodeJSONValue(v.Field(i))
+			}
+		}
+		return res
+	case reflect.Array:
+		res := make([]any, v.Len())
+		for i := range res {
+			res[i] = encodeJSONValue(v.Index(i))
+		}
+		return res
+	}
+	return v.Interface()
+}
+
+func decodeJSONValue(data json.RawMessage, v reflect.Value) error {
+	t := v.Type()
+	if t == durationType {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	ptr := v.Addr().Interface()
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) == true ||
+		(reflect.PointerTo(t).Implements(textUnmarshalerType) == true && len(data) > 0 && data[0] == '"') {
+		return json.Unmarshal(data, ptr)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		for name, raw := range fields {
+			f, ok := t.FieldByName(name)
+			if ok == false || f.IsExported() == false {
+				return fmt.Errorf("Unknown field '%s'", name)
+			}
+			if err := decodeJSONValue(raw, v.FieldByIndex(f.Index)); err != nil {
+				return fmt.Errorf("Field '%s': %w", name, err)
+			}
+		}
+		return nil
+	case reflect.Array:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		if len(items) != v.Len() {
+			return fmt.Errorf("Expected %d values, got %d", v.Len(), len(items))
+		}
+		for i, raw := range items {
+			if err := decodeJSONValue(raw, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return json.Unmarshal(data, ptr)
+}
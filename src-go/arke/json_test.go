@@ -0,0 +1,132 @@
+package arke
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type EncodingSuite struct{}
+
+var _ = Suite(&EncodingSuite{})
+
+func encodingTestdata() []ReceivableMessage {
+	return []ReceivableMessage{
+		&ZeusSetPoint{Humidity: 42.5, Temperature: 25.25, Wind: 127},
+		&ZeusReport{Humidity: 55.5, Temperature: [4]float32{21.5, 22.25, -3.75, 30}},
+		&ZeusConfig{
+			Humidity:    PDConfig{ProportionnalMultiplier: 10, DerivativeMultiplier: 3, IntegralMultiplier: 1, DividerPower: 4, DividerPowerIntegral: 6},
+			Temperature: PDConfig{ProportionnalMultiplier: 200, DerivativeMultiplier: 0, IntegralMultiplier: 8, DividerPower: 0, DividerPowerIntegral: 15},
+		},
+		&ZeusStatus{
+			Status: ZeusActive | ZeusHumidityUnreachable,
+			Fans:   [3]FanStatusAndRPM{1200, 0x4000 | 800, 0x8000},
+		},
+		&ZeusControlPoint{Humidity: -12, Temperature: 300},
+		&ZeusDeltaTemperature{Delta: [4]float32{0.5, -0.0625, 0, 1.125}},
+		&HeliosSetPoint{Visible: 12, UV: 255},
+		&HeliosPulseMode{Period: 1500 * time.Millisecond},
+		&HeliosTriggerMode{Period: 100 * time.Millisecond, PulseLength: 2 * time.Millisecond, CameraDelay: -50 * time.Microsecond},
+		&CelaenoSetPoint{Power: 42},
+		&CelaenoStatus{WaterLevel: CelaenoWaterWarning, Fan: 0x4000 | 1000},
+		&CelaenoStatus{WaterLevel: CelaenoWaterWarning, Fan: 0xc005},
+		&CelaenoConfig{RampUpTime: time.Second, RampDownTime: 2 * time.Second, MinimumOnTime: 3 * time.Second, DebounceTime: 400 * time.Millisecond},
+		&NotusSetPoint{Power: 3},
+		&NotusConfig{RampDownTime: 2 * time.Second, MinFan: 50, MaxHeat: 200},
+		&HeartBeatData{Class: HeliosClass, ID: 2, MajorVersion: 1, MinorVersion: 2, PatchVersion: 3},
+		&HeartBeatData{Class: ZeusClass, ID: 1},
+		&ResetRequestData{Class: CelaenoClass, ID: 3},
+		&ResetRequestData{Class: BroadcastClass, ID: 0},
+		&HeartBeatRequestData{Class: ZeusClass, Period: 500 * time.Millisecond},
+		&HeartBeatRequestData{Class: ZeusClass},
+		&IDChangeRequestData{Class: NotusClass, Old: 1, New: 4},
+		&ErrorReportData{Class: ZeusClass, ID: 1, ErrorCode: 0x0042},
+		&SynchronisationRequestData{Class: BroadcastClass, Sequence: 12},
+		&SynchronisationReplyData{Class: HeliosClass, ID: 2, Sequence: 12, Timestamp: 1234567 * time.Microsecond},
+		&MessageRequestData{Class: ZeusReportMessage, ID: 2},
+		&MessageRequestData{Class: CelaenoConfigMessage, ID: 0},
+		&RawMessage{Type: HighPriorityMessage, Class: ZeusVibrationReportMessage, ID: 1, Data: []byte{1, 0x2a}},
+	}
+}
+
+func (s *EncodingSuite) TestJSONRoundTrip(c *C) {
+	for _, m := range encodingTestdata() {
+		data, err := MarshalMessageJSON(m)
+		if c.Check(err, IsNil) == false {
+			continue
+		}
+		res, err := UnmarshalMessageJSON(data)
+		c.Check(err, IsNil, Commentf("%s", data))
+		c.Check(res, DeepEquals, m, Commentf("%s", data))
+	}
+}
+
+func (s *EncodingSuite) TestJSONEncoding(c *C) {
+	testdata := []struct {
+		M ReceivableMessage
+		E string
+	}{
+		{
+			&CelaenoStatus{WaterLevel: CelaenoWaterCritical, Fan: 0x8000 | 12},
+			`{"Class":"Celaeno.Status","Data":{"Fan":{"Aging":false,"Stalled":true,"RPM":12},"WaterLevel":"critical"}}`,
+		},
+		{
+			&ZeusStatus{Fans: [3]FanStatusAndRPM{0xc005, 0x4000, 0}},
+			`{"Class":"Zeus.Status","Data":{"Fans":[{"Aging":true,"Stalled":true,"RPM":5},{"Aging":true,"Stalled":false,"RPM":0},{"Aging":false,"Stalled":false,"RPM":0}],"Status":"idle"}}`,
+		},
+		{
+			&HeliosPulseMode{Period: 1500 * time.Millisecond},
+			`{"Class":"Helios.PulseMode","Data":{"Period":"1.5s"}}`,
+		},
+		{
+			&HeartBeatData{Class: ZeusClass, ID: 1},
+			`{"Class":"arke.HeartBeat","Data":{"Class":"Zeus","ID":1,"MajorVersion":0,"MinorVersion":0,"PatchVersion":0,"TweakVersion":0}}`,
+		},
+	}
+	for _, d := range testdata {
+		data, err := MarshalMessageJSON(d.M)
+		c.Check(err, IsNil)
+		c.Check(string(data), Equals, d.E)
+	}
+
+	errors := []struct {
+		Data string
+		E    string
+	}{
+		{`{"Class":"Hades.SetPoint","Data":{}}`, "Unknown message class 'Hades.SetPoint'"},
+		{`{"Class":"Zeus.VibrationReport","Data":{}}`, "Unknown message class 'Zeus.VibrationReport'"},
+		{`{"Class":"Helios.PulseMode","Data":{"Period":1000}}`, "Could not decode Helios.PulseMode: Field 'Period': .*"},
+		{`{"Class":"Helios.PulseMode","Data":{"Duration":"1s"}}`, "Could not decode Helios.PulseMode: Unknown field 'Duration'"},
+		{`{"Class":"Zeus.Report","Data":{"Temperature":[1,2]}}`, "Could not decode Zeus.Report: Field 'Temperature': Expected 4 values, got 2"},
+		{`{"Class":"Celaeno.Status","Data":{"Fan":{"RPM":20000}}}`, "Could not decode Celaeno.Status: Field 'Fan': Fan RPM 20000 is too large .*"},
+	}
+	for _, d := range errors {
+		_, err := UnmarshalMessageJSON([]byte(d.Data))
+		c.Check(err, ErrorMatches, d.E)
+	}
+}
+
+func (s *EncodingSuite) TestTextRoundTrip(c *C) {
+	for _, m := range encodingTestdata() {
+		res, err := ParseMessageText(m.String())
+		c.Check(err, IsNil, Commentf("%s", m))
+		c.Check(res, DeepEquals, m, Commentf("%s", m))
+	}
+
+	errors := []struct {
+		Text string
+		E    string
+	}{
+		{"Zeus.SetPoint", "Invalid message text 'Zeus.SetPoint'"},
+		{"Zeus.SetPoint{Humidity 12}", "Invalid field 'Humidity 12' in .*"},
+		{"Zeus.SetPoint{Humidity: {12}", "Unbalanced braces in .*"},
+		{"Hades.SetPoint{}", "Unknown message class 'Hades.SetPoint'"},
+		{"Zeus.SetPoint{Wind: 300}", "Invalid Zeus.SetPoint field 'Wind': .*"},
+		{"Zeus.SetPoint{Rain: 3}", "Invalid Zeus.SetPoint field 'Rain': unknown field"},
+		{"Zeus.Config{Humidity:PIDConfig{Proportional:1/3}}", "Invalid Zeus.Config field 'Humidity': Invalid divider '3'"},
+	}
+	for _, d := range errors {
+		_, err := ParseMessageText(d.Text)
+		c.Check(err, ErrorMatches, d.E)
+	}
+}
@@ -0,0 +1,294 @@
+package arke
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+)
+
+type LivenessEventType int
+
+const (
+	NodeOnline LivenessEventType = iota
+	NodeOffline
+	NodeRebooted
+)
+
+func (t LivenessEventType) String() string {
+	switch t {
+	case NodeOnline:
+		return "online"
+	case NodeOffline:
+		return "offline"
+	case NodeRebooted:
+		return "rebooted"
+	}
+	return "<unknown>"
+}
+
+type LivenessEvent struct {
+	Type    LivenessEventType
+	Class   NodeClass
+	ID      NodeID
+	Time    time.Time
+	Version FirmwareVersion
+}
+
+func (e LivenessEvent) String() string {
+	return fmt.Sprintf("arke.LivenessEvent{Node: %s.%d, Event: %s}", e.Class, e.ID, e.Type)
+}
+
+type nodeLiveness struct {
+	lastSeen time.Time
+	version  FirmwareVersion
+	online   bool
+}
+
+type livenessTracker struct {
+	mx          sync.Mutex
+	periods     map[NodeClass]time.Duration
+	missedBeats int
+	pingGrace   time.Duration
+	nodes       map[nodeKey]*nodeLiveness
+	pinged      map[NodeClass]time.Time
+}
+
+func newLivenessTracker(periods map[NodeClass]time.Duration, missedBeats int, pingGrace time.Duration) *livenessTracker {
+	return &livenessTracker{
+		periods:     periods,
+		missedBeats: missedBeats,
+		pingGrace:   pingGrace,
+		nodes:       make(map[nodeKey]*nodeLiveness),
+		pinged:      make(map[NodeClass]time.Time),
+	}
+}
+
+func (t *livenessTracker) timeout(c NodeClass) (time.Duration, bool) {
+	period, ok := t.periods[c]
+	if ok == false {
+		period, ok = t.periods[BroadcastClass]
+	}
+	return time.Duration(t.missedBeats) * period, ok
+}
+
+func (t *livenessTracker) ping(c NodeClass, now time.Time) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.pinged[c] = now
+}
+
+func (t *livenessTracker) wasPinged(c NodeClass, now time.Time) bool {
+	for _, pc := range []NodeClass{c, BroadcastClass} {
+		if at, ok := t.pinged[pc]; ok == true && now.Sub(at) <= t.pingGrace {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *livenessTracker) heartbeat(h *HeartBeatData, now time.Time) []LivenessEvent {
+	if _, ok := t.timeout(h.Class); ok == false {
+		return nil
+	}
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	event := LivenessEvent{Class: h.Class, ID: h.ID, Time: now, Version: h.Version()}
+	key := nodeKey{h.Class, h.ID}
+	n, ok := t.nodes[key]
+	if ok == false {
+		t.nodes[key] = &nodeLiveness{lastSeen: now, version: event.Version, online: true}
+		event.Type = NodeOnline
+		return []LivenessEvent{event}
+	}
+
+	wasOnline := n.online
+	n.lastSeen = now
+	n.online = true
+	if event.Version.IsZero() == false {
+		n.version = event.Version
+	}
+	event.Version = n.version
+
+	if wasOnline == false {
+		event.Type = NodeRebooted
+		return []LivenessEvent{event}
+	}
+	// A node announces its version on boot, if nobody asked for it.
+	if h.Version().IsZero() == false && t.wasPinged(h.Class, now) == false {
+		event.Type = NodeRebooted
+		return []LivenessEvent{event}
+	}
+	return nil
+}
+
+func (t *livenessTracker) expire(now time.Time) []LivenessEvent {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	var res []LivenessEvent
+	for key, n := range t.nodes {
+		timeout, _ := t.timeout(key.Class)
+		if n.online == false || now.Sub(n.lastSeen) <= timeout {
+			continue
+		}
+		n.online = false
+		res = append(res, LivenessEvent{
+			Type:    NodeOffline,
+			Class:   key.Class,
+			ID:      key.ID,
+			Time:    now,
+			Version: n.version,
+		})
+	}
+	return res
+}
+
+func (t *livenessTracker) lastSeen(c NodeClass, ID NodeID) (last time.Time, online, known bool) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	n, ok := t.nodes[nodeKey{c, ID}]
+	if ok == false {
+		return time.Time{}, false, false
+	}
+	return n.lastSeen, n.online, true
+}
+
+// LivenessMonitor requests periodic heartbeats for a set of node
+// classes, and reports when nodes come online, go offline or reboot.
+type LivenessMonitor struct {
+	bus     Bus
+	tracker *livenessTracker
+	events  chan LivenessEvent
+}
+
+// NewLivenessMonitor creates a monitor requesting heartbeats with the
+// given period for each node class. BroadcastClass can be used to
+// monitor every class. A node is considered offline after missedBeats
+// periods without any heartbeat.
+func NewLivenessMonitor(bus Bus, periods map[NodeClass]time.Duration, missedBeats int) *LivenessMonitor {
+	p := make(map[NodeClass]time.Duration, len(periods))
+	for c, d := range periods {
+		p[c] = d
+	}
+	if missedBeats < 1 {
+		missedBeats = 1
+	}
+	return &LivenessMonitor{
+		bus:     bus,
+		tracker: newLivenessTracker(p, missedBeats, 500*time.Millisecond),
+		events:  make(chan LivenessEvent, 16),
+	}
+}
+
+// Events returns the channel where events are reported. It is closed
+// when Run returns, and must be drained.
+func (m *LivenessMonitor) Events() <-chan LivenessEvent {
+	return m.events
+}
+
+// LastSeen returns the state of a node: last is the time of its last
+// heartbeat, online is true if it is currently considered online, and
+// known is false if no heartbeat was ever received from it, in which
+// case last is the zero time.
+func (m *LivenessMonitor) LastSeen(c NodeClass, ID NodeID) (last time.Time, online, known bool) {
+	return m.tracker.lastSeen(c, ID)
+}
+
+func (m *LivenessMonitor) requestHeartbeats(c NodeClass) error {
+	period, ok := m.tracker.periods[c]
+	if ok == false {
+		c = BroadcastClass
+		period = m.tracker.periods[c]
+	}
+	f := MakeHeartBeatRequest(c, period)
+	if f.Dlc == 0 {
+		return fmt.Errorf("Invalid heartbeat period %s for %s", period, c)
+	}
+	m.tracker.ping(c, time.Now())
+	return m.bus.Send(f)
+}
+
+func (m *LivenessMonitor) checkPeriod() time.Duration {
+	res := time.Duration(0)
+	for _, p := range m.tracker.periods {
+		if res == 0 || p < res {
+			res = p
+		}
+	}
+	return max(res/2, time.Millisecond)
+}
+
+func (m *LivenessMonitor) handle(f *socketcan.CanFrame, now time.Time) []LivenessEvent {
+	if f.RTR == true || f.Extended == true {
+		return nil
+	}
+	mType, mClass, ID := ExtractCANIDT(f.ID)
+	switch mType {
+	case NetworkControlCommand:
+		if ID == NodeID(HeartBeatRequest) {
+			m.tracker.ping(NodeClass(mClass), now)
+		}
+	case HeartBeat:
+		parsed, _, err := ParseMessage(f)
+		if err != nil {
+			return nil
+		}
+		return m.tracker.heartbeat(parsed.(*HeartBeatData), now)
+	}
+	return nil
+}
+
+func (m *LivenessMonitor) emit(ctx context.Context, events []LivenessEvent) error {
+	for _, e := range events {
+		if e.Type != NodeOnline {
+			// the node may have lost its heartbeat configuration.
+			if err := m.requestHeartbeats(e.Class); err != nil {
+				return err
+			}
+		}
+		select {
+		case m.events <- e:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+// Run requests the heartbeats and monitors the nodes until the
+// context is done or the Bus fails.
+func (m *LivenessMonitor) Run(ctx context.Context) error {
+	defer close(m.events)
+
+	for c := range m.tracker.periods {
+		if err := m.requestHeartbeats(c); err != nil {
+			return err
+		}
+	}
+
+	checkPeriod := m.checkPeriod()
+	for {
+		rctx, cancel := context.WithTimeout(ctx, checkPeriod)
+		f, err := m.bus.Receive(rctx)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil && errors.Is(err, context.DeadlineExceeded) == false {
+			return err
+		}
+		now := time.Now()
+		var events []LivenessEvent
+		if err == nil {
+			events = m.handle(&f, now)
+		}
+		events = append(events, m.tracker.expire(now)...)
+		if err := m.emit(ctx, events); err != nil {
+			return err
+		}
+	}
+}
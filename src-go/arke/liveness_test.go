@@ -0,0 +1,126 @@
+package arke
+
+import (
+	"context"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type LivenessSuite struct{}
+
+var _ = Suite(&LivenessSuite{})
+
+func eventTypes(events []LivenessEvent) []LivenessEventType {
+	res := make([]LivenessEventType, len(events))
+	for i, e := range events {
+		res[i] = e.Type
+	}
+	return res
+}
+
+func (s *LivenessSuite) TestTracker(c *C) {
+	t := newLivenessTracker(map[NodeClass]time.Duration{
+		ZeusClass: time.Second,
+	}, 3, 500*time.Millisecond)
+	start := time.Now()
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	zeus1 := &HeartBeatData{Class: ZeusClass, ID: 1}
+	versioned := &HeartBeatData{Class: ZeusClass, ID: 1, MajorVersion: 1, MinorVersion: 2}
+
+	// untracked classes are ignored
+	c.Check(t.heartbeat(&HeartBeatData{Class: HeliosClass, ID: 1}, at(0)), HasLen, 0)
+
+	c.Check(eventTypes(t.heartbeat(zeus1, at(0))), DeepEquals, []LivenessEventType{NodeOnline})
+	c.Check(t.heartbeat(zeus1, at(time.Second)), HasLen, 0)
+	c.Check(t.expire(at(4*time.Second)), HasLen, 0)
+
+	events := t.expire(at(4*time.Second + 1))
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0].Type, Equals, NodeOffline)
+	c.Check(events[0].Class, Equals, ZeusClass)
+	c.Check(events[0].ID, Equals, NodeID(1))
+	// reported only once
+	c.Check(t.expire(at(10*time.Second)), HasLen, 0)
+
+	c.Check(eventTypes(t.heartbeat(zeus1, at(11*time.Second))), DeepEquals, []LivenessEventType{NodeRebooted})
+
+	// a versioned heartbeat following a ping is expected
+	t.ping(BroadcastClass, at(12*time.Second))
+	c.Check(t.heartbeat(versioned, at(12*time.Second+100*time.Millisecond)), HasLen, 0)
+	// but not when unsolicited
+	events = t.heartbeat(versioned, at(14*time.Second))
+	c.Check(eventTypes(events), DeepEquals, []LivenessEventType{NodeRebooted})
+	c.Check(events[0].Version, Equals, FirmwareVersion{Major: 1, Minor: 2})
+
+	last, online, ok := t.lastSeen(ZeusClass, 1)
+	c.Check(ok, Equals, true)
+	c.Check(online, Equals, true)
+	c.Check(last, Equals, at(14*time.Second))
+	_, _, ok = t.lastSeen(ZeusClass, 2)
+	c.Check(ok, Equals, false)
+}
+
+func (s *LivenessSuite) TestTrackerBroadcastPeriod(c *C) {
+	t := newLivenessTracker(map[NodeClass]time.Duration{
+		BroadcastClass: time.Second,
+		HeliosClass:    2 * time.Second,
+	}, 1, 0)
+	start := time.Now()
+	t.heartbeat(&HeartBeatData{Class: HeliosClass, ID: 1}, start)
+	t.heartbeat(&HeartBeatData{Class: NotusClass, ID: 1}, start)
+
+	events := t.expire(start.Add(1500 * time.Millisecond))
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0].Class, Equals, NotusClass)
+}
+
+func (s *LivenessSuite) TestEventFormatting(c *C) {
+	c.Check(LivenessEvent{Type: NodeRebooted, Class: CelaenoClass, ID: 2}.String(), Equals,
+		"arke.LivenessEvent{Node: Celaeno.2, Event: rebooted}")
+	c.Check(LivenessEventType(42).String(), Equals, "<unknown>")
+}
+
+func (s *LivenessSuite) TestMonitor(c *C) {
+	l := NewLoopback()
+	defer l.Close()
+	node := l.Endpoint()
+	m := NewLivenessMonitor(l.Endpoint(), map[NodeClass]time.Duration{CelaenoClass: 10 * time.Millisecond}, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() { errs <- m.Run(ctx) }()
+
+	f, err := receiveWithin(node, time.Second)
+	c.Assert(err, IsNil)
+	c.Check(f, DeepEquals, copyFrame(MakeHeartBeatRequest(CelaenoClass, 10*time.Millisecond)))
+
+	c.Assert(node.Send(makeHeartBeat(CelaenoClass, 3, FirmwareVersion{})), IsNil)
+	e := <-m.Events()
+	c.Check(e.Type, Equals, NodeOnline)
+	c.Check(e.ID, Equals, NodeID(3))
+
+	e = <-m.Events()
+	c.Check(e.Type, Equals, NodeOffline)
+	last, online, known := m.LastSeen(CelaenoClass, 3)
+	c.Check(known, Equals, true)
+	c.Check(online, Equals, false)
+	c.Check(last.IsZero(), Equals, false)
+	_, _, known = m.LastSeen(CelaenoClass, 4)
+	c.Check(known, Equals, false)
+
+	// heartbeats are requested again
+	f, err = receiveWithin(node, time.Second)
+	c.Assert(err, IsNil)
+	c.Check(f.ID, Equals, MakeCANIDT(NetworkControlCommand, MessageClass(CelaenoClass), NodeID(HeartBeatRequest)))
+
+	c.Assert(node.Send(makeHeartBeat(CelaenoClass, 3, FirmwareVersion{})), IsNil)
+	e = <-m.Events()
+	c.Check(e.Type, Equals, NodeRebooted)
+
+	cancel()
+	c.Check(<-errs, IsNil)
+	for range m.Events() {
+	}
+}
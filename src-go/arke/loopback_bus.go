@@ -0,0 +1,142 @@
+package arke
+
+import (
+	"context"
+	"sync"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+)
+
+// Loopback is an in-memory CAN bus. Every frame sent by one of its
+// endpoints is received by all the other open endpoints, but not by
+// its sender, like a SocketCAN raw socket does.
+type Loopback struct {
+	mx        sync.Mutex
+	endpoints map[*loopbackEndpoint]struct{}
+}
+
+func NewLoopback() *Loopback {
+	return &Loopback{
+		endpoints: make(map[*loopbackEndpoint]struct{}),
+	}
+}
+
+func (l *Loopback) Endpoint() Bus {
+	res := &loopbackEndpoint{
+		parent: l,
+		signal: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.endpoints[res] = struct{}{}
+	return res
+}
+
+func (l *Loopback) Close() error {
+	l.mx.Lock()
+	endpoints := make([]*loopbackEndpoint, 0, len(l.endpoints))
+	for e := range l.endpoints {
+		endpoints = append(endpoints, e)
+	}
+	l.mx.Unlock()
+
+	for _, e := range endpoints {
+		e.Close()
+	}
+	return nil
+}
+
+func (l *Loopback) broadcast(from *loopbackEndpoint, f socketcan.CanFrame) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	for e := range l.endpoints {
+		if e == from {
+			continue
+		}
+		e.push(copyFrame(f))
+	}
+}
+
+func (l *Loopback) remove(e *loopbackEndpoint) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	delete(l.endpoints, e)
+}
+
+type loopbackEndpoint struct {
+	parent *Loopback
+
+	mx     sync.Mutex
+	queue  []socketcan.CanFrame
+	closed bool
+	signal chan struct{}
+	done   chan struct{}
+}
+
+func (e *loopbackEndpoint) push(f socketcan.CanFrame) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if e.closed == true {
+		return
+	}
+	e.queue = append(e.queue, f)
+	e.notify()
+}
+
+func (e *loopbackEndpoint) notify() {
+	select {
+	case e.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (e *loopbackEndpoint) Send(f socketcan.CanFrame) error {
+	e.mx.Lock()
+	closed := e.closed
+	e.mx.Unlock()
+	if closed == true {
+		return ErrBusClosed
+	}
+	e.parent.broadcast(e, f)
+	return nil
+}
+
+func (e *loopbackEndpoint) Receive(ctx context.Context) (socketcan.CanFrame, error) {
+	for {
+		e.mx.Lock()
+		if len(e.queue) > 0 {
+			f := e.queue[0]
+			e.queue = e.queue[1:]
+			e.mx.Unlock()
+			return f, nil
+		}
+		closed := e.closed
+		e.mx.Unlock()
+		if closed == true {
+			return socketcan.CanFrame{}, ErrBusClosed
+		}
+
+		select {
+		case <-ctx.Done():
+			return socketcan.CanFrame{}, ctx.Err()
+		case <-e.signal:
+		case <-e.done:
+		}
+	}
+}
+
+func (e *loopbackEndpoint) Close() error {
+	e.mx.Lock()
+	if e.closed == true {
+		e.mx.Unlock()
+		return ErrBusClosed
+	}
+	e.closed = true
+	e.queue = nil
+	close(e.done)
+	e.mx.Unlock()
+
+	e.parent.remove(e)
+	return nil
+}
@@ -18,46 +18,44 @@ const (
 	HeartBeat             MessageType = 0x03
 	MessageTypeMask       uint16      = 0x03 << 9
 
-	BroadcastClass NodeClass = 0x0
-	ZeusClass      NodeClass = 0x38
-	HeliosClass    NodeClass = 0x34
-	CelaenoClass   NodeClass = 0x30
-	NotusClass     NodeClass = 0x2c
-	NodeClassMask  uint16    = 0x3f << 3
-
-	ResetRequest           MessageClass = 0x00
-	SynchronisationRequest MessageClass = 0x01
-	IDChangeRequest        MessageClass = 0x02
-	ErrorReport            MessageClass = 0x03
-	HeartBeatRequest       MessageClass = 0x07
-	IDMask                 uint16       = 0x07
+	NodeClassMask uint16 = 0x3f << 3
+
+	IDMask uint16 = 0x07
 
 	BroadcastID NodeID = 0x00
 
-	RTRRequestMessage             MessageClass = MessageClass(1 << 10)
-	HeartBeatMessage              MessageClass = MessageClass(HeartBeat << 9)
-	ResetRequestMessage           MessageClass = MessageClass(0x7f8 | ResetRequest)
-	SynchronisationRequestMessage MessageClass = MessageClass(0x7f8 | SynchronisationRequest)
-	IDChangeRequestMessage        MessageClass = MessageClass(0x7f8 | IDChangeRequest)
-	ErrorReportMessage            MessageClass = MessageClass(0x7f8 | ErrorReport)
-	HeartBeatRequestMessage       MessageClass = MessageClass(0x7f8 | HeartBeatRequest)
-	ZeusSetPointMessage           MessageClass = 0x38
-	ZeusReportMessage             MessageClass = 0x39
-	ZeusVibrationReportMessage    MessageClass = 0x3a
-	ZeusConfigMessage             MessageClass = 0x3b
-	ZeusStatusMessage             MessageClass = 0x3c
-	ZeusControlPointMessage       MessageClass = 0x3d
-	ZeusDeltaTemperatureMessage   MessageClass = 0x3e
-	HeliosSetPointMessage         MessageClass = 0x34
-	HeliosPulseModeMessage        MessageClass = 0x35
-	HeliosTriggerModeMessage      MessageClass = 0x36
-	CelaenoSetPointMessage        MessageClass = 0x30
-	CelaenoStatusMessage          MessageClass = 0x31
-	CelaenoConfigMessage          MessageClass = 0x32
-	NotusSetPointMessage          MessageClass = 0x2c
-	NotusConfigMessage            MessageClass = 0x2d
+	RTRRequestMessage MessageClass = MessageClass(1 << 10)
+	HeartBeatMessage  MessageClass = MessageClass(HeartBeat << 9)
 )
 
+func (t MessageType) String() string {
+	switch t {
+	case NetworkControlCommand:
+		return "NetworkCommand"
+	case HighPriorityMessage:
+		return "HighPriority"
+	case StandardMessage:
+		return "Standard"
+	case HeartBeat:
+		return "HeartBeat"
+	}
+	return "<unknown>"
+}
+
+func (t MessageType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *MessageType) UnmarshalText(text []byte) error {
+	for _, candidate := range []MessageType{NetworkControlCommand, HighPriorityMessage, StandardMessage, HeartBeat} {
+		if candidate.String() == string(text) {
+			*t = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown message type '%s'", text)
+}
+
 func MakeCANIDT(t MessageType, c MessageClass, n NodeID) uint32 {
 	return uint32((uint32(t) << 9) | (uint32(c) << 3) | uint32(n))
 }
@@ -105,7 +103,7 @@ func checkID(ID NodeID) error {
 	return nil
 }
 
-func SendMessage(itf socketcan.RawInterface, m SendableMessage, highPriority bool, ID NodeID) error {
+func SendMessage(itf FrameSender, m SendableMessage, highPriority bool, ID NodeID) error {
 	if err := checkID(ID); err != nil {
 		return err
 	}
@@ -122,32 +120,28 @@ func SendMessage(itf socketcan.RawInterface, m SendableMessage, highPriority boo
 	}
 	dlc, err := m.Marshal(f.Data)
 	if err != nil {
-		return fmt.Errorf("Could not marshall %v: %s", m, err)
+		return fmt.Errorf("Could not marshall %v: %w", m, err)
 	}
 	f.Dlc = uint8(dlc)
 	return itf.Send(f)
 }
 
-func RequestMessage(itf socketcan.RawInterface, m ReceivableMessage, ID NodeID) error {
-	if err := checkID(ID); err != nil {
-		return err
-	}
-	return itf.Send(socketcan.CanFrame{
-		ID:       MakeCANIDT(StandardMessage, m.MessageClassID(), ID),
+func makeRequestFrame(c MessageClass, ID NodeID) socketcan.CanFrame {
+	return socketcan.CanFrame{
+		ID:       MakeCANIDT(StandardMessage, c, ID),
 		Extended: false,
 		RTR:      true,
 		Data:     make([]byte, 0),
 		Dlc:      0,
-	})
+	}
 }
 
-type messageCreator func() Message
-
-var messageFactory = make(map[MessageClass]messageCreator)
-
-type networkCommandParser func(c MessageClass, buffer []byte) (ReceivableMessage, NodeID, error)
-
-var networkCommandFactory = make(map[NodeID]networkCommandParser)
+func RequestMessage(itf FrameSender, m ReceivableMessage, ID NodeID) error {
+	if err := checkID(ID); err != nil {
+		return err
+	}
+	return itf.Send(makeRequestFrame(m.MessageClassID(), ID))
+}
 
 type MessageRequestData struct {
 	Class MessageClass
@@ -158,9 +152,9 @@ func (d *MessageRequestData) MessageClassID() MessageClass {
 	return RTRRequestMessage
 }
 
-var messagesName = make(map[MessageClass]string)
-
 func (c MessageClass) String() string {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
 	if n, ok := messagesName[c]; ok == true {
 		return n
 	}
@@ -178,66 +172,91 @@ func (d *MessageRequestData) Unmarshal(buf []byte) error {
 	return nil
 }
 
-func parseRTR(f *socketcan.CanFrame) (ReceivableMessage, NodeID, error) {
+func parseRTR(f *socketcan.CanFrame, s *messageSet) (ReceivableMessage, NodeID, error) {
 	mType, mClass, mID := ExtractCANIDT(f.ID)
 
 	if f.Dlc > 0 {
-		return nil, 0, fmt.Errorf("RTR frame with a payload")
+		return nil, 0, newParseError(f, "RTR", errRTRPayload)
 	}
 	if mType != StandardMessage && mType != HighPriorityMessage {
-		return nil, 0, fmt.Errorf("Unauthorized network command RTR frame")
+		return nil, 0, newParseError(f, "RTR", errRTRNetworkCmd)
 	}
 
-	_, ok := messageFactory[mClass]
-	if ok == false {
-		return nil, mID, fmt.Errorf("Unknown message type 0x%02x", int(mClass))
-	}
+	res := acquire(s, &s.request)
+	res.Class = mClass
+	res.ID = mID
+	return res, mID, nil
 
-	return &MessageRequestData{
-		Class: mClass,
-		ID:    mID,
-	}, mID, nil
+}
 
+// frameData returns the payload of a frame, or an error if its Dlc is
+// not consistent with its Data.
+func frameData(f *socketcan.CanFrame) ([]byte, error) {
+	if f.Dlc > 8 {
+		return nil, newParseError(f, "Dlc", errInvalidDlc)
+	}
+	if int(f.Dlc) > len(f.Data) {
+		return nil, newParseError(f, "Dlc", shortBufferError(len(f.Data), int(f.Dlc)))
+	}
+	return f.Data[:f.Dlc], nil
 }
 
+// ParseMessage decodes a CAN frame. Frames with an unknown message
+// class or network command are returned as a *RawMessage. If the
+// payload cannot be decoded, a *RawMessage is returned alongside the
+// error. Malformed frames, with an extended IDT or a Dlc larger than
+// 8 or than their Data, are rejected without a message. All errors
+// are of type *ParseError.
 func ParseMessage(f *socketcan.CanFrame) (ReceivableMessage, NodeID, error) {
+	var s messageSet
+	return parseFrame(f, &s)
+}
+
+func parseFrame(f *socketcan.CanFrame, s *messageSet) (ReceivableMessage, NodeID, error) {
 	if f.Extended == true {
-		return nil, 0, fmt.Errorf("Arke does not support extended IDT")
+		return nil, 0, newParseError(f, "IDT", errExtendedIDT)
 	}
 
 	if f.RTR == true {
-		return parseRTR(f)
+		return parseRTR(f, s)
 	}
 
+	data, err := frameData(f)
+	if err != nil {
+		return nil, 0, err
+	}
 	mType, mClass, mID := ExtractCANIDT(f.ID)
 	if mType == NetworkControlCommand {
-		parser, ok := networkCommandFactory[mID]
+		parser, ok := lookupNetworkCommand(mID)
 		if ok == false {
-			return nil, 0, fmt.Errorf("Unknown network command 0x%02x", mID)
+			return s.rawMessage(mType, mClass, mID, data), 0, nil
 		}
-		return parser(mClass, f.Data[0:f.Dlc])
+		m, ID, err := parser(mClass, data, s)
+		if err != nil {
+			return s.rawMessage(mType, mClass, mID, data), ID, newParseError(f, "Data", err)
+		}
+		return m, ID, nil
 	}
 
 	if mType == HeartBeat {
-		res := &HeartBeatData{}
-		if err := res.Unmarshal(f.Data[0:f.Dlc]); err != nil {
-			return nil, mID, err
+		res := acquire(s, &s.heartBeat)
+		if err := res.Unmarshal(data); err != nil {
+			return s.rawMessage(mType, mClass, mID, data), mID, newParseError(f, "Data", err)
 		}
 		res.Class = NodeClass(mClass)
 		res.ID = mID
 		return res, mID, nil
 	}
 
-	creator, ok := messageFactory[mClass]
+	creator, ok := lookupMessage(mClass)
 	if ok == false {
-		return nil, mID, fmt.Errorf("Unknown message type 0x%02x", int(mClass))
+		return s.rawMessage(mType, mClass, mID, data), mID, nil
 	}
 
-	m := creator()
-	err := m.Unmarshal(f.Data[0:f.Dlc])
-	if err != nil {
-		err = fmt.Errorf("Could not parse message data: %s", err)
+	m := s.message(mClass, creator)
+	if err := m.Unmarshal(data); err != nil {
+		return s.rawMessage(mType, mClass, mID, data), mID, newParseError(f, "Data", err)
 	}
 
-	return m, mID, err
+	return m, mID, nil
 }
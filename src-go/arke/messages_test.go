@@ -1,6 +1,9 @@
 package arke
 
 import (
+	"errors"
+	"reflect"
+	"testing"
 	"time"
 
 	socketcan "github.com/atuleu/golang-socketcan"
@@ -82,6 +85,16 @@ func (s *MessageSuite) TestMessageParsing(c *C) {
 			1,
 			&IDChangeRequestData{HeliosClass, 1, 2},
 		},
+		{
+			MakeSynchronisationRequest(HeliosClass, 42),
+			0,
+			&SynchronisationRequestData{HeliosClass, 42},
+		},
+		{
+			MakeSynchronisationReply(HeliosClass, 3, 42, 0x010203040506*time.Microsecond),
+			3,
+			&SynchronisationReplyData{HeliosClass, 3, 42, 0x010203040506 * time.Microsecond},
+		},
 		{
 			socketcan.CanFrame{ID: MakeCANIDT(NetworkControlCommand, MessageClass(0), NodeID(ErrorReport)), Dlc: 4, Data: []byte{byte(ZeusClass), 3, 0x42, 0}},
 			3,
@@ -177,30 +190,70 @@ func (s *MessageSuite) TestMessageParsing(c *C) {
 		},
 
 		{
-			socketcan.CanFrame{ID: MakeCANIDT(NetworkControlCommand, 0, 6)},
-			"Unknown network command 0x06",
+			socketcan.CanFrame{ID: MakeCANIDT(HeartBeat, MessageClass(ZeusClass), 1), Dlc: 1, Data: []byte{0}},
+			"Could not parse message data: Invalid buffer size 1 .*",
 		},
 		{
-			socketcan.CanFrame{ID: MakeCANIDT(HeartBeat, MessageClass(ZeusClass), 1), Dlc: 1, Data: []byte{0}},
-			"Invalid buffer size 1 .*",
+			socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusReportMessage, 1), Dlc: 1, Data: []byte{0}},
+			"Could not parse message data: .*",
+		},
+	}
+
+	for _, d := range errorData {
+		_, _, err := ParseMessage(&d.F)
+		c.Check(err, ErrorMatches, d.E)
+	}
+
+	rawData := []struct {
+		F socketcan.CanFrame
+		M ReceivableMessage
+		E string
+	}{
+		{
+			socketcan.CanFrame{ID: MakeCANIDT(NetworkControlCommand, MessageClass(ZeusClass), 6), Dlc: 2, Data: []byte{1, 2}},
+			&RawMessage{NetworkControlCommand, MessageClass(ZeusClass), 6, []byte{1, 2}},
+			"",
 		},
 		{
 			socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, 0, 1), Dlc: 1, Data: []byte{0}},
-			"Unknown message type 0x00",
+			&RawMessage{StandardMessage, 0, 1, []byte{0}},
+			"",
+		},
+		{
+			socketcan.CanFrame{ID: MakeCANIDT(HighPriorityMessage, ZeusVibrationReportMessage, 2), Dlc: 3, Data: []byte{1, 2, 3, 4}},
+			&RawMessage{HighPriorityMessage, ZeusVibrationReportMessage, 2, []byte{1, 2, 3}},
+			"",
 		},
 		{
 			socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, 0, 1), Dlc: 0, Data: []byte{}, RTR: true},
-			"Unknown message type 0x00",
+			&MessageRequestData{Class: 0, ID: 1},
+			"",
 		},
 		{
 			socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusReportMessage, 1), Dlc: 1, Data: []byte{0}},
+			&RawMessage{StandardMessage, ZeusReportMessage, 1, []byte{0}},
 			"Could not parse message data: .*",
 		},
+		{
+			socketcan.CanFrame{ID: MakeCANIDT(HeartBeat, MessageClass(ZeusClass), 1), Dlc: 1, Data: []byte{0}},
+			&RawMessage{HeartBeat, MessageClass(ZeusClass), 1, []byte{0}},
+			"Could not parse message data: Invalid buffer size 1 .*",
+		},
+		{
+			socketcan.CanFrame{ID: MakeCANIDT(NetworkControlCommand, 0, NodeID(IDChangeRequest)), Dlc: 1, Data: []byte{0}},
+			&RawMessage{NetworkControlCommand, 0, NodeID(IDChangeRequest), []byte{0}},
+			"Could not parse message data: Invalid buffer size 1, required: 2",
+		},
 	}
 
-	for _, d := range errorData {
-		_, _, err := ParseMessage(&d.F)
-		c.Check(err, ErrorMatches, d.E)
+	for _, d := range rawData {
+		m, _, err := ParseMessage(&d.F)
+		if len(d.E) == 0 {
+			c.Check(err, IsNil)
+		} else {
+			c.Check(err, ErrorMatches, d.E)
+		}
+		c.Check(m, DeepEquals, d.M)
 	}
 
 	// does nothing
@@ -235,3 +288,118 @@ func (s *MessageSuite) TestMessagesName(c *C) {
 	}
 
 }
+
+func (s *MessageSuite) TestRejectsMalformedFrames(c *C) {
+	testdata := []struct {
+		F     socketcan.CanFrame
+		Error string
+	}{
+		{
+			socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusSetPointMessage, 1), Dlc: 5, Data: []byte{0, 0}},
+			"Could not parse message data: Invalid buffer size 2, required: 5",
+		},
+		{
+			socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusSetPointMessage, 1), Dlc: 9, Data: make([]byte, 9)},
+			"Could not parse message data: Invalid DLC \\(max is 8\\)",
+		},
+		{
+			socketcan.CanFrame{ID: MakeCANIDT(HeartBeat, MessageClass(ZeusClass), 1), Dlc: 4},
+			"Could not parse message data: Invalid buffer size 0, required: 4",
+		},
+	}
+	for _, d := range testdata {
+		m, _, err := ParseMessage(&d.F)
+		c.Check(m, IsNil)
+		c.Check(err, ErrorMatches, d.Error)
+		var pErr *ParseError
+		if c.Check(errors.As(err, &pErr), Equals, true) {
+			c.Check(pErr.Field, Equals, "Dlc")
+		}
+	}
+}
+
+func FuzzParseMessage(f *testing.F) {
+	frames, err := LoadGoldenFrames(goldenFramesPath)
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, g := range frames {
+		f.Add(uint16(g.Frame.ID), g.Frame.Dlc, g.Frame.RTR, false, g.Frame.Data)
+	}
+	f.Add(uint16(0x5c1), uint8(9), false, false, make([]byte, 9))
+	f.Add(uint16(0x5c1), uint8(5), false, false, []byte{})
+	f.Add(uint16(0x5c1), uint8(0), false, true, []byte{})
+
+	f.Fuzz(func(t *testing.T, idt uint16, dlc uint8, rtr, extended bool, data []byte) {
+		frame := socketcan.CanFrame{ID: uint32(idt & 0x7ff), Dlc: dlc, RTR: rtr, Extended: extended, Data: data}
+		m, ID, err := ParseMessage(&frame)
+		if err != nil {
+			var pErr *ParseError
+			if errors.As(err, &pErr) == false {
+				t.Fatalf("%v: error %v is not a *ParseError", frame, err)
+			}
+			if _, ok := m.(*RawMessage); m != nil && ok == false {
+				t.Fatalf("%v: got %v alongside error %v", frame, m, err)
+			}
+			return
+		}
+		if m == nil {
+			t.Fatalf("%v: no message and no error", frame)
+		}
+
+		// decoding a re-encoded message must be stable, even if the
+		// original frame was not in its canonical form. Payloads
+		// outside the documented ranges are decoded, but cannot be
+		// emitted.
+		if v, ok := m.(interface{ Validate() error }); ok == true && v.Validate() != nil {
+			return
+		}
+		encoded, err := encodeGolden(m, frame, ID)
+		if err != nil {
+			t.Fatalf("%v: could not encode %v: %v", frame, m, err)
+		}
+		decoded, decodedID, err := ParseMessage(&encoded)
+		if err != nil {
+			t.Fatalf("%v: could not decode %v: %v", frame, encoded, err)
+		}
+		if reflect.DeepEqual(decoded, m) == false || decodedID != ID {
+			t.Fatalf("%v: decoded %v (ID %d) from %v, expected %v (ID %d)", frame, decoded, decodedID, encoded, m, ID)
+		}
+		reencoded, err := encodeGolden(decoded, encoded, decodedID)
+		if err != nil {
+			t.Fatalf("%v: could not encode %v: %v", frame, decoded, err)
+		}
+		if frameText(reencoded) != frameText(encoded) {
+			t.Fatalf("%v: re-encoded to %s, expected %s", frame, frameText(reencoded), frameText(encoded))
+		}
+	})
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	f.Add(make([]byte, 12))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		messages := []ReceivableMessage{
+			&MessageRequestData{},
+			&ResetRequestData{},
+			&HeartBeatRequestData{},
+			&SynchronisationRequestData{},
+			&SynchronisationReplyData{},
+			&IDChangeRequestData{},
+			&ErrorReportData{},
+			&HeartBeatData{},
+			&RawMessage{},
+		}
+		for _, mc := range MessageClasses() {
+			if m, err := NewMessage(mc); err == nil {
+				messages = append(messages, m)
+			}
+		}
+		for _, m := range messages {
+			// only checks that no payload can panic
+			m.Unmarshal(data)
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package arke
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrOutOfRange is matched by the errors returned by the Validate()
+// methods of the messages.
+var ErrOutOfRange = errors.New("Value out of range")
+
+// FieldInfo describes a message field, its valid range and its wire
+// encoding. A field is stored little endian on BitSize bits starting
+// at bit BitOffset of the payload. Its value, expressed in Unit, is
+// raw*Resolution + Bias.
+type FieldInfo struct {
+	// Name is the path of the field in the message struct, e.g.
+	// "Temperature[1]" or "Humidity.DividerPower".
+	Name       string
+	Unit       string
+	Min, Max   float64
+	Resolution float64
+	Bias       float64
+	BitOffset  int
+	BitSize    int
+	Signed     bool
+}
+
+// Describable is implemented by messages exposing their fields
+// metadata.
+type Describable interface {
+	Fields() []FieldInfo
+}
+
+func field(name, unit string, offset, size int, signed bool, resolution, bias float64) FieldInfo {
+	rawMin, rawMax := 0.0, math.Exp2(float64(size))-1
+	if signed == true {
+		rawMin, rawMax = -math.Exp2(float64(size-1)), math.Exp2(float64(size-1))-1
+	}
+	return FieldInfo{
+		Name:       name,
+		Unit:       unit,
+		Min:        rawMin*resolution + bias,
+		Max:        rawMax*resolution + bias,
+		Resolution: resolution,
+		Bias:       bias,
+		BitOffset:  offset,
+		BitSize:    size,
+		Signed:     signed,
+	}
+}
+
+func uintField(name string, offset, size int) FieldInfo {
+	return field(name, "", offset, size, false, 1, 0)
+}
+
+func (f FieldInfo) limit(min, max float64) FieldInfo {
+	f.Min, f.Max = min, max
+	return f
+}
+
+// Encoding describes the wire encoding of the field, e.g. "bits
+// 16-31, uint16, x*0.01007-40".
+func (f FieldInfo) Encoding() string {
+	kind := "uint"
+	if f.Signed == true {
+		kind = "int"
+	}
+	res := fmt.Sprintf("bits %d-%d, %s%d", f.BitOffset, f.BitOffset+f.BitSize-1, kind, f.BitSize)
+	if f.Resolution != 1 || f.Bias != 0 {
+		res += fmt.Sprintf(", x*%.4g", f.Resolution)
+		if f.Bias != 0 {
+			res += fmt.Sprintf("%+g", f.Bias)
+		}
+	}
+	return res
+}
+
+func (f FieldInfo) String() string {
+	return fmt.Sprintf("%s [%g%s, %g%s] (%s)", f.Name, f.Min, f.Unit, f.Max, f.Unit, f.Encoding())
+}
+
+// RangeError is returned when a field value is outside its valid
+// range. It matches ErrOutOfRange, and ErrDurationOverflow for
+// durations.
+type RangeError struct {
+	Field FieldInfo
+	Value float64
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("%s %g%s is out of range [%g%s, %g%s]",
+		e.Field.Name, e.Value, e.Field.Unit, e.Field.Min, e.Field.Unit, e.Field.Max, e.Field.Unit)
+}
+
+func (e *RangeError) Is(target error) bool {
+	if target == ErrOutOfRange {
+		return true
+	}
+	_, isDuration := durationUnits[e.Field.Unit]
+	return target == ErrDurationOverflow && isDuration
+}
+
+var durationUnits = map[string]time.Duration{
+	"s":  time.Second,
+	"ms": time.Millisecond,
+	"µs": time.Microsecond,
+}
+
+// fieldByPath resolves a path like "Temperature[2]" or
+// "Humidity.DividerPower" in a struct value.
+func fieldByPath(v reflect.Value, path string) (reflect.Value, error) {
+	for rest, more := path, true; more == true; {
+		var part string
+		part, rest, more = strings.Cut(rest, ".")
+		name, index, indexed := strings.Cut(part, "[")
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("unknown field")
+		}
+		f, ok := v.Type().FieldByName(name)
+		if ok == false || f.IsExported() == false {
+			return reflect.Value{}, fmt.Errorf("unknown field")
+		}
+		v = v.FieldByIndex(f.Index)
+		if indexed == false {
+			continue
+		}
+		i, err := strconv.Atoi(strings.TrimSuffix(index, "]"))
+		if err != nil || v.Kind() != reflect.Array || i < 0 || i >= v.Len() {
+			return reflect.Value{}, fmt.Errorf("invalid index in '%s'", path)
+		}
+		v = v.Index(i)
+	}
+	return v, nil
+}
+
+// fieldType returns the Go type of the field at path in the message
+// m, or nil if it cannot be resolved.
+func fieldType(m any, path string) reflect.Type {
+	v, err := fieldByPath(reflect.Indirect(reflect.ValueOf(m)), path)
+	if err != nil {
+		return nil
+	}
+	return v.Type()
+}
+
+// fieldValue returns the value of a field expressed in its unit.
+func fieldValue(v reflect.Value, unit string) (float64, error) {
+	if v.Type() == durationType {
+		d, ok := durationUnits[unit]
+		if ok == false {
+			return 0, fmt.Errorf("invalid duration unit '%s'", unit)
+		}
+		return float64(v.Int()) / float64(d), nil
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	}
+	return 0, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// checkRange returns a *RangeError if value, expressed in the unit of
+// f, is outside the valid range of f. It is used by the Validate()
+// methods, and only allocates on error.
+func checkRange(f *FieldInfo, value float64) error {
+	if math.IsNaN(value) == true || value < f.Min || value > f.Max {
+		return &RangeError{Field: *f, Value: value}
+	}
+	return nil
+}
+
+// validateFields is the reflection based equivalent of the Validate()
+// methods.
+func validateFields(m any, fields []FieldInfo) error {
+	v := reflect.Indirect(reflect.ValueOf(m))
+	for _, f := range fields {
+		fv, err := fieldByPath(v, f.Name)
+		if err != nil {
+			return fmt.Errorf("Invalid field '%s': %w", f.Name, err)
+		}
+		value, err := fieldValue(fv, f.Unit)
+		if err != nil {
+			return fmt.Errorf("Invalid field '%s': %w", f.Name, err)
+		}
+		if math.IsNaN(value) == true || value < f.Min || value > f.Max {
+			return &RangeError{Field: f, Value: value}
+		}
+	}
+	return nil
+}
+
+// MessageFields returns the fields metadata of a registered message
+// class.
+func MessageFields(c MessageClass) ([]FieldInfo, error) {
+	m, err := NewMessage(c)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := m.(Describable)
+	if ok == false {
+		return nil, fmt.Errorf("%s does not describe its fields", c)
+	}
+	return d.Fields(), nil
+}
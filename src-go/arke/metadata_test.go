@@ -0,0 +1,198 @@
+package arke
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type MetadataSuite struct{}
+
+var _ = Suite(&MetadataSuite{})
+
+// leafFields lists the paths of all the exported scalar fields of a
+// struct type.
+func leafFields(t reflect.Type, prefix string) []string {
+	var res []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.IsExported() == false {
+			continue
+		}
+		name := prefix + f.Name
+		switch {
+		case f.Type.Kind() == reflect.Struct:
+			res = append(res, leafFields(f.Type, name+".")...)
+		case f.Type.Kind() == reflect.Array:
+			for j := 0; j < f.Type.Len(); j++ {
+				res = append(res, name+"["+string(rune('0'+j))+"]")
+			}
+		default:
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// inIDT lists the fields encoded in the IDT rather than the payload.
+var inIDT = map[reflect.Type][]string{
+	reflect.TypeOf(HeartBeatData{}):              {"Class", "ID"},
+	reflect.TypeOf(ResetRequestData{}):           {"Class"},
+	reflect.TypeOf(HeartBeatRequestData{}):       {"Class"},
+	reflect.TypeOf(IDChangeRequestData{}):        {"Class"},
+	reflect.TypeOf(SynchronisationRequestData{}): {"Class"},
+	reflect.TypeOf(SynchronisationReplyData{}):   {"Class"},
+}
+
+func describableMessages(c *C) []Describable {
+	messages := []Describable{&HeartBeatData{}, &ResetRequestData{}, &HeartBeatRequestData{},
+		&IDChangeRequestData{}, &ErrorReportData{}, &SynchronisationRequestData{}, &SynchronisationReplyData{}}
+	for _, mc := range MessageClasses() {
+		m, err := NewMessage(mc)
+		if err != nil {
+			continue
+		}
+		d, ok := m.(Describable)
+		if c.Check(ok, Equals, true, Commentf("%s", mc)) == true {
+			messages = append(messages, d)
+		}
+	}
+	return messages
+}
+
+// setFieldValue sets a field to a value expressed in unit. It returns
+// false if the field type cannot hold the value.
+func setFieldValue(v reflect.Value, unit string, value float64) bool {
+	if v.Type() == durationType {
+		value *= float64(durationUnits[unit])
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(value)
+		return true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.OverflowInt(int64(value)) == true || value != math.Trunc(value) {
+			return false
+		}
+		v.SetInt(int64(value))
+		return true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value < 0 || v.OverflowUint(uint64(value)) == true || value != math.Trunc(value) {
+			return false
+		}
+		v.SetUint(uint64(value))
+		return true
+	}
+	return false
+}
+
+func (s *MetadataSuite) TestFieldsAreComplete(c *C) {
+	messages := describableMessages(c)
+
+	for _, m := range messages {
+		t := reflect.TypeOf(m).Elem()
+		described := map[string]bool{}
+		used := 0
+		for _, f := range m.Fields() {
+			described[f.Name] = true
+			c.Check(f.BitOffset >= used, Equals, true, Commentf("%s.%s overlaps", t, f.Name))
+			used = f.BitOffset + f.BitSize
+			c.Check(used <= 64, Equals, true, Commentf("%s.%s", t, f.Name))
+			rawMax := (f.Max - f.Bias) / f.Resolution
+			rawMin := (f.Min - f.Bias) / f.Resolution
+			if f.Signed == true {
+				c.Check(rawMin >= -math.Exp2(float64(f.BitSize-1)), Equals, true, Commentf("%s.%s", t, f.Name))
+				c.Check(rawMax <= math.Exp2(float64(f.BitSize-1))-1+1e-6, Equals, true, Commentf("%s.%s", t, f.Name))
+			} else {
+				c.Check(rawMin >= -1e-6, Equals, true, Commentf("%s.%s", t, f.Name))
+				c.Check(rawMax <= math.Exp2(float64(f.BitSize))-1+1e-6, Equals, true, Commentf("%s.%s", t, f.Name))
+			}
+		}
+		for _, name := range leafFields(t, "") {
+			if slices.Contains(inIDT[t], name) == true {
+				continue
+			}
+			c.Check(described[name], Equals, true, Commentf("%s.%s is not described", t, name))
+		}
+		if t != reflect.TypeOf(IDChangeRequestData{}) {
+			c.Check(validateFields(m, m.Fields()), IsNil, Commentf("zero %s", t))
+		}
+	}
+}
+
+func (s *MetadataSuite) TestValidation(c *C) {
+	testdata := []struct {
+		M SendableMessage
+		E string
+	}{
+		{&ZeusSetPoint{Humidity: 101}, "Humidity 101% is out of range \\[0%, 100%\\]"},
+		{&ZeusSetPoint{Temperature: -41}, "Temperature -41°C is out of range \\[-40°C, 125°C\\]"},
+		{&ZeusSetPoint{Humidity: float32(math.NaN())}, "Humidity NaN% is out of range .*"},
+		{&ZeusReport{Temperature: [4]float32{0, 0, 128, 0}}, "Temperature\\[2\\] 128°C is out of range \\[-128°C, 127.9375°C\\]"},
+		{&HeliosPulseMode{Period: 70 * time.Second}, "Period 70000ms is out of range \\[0ms, 65535ms\\]"},
+		{&HeliosTriggerMode{Period: 7 * time.Second}, "Period 7e\\+06µs is out of range \\[0µs, 6.5535e\\+06µs\\]"},
+		{&HeliosTriggerMode{PulseLength: 4 * time.Millisecond}, "PulseLength 4000µs is out of range \\[0µs, 3500µs\\]"},
+		{&HeliosTriggerMode{CameraDelay: -40 * time.Millisecond}, "CameraDelay -40000µs is out of range \\[-32768µs, 32767µs\\]"},
+		{&NotusConfig{RampDownTime: -time.Second}, "RampDownTime -1000ms is out of range \\[0ms, 65535ms\\]"},
+		{&ZeusDeltaTemperature{Delta: [4]float32{0, 0, 0, 4096}}, "Delta\\[3\\] 4096°C is out of range \\[-2048°C, 2047.9375°C\\]"},
+	}
+
+	for _, d := range testdata {
+		_, err := d.M.Marshal(make([]byte, 8))
+		c.Check(err, ErrorMatches, d.E)
+		c.Check(errors.Is(err, ErrOutOfRange), Equals, true)
+		var rerr *RangeError
+		c.Check(errors.As(err, &rerr), Equals, true)
+	}
+
+	c.Check((&HeliosPulseMode{Period: 70 * time.Second}).Validate(), Not(IsNil))
+	c.Check(errors.Is((&ZeusSetPoint{Humidity: 101}).Validate(), ErrDurationOverflow), Equals, false)
+	c.Check((IDChangeRequestData{Old: 0, New: 3}).Validate(), ErrorMatches, "Old 0 is out of range \\[1, 7\\]")
+}
+
+func (s *MetadataSuite) TestValidateMatchesFields(c *C) {
+	type validable interface {
+		Describable
+		Validate() error
+	}
+	for _, d := range describableMessages(c) {
+		for _, f := range d.Fields() {
+			for _, value := range []float64{f.Min - 1, f.Min, f.Max, f.Max + 1, math.NaN()} {
+				m := reflect.New(reflect.TypeOf(d).Elem())
+				fv, err := fieldByPath(m.Elem(), f.Name)
+				c.Assert(err, IsNil)
+				if setFieldValue(fv, f.Unit, value) == false {
+					continue
+				}
+				v := m.Interface().(validable)
+				comment := Commentf("%T.%s = %g", v, f.Name, value)
+				expected := validateFields(v, v.Fields())
+				err = v.Validate()
+				if expected == nil {
+					c.Check(err, IsNil, comment)
+				} else if c.Check(err, Not(IsNil), comment) == true {
+					c.Check(err.Error(), Equals, expected.Error(), comment)
+				}
+			}
+		}
+	}
+}
+
+func (s *MetadataSuite) TestMetadata(c *C) {
+	fields, err := MessageFields(HeliosTriggerModeMessage)
+	c.Assert(err, IsNil)
+	c.Assert(fields, HasLen, 3)
+	c.Check(fields[0].String(), Equals, "Period [0µs, 6.5535e+06µs] (bits 0-15, uint16, x*100)")
+	c.Check(fields[2].Encoding(), Equals, "bits 32-47, int16")
+
+	fields, err = MessageFields(ZeusSetPointMessage)
+	c.Assert(err, IsNil)
+	c.Check(fields[1].Encoding(), Equals, "bits 16-31, uint16, x*0.01007-40")
+
+	_, err = MessageFields(ZeusVibrationReportMessage)
+	c.Check(errors.Is(err, ErrUnknownMessageClass), Equals, true)
+}
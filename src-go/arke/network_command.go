@@ -48,6 +48,33 @@ func MakeHeartBeatRequest(c NodeClass, t time.Duration) socketcan.CanFrame {
 	return f
 }
 
+func MakeSynchronisationRequest(c NodeClass, sequence uint8) socketcan.CanFrame {
+	return socketcan.CanFrame{
+		ID:       MakeCANIDT(NetworkControlCommand, MessageClass(c), NodeID(SynchronisationRequest)),
+		Dlc:      1,
+		Extended: false,
+		RTR:      false,
+		Data:     []byte{sequence},
+	}
+}
+
+// MakeSynchronisationReply builds the frame a node sends to answer a
+// synchronisation request. timestamp is truncated to the microsecond
+// and to 48 bits.
+func MakeSynchronisationReply(c NodeClass, ID NodeID, sequence uint8, timestamp time.Duration) socketcan.CanFrame {
+	f := socketcan.CanFrame{
+		ID:       MakeCANIDT(NetworkControlCommand, MessageClass(c), NodeID(SynchronisationRequest)),
+		Dlc:      8,
+		Extended: false,
+		RTR:      false,
+		Data:     make([]byte, 8),
+	}
+	f.Data[0] = sequence
+	f.Data[1] = byte(ID)
+	putUint48(f.Data[2:], uint64(timestamp.Microseconds()))
+	return f
+}
+
 func MakeIDChangeRequest(c NodeClass, original, new NodeID) socketcan.CanFrame {
 	return socketcan.CanFrame{
 		ID:       MakeCANIDT(NetworkControlCommand, MessageClass(c), NodeID(IDChangeRequest)),
@@ -58,6 +85,35 @@ func MakeIDChangeRequest(c NodeClass, original, new NodeID) socketcan.CanFrame {
 	}
 }
 
+// MakeErrorReport builds the frame a node sends to report an internal
+// error.
+func MakeErrorReport(c NodeClass, ID NodeID, code uint16) socketcan.CanFrame {
+	f := socketcan.CanFrame{
+		ID:       MakeCANIDT(NetworkControlCommand, 0, NodeID(ErrorReport)),
+		Dlc:      4,
+		Extended: false,
+		RTR:      false,
+		Data:     []byte{byte(c), byte(ID), 0x00, 0x00},
+	}
+	binary.LittleEndian.PutUint16(f.Data[2:], code)
+	return f
+}
+
+// MakeHeartBeat builds the heartbeat frame of a node. The version is
+// only sent if it is not zero, as when answering a ping.
+func MakeHeartBeat(c NodeClass, ID NodeID, v FirmwareVersion) socketcan.CanFrame {
+	f := socketcan.CanFrame{
+		ID:       MakeCANIDT(HeartBeat, MessageClass(c), ID),
+		Extended: false,
+		RTR:      false,
+	}
+	if v.IsZero() == false {
+		f.Dlc = 4
+		f.Data = []byte{v.Major, v.Minor, v.Patch, v.Tweak}
+	}
+	return f
+}
+
 type ResetRequestData struct {
 	Class NodeClass
 	ID    NodeID
@@ -67,6 +123,18 @@ func (d *ResetRequestData) MessageClassID() MessageClass {
 	return ResetRequestMessage
 }
 
+func (m ResetRequestData) Fields() []FieldInfo {
+	return []FieldInfo{
+		uintField("ID", 0, 8).limit(0, 7),
+	}
+}
+
+var resetRequestFields = ResetRequestData{}.Fields()
+
+func (m ResetRequestData) Validate() error {
+	return checkRange(&resetRequestFields[0], float64(m.ID))
+}
+
 func (d *ResetRequestData) String() string {
 	if d.ID == 0 {
 		return fmt.Sprintf("arke.ResetRequest{Class: %s, Node: All}", d.Class)
@@ -91,6 +159,18 @@ func (d *HeartBeatRequestData) MessageClassID() MessageClass {
 	return HeartBeatRequestMessage
 }
 
+func (m HeartBeatRequestData) Fields() []FieldInfo {
+	return []FieldInfo{
+		field("Period", "ms", 0, 16, false, 1, 0),
+	}
+}
+
+var heartBeatRequestFields = HeartBeatRequestData{}.Fields()
+
+func (m HeartBeatRequestData) Validate() error {
+	return checkRange(&heartBeatRequestFields[0], float64(m.Period)/float64(time.Millisecond))
+}
+
 func (d *HeartBeatRequestData) String() string {
 	periodStr := "SinglePing"
 	if d.Period != 0 {
@@ -113,6 +193,100 @@ func (d *HeartBeatRequestData) Unmarshal(buf []byte) error {
 	return nil
 }
 
+const synchronisationReplySize = 8
+
+func putUint48(buf []byte, v uint64) {
+	for i := 0; i < 6; i++ {
+		buf[i] = byte(v >> (8 * i))
+	}
+}
+
+func uint48(buf []byte) uint64 {
+	res := uint64(0)
+	for i := 0; i < 6; i++ {
+		res |= uint64(buf[i]) << (8 * i)
+	}
+	return res
+}
+
+type SynchronisationRequestData struct {
+	Class    NodeClass
+	Sequence uint8
+}
+
+func (d *SynchronisationRequestData) MessageClassID() MessageClass {
+	return SynchronisationRequestMessage
+}
+
+func (m SynchronisationRequestData) Fields() []FieldInfo {
+	return []FieldInfo{
+		uintField("Sequence", 0, 8),
+	}
+}
+
+func (m SynchronisationRequestData) Validate() error {
+	// every value of the fields is valid
+	return nil
+}
+
+func (d *SynchronisationRequestData) String() string {
+	return fmt.Sprintf("arke.SynchronisationRequest{Class: %s, Node: All, Sequence: %d}", d.Class, d.Sequence)
+}
+
+func (d *SynchronisationRequestData) Unmarshal(buf []byte) error {
+	if err := checkSize(buf, 1); err != nil {
+		return err
+	}
+	d.Sequence = buf[0]
+	return nil
+}
+
+// SynchronisationReplyData is sent by a node in response to a
+// SynchronisationRequest. Timestamp is the node's clock when it
+// received the request.
+type SynchronisationReplyData struct {
+	Class     NodeClass
+	ID        NodeID
+	Sequence  uint8
+	Timestamp time.Duration
+}
+
+func (d *SynchronisationReplyData) MessageClassID() MessageClass {
+	return SynchronisationRequestMessage
+}
+
+func (m SynchronisationReplyData) Fields() []FieldInfo {
+	return []FieldInfo{
+		uintField("Sequence", 0, 8),
+		uintField("ID", 8, 8).limit(0, 7),
+		field("Timestamp", "µs", 16, 48, false, 1, 0),
+	}
+}
+
+var synchronisationReplyFields = SynchronisationReplyData{}.Fields()
+
+func (m SynchronisationReplyData) Validate() error {
+	if err := checkRange(&synchronisationReplyFields[1], float64(m.ID)); err != nil {
+		return err
+	}
+	return checkRange(&synchronisationReplyFields[2], float64(m.Timestamp)/float64(time.Microsecond))
+}
+
+func (d *SynchronisationReplyData) String() string {
+	return fmt.Sprintf("arke.SynchronisationReply{Class: %s, ID: %d, Sequence: %d, Timestamp: %s}",
+		d.Class, d.ID, d.Sequence, d.Timestamp)
+}
+
+func (d *SynchronisationReplyData) Unmarshal(buf []byte) error {
+	if err := checkSize(buf, synchronisationReplySize); err != nil {
+		return err
+	}
+	d.Sequence = buf[0]
+	d.ID = NodeID(buf[1])
+	d.Timestamp = time.Duration(uint48(buf[2:])) * time.Microsecond
+	return nil
+}
+
 type IDChangeRequestData struct {
 	Class    NodeClass
 	Old, New NodeID
@@ -122,6 +296,22 @@ func (d *IDChangeRequestData) MessageClassID() MessageClass {
 	return IDChangeRequestMessage
 }
 
+func (m IDChangeRequestData) Fields() []FieldInfo {
+	return []FieldInfo{
+		uintField("Old", 0, 8).limit(1, 7),
+		uintField("New", 8, 8).limit(1, 7),
+	}
+}
+
+var idChangeRequestFields = IDChangeRequestData{}.Fields()
+
+func (m IDChangeRequestData) Validate() error {
+	if err := checkRange(&idChangeRequestFields[0], float64(m.Old)); err != nil {
+		return err
+	}
+	return checkRange(&idChangeRequestFields[1], float64(m.New))
+}
+
 func (d *IDChangeRequestData) String() string {
 	return fmt.Sprintf("arke.IDChangeRequest{Class: %s, OldID: %d, NewID: %d}", d.Class, d.Old, d.New)
 }
@@ -145,7 +335,27 @@ func (d *ErrorReportData) MessageClassID() MessageClass {
 	return ErrorReportMessage
 }
 
+func (m ErrorReportData) Fields() []FieldInfo {
+	return []FieldInfo{
+		uintField("Class", 0, 8).limit(0, maxClass),
+		uintField("ID", 8, 8).limit(0, 7),
+		uintField("ErrorCode", 16, 16),
+	}
+}
+
+var errorReportFields = ErrorReportData{}.Fields()
+
+func (m ErrorReportData) Validate() error {
+	if err := checkRange(&errorReportFields[0], float64(m.Class)); err != nil {
+		return err
+	}
+	return checkRange(&errorReportFields[1], float64(m.ID))
+}
+
 func (d *ErrorReportData) String() string {
+	if info, ok := d.Describe(); ok == true {
+		return fmt.Sprintf("arke.ErrorReport{Class: %s, ID: %d, ErrorCode: 0x%04x %s}", d.Class, d.ID, d.ErrorCode, info)
+	}
 	return fmt.Sprintf("arke.ErrorReport{Class: %s, ID: %d, ErrorCode: 0x%04x}", d.Class, d.ID, d.ErrorCode)
 }
 
@@ -178,7 +388,7 @@ func (h *HeartBeatData) Unmarshal(buf []byte) error {
 	}
 
 	if len(buf) == 1 {
-		return fmt.Errorf("Invalid buffer size 1 (min 2 required)")
+		return fmt.Errorf("%w 1 (min 2 required)", ErrShortBuffer)
 	}
 
 	h.MajorVersion = buf[0]
@@ -193,61 +403,106 @@ func (h *HeartBeatData) Unmarshal(buf []byte) error {
 }
 
 func (h *HeartBeatData) String() string {
-	if h.MajorVersion == 0 && h.MinorVersion == 0 && h.PatchVersion == 0 && h.TweakVersion == 0 {
+	if h.Version().IsZero() == true {
 		return fmt.Sprintf("arke.HeartBeat{Class: %s, ID: %d}", ClassName(h.Class), h.ID)
 	}
 
-	return fmt.Sprintf("arke.HeartBeat{Class: %s, ID: %d, Version: %d.%d.%d.%d}",
+	return fmt.Sprintf("arke.HeartBeat{Class: %s, ID: %d, Version: %s}",
 		ClassName(h.Class),
 		h.ID,
-		h.MajorVersion,
-		h.MinorVersion,
-		h.PatchVersion,
-		h.TweakVersion)
+		h.Version())
 }
 
 func (h *HeartBeatData) MessageClassID() MessageClass {
 	return HeartBeatMessage
 }
 
+func (m HeartBeatData) Fields() []FieldInfo {
+	return []FieldInfo{
+		uintField("MajorVersion", 0, 8),
+		uintField("MinorVersion", 8, 8),
+		uintField("PatchVersion", 16, 8),
+		uintField("TweakVersion", 24, 8),
+	}
+}
+
+func (m HeartBeatData) Validate() error {
+	// every value of the fields is valid
+	return nil
+}
+
+type FirmwareVersion struct {
+	Major, Minor, Patch, Tweak uint8
+}
+
+func (v FirmwareVersion) IsZero() bool {
+	return v == FirmwareVersion{}
+}
+
+func (v FirmwareVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Patch, v.Tweak)
+}
+
+func (h *HeartBeatData) Version() FirmwareVersion {
+	return FirmwareVersion{
+		Major: h.MajorVersion,
+		Minor: h.MinorVersion,
+		Patch: h.PatchVersion,
+		Tweak: h.TweakVersion,
+	}
+}
+
 func init() {
-	networkCommandFactory[NodeID(ResetRequest)] = func(c MessageClass, buffer []byte) (ReceivableMessage, NodeID, error) {
-		res := &ResetRequestData{
-			Class: NodeClass(c),
+	registerNetworkCommand(ResetRequest, func(c MessageClass, buffer []byte, s *messageSet) (ReceivableMessage, NodeID, error) {
+		res := acquire(s, &s.resetRequest)
+		res.Class = NodeClass(c)
+		if err := res.Unmarshal(buffer); err != nil {
+			return nil, 0, err
+		}
+		return res, res.ID, nil
+	})
+
+	registerNetworkCommand(SynchronisationRequest, func(c MessageClass, buffer []byte, s *messageSet) (ReceivableMessage, NodeID, error) {
+		if len(buffer) <= 1 {
+			res := acquire(s, &s.synchronisationRequest)
+			res.Class = NodeClass(c)
+			if err := res.Unmarshal(buffer); err != nil {
+				return nil, 0, err
+			}
+			return res, 0, nil
 		}
+		res := acquire(s, &s.synchronisationReply)
+		res.Class = NodeClass(c)
 		if err := res.Unmarshal(buffer); err != nil {
 			return nil, 0, err
 		}
 		return res, res.ID, nil
-	}
+	})
 
-	networkCommandFactory[NodeID(IDChangeRequest)] = func(c MessageClass, buffer []byte) (ReceivableMessage, NodeID, error) {
-		res := &IDChangeRequestData{
-			Class: NodeClass(c),
-		}
+	registerNetworkCommand(IDChangeRequest, func(c MessageClass, buffer []byte, s *messageSet) (ReceivableMessage, NodeID, error) {
+		res := acquire(s, &s.idChangeRequest)
+		res.Class = NodeClass(c)
 		if err := res.Unmarshal(buffer); err != nil {
 			return nil, 0, err
 		}
 		return res, res.Old, nil
-	}
+	})
 
-	networkCommandFactory[NodeID(HeartBeatRequest)] = func(c MessageClass, buffer []byte) (ReceivableMessage, NodeID, error) {
-		res := &HeartBeatRequestData{
-			Class: NodeClass(c),
-		}
+	registerNetworkCommand(HeartBeatRequest, func(c MessageClass, buffer []byte, s *messageSet) (ReceivableMessage, NodeID, error) {
+		res := acquire(s, &s.heartBeatRequest)
+		res.Class = NodeClass(c)
 		if err := res.Unmarshal(buffer); err != nil {
 			return nil, 0, err
 		}
 
 		return res, 0, nil
-	}
+	})
 
-	networkCommandFactory[NodeID(ErrorReport)] = func(c MessageClass, buffer []byte) (ReceivableMessage, NodeID, error) {
-		res := &ErrorReportData{}
+	registerNetworkCommand(ErrorReport, func(c MessageClass, buffer []byte, s *messageSet) (ReceivableMessage, NodeID, error) {
+		res := acquire(s, &s.errorReport)
 		if err := res.Unmarshal(buffer); err != nil {
 			return nil, 0, err
 		}
 		return res, res.ID, nil
-	}
-
+	})
 }
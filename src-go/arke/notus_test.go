@@ -12,7 +12,7 @@ type NotusSuite struct{}
 var _ = Suite(&NotusSuite{})
 
 func checkMessageEncoding(c *C, m Message, buffer []byte) {
-	builder, ok := messageFactory[m.MessageClassID()]
+	builder, ok := lookupMessage(m.MessageClassID())
 	c.Assert(ok, Equals, true, Commentf("missing factory"))
 	parsed := builder()
 
@@ -0,0 +1,289 @@
+package arke
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+)
+
+// pcapng captures are made of blocks, see
+// https://www.ietf.org/archive/id/draft-ietf-opsawg-pcapng-01.html. A
+// Recorder writes a single section, with an Interface Description
+// Block for each interface before its first frame, and an Enhanced
+// Packet Block for each frame, in the LINKTYPE_CAN_SOCKETCAN format.
+const (
+	pcapngSectionHeader        uint32 = 0x0a0d0d0a
+	pcapngInterfaceDescription uint32 = 0x00000001
+	pcapngEnhancedPacket       uint32 = 0x00000006
+	pcapngByteOrderMagic       uint32 = 0x1a2b3c4d
+
+	pcapngOptionEnd          uint16 = 0
+	pcapngOptionIfName       uint16 = 2
+	pcapngOptionIfTsresol    uint16 = 9
+	pcapngDefaultTsresol     uint8  = 6
+	pcapngMaxBlockLength     uint32 = 1 << 20
+	linkTypeCANSocketCAN     uint16 = 227
+	socketCANFrameHeaderSize int    = 8
+	socketCANFrameSize       int    = 16
+)
+
+func appendPcapngOption(b []byte, code uint16, value []byte) []byte {
+	b = binary.LittleEndian.AppendUint16(b, code)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(value)))
+	b = append(b, value...)
+	for i := len(value); i%4 != 0; i++ {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// appendPcapngBlock appends a block of type t, whose body is appended
+// by body.
+func appendPcapngBlock(b []byte, t uint32, body func([]byte) []byte) []byte {
+	start := len(b)
+	b = binary.LittleEndian.AppendUint32(b, t)
+	b = binary.LittleEndian.AppendUint32(b, 0)
+	b = body(b)
+	length := uint32(len(b) - start + 4)
+	binary.LittleEndian.PutUint32(b[start+4:], length)
+	return binary.LittleEndian.AppendUint32(b, length)
+}
+
+func appendPcapngSectionHeader(b []byte) []byte {
+	return appendPcapngBlock(b, pcapngSectionHeader, func(b []byte) []byte {
+		b = binary.LittleEndian.AppendUint32(b, pcapngByteOrderMagic)
+		b = binary.LittleEndian.AppendUint16(b, 1)
+		b = binary.LittleEndian.AppendUint16(b, 0)
+		// unknown section length
+		return binary.LittleEndian.AppendUint64(b, 0xffffffffffffffff)
+	})
+}
+
+func appendPcapngInterface(b []byte, _ uint8, name string) []byte {
+	return appendPcapngBlock(b, pcapngInterfaceDescription, func(b []byte) []byte {
+		b = binary.LittleEndian.AppendUint16(b, linkTypeCANSocketCAN)
+		b = binary.LittleEndian.AppendUint16(b, 0)
+		b = binary.LittleEndian.AppendUint32(b, uint32(socketCANFrameSize))
+		b = appendPcapngOption(b, pcapngOptionIfName, []byte(name))
+		b = appendPcapngOption(b, pcapngOptionIfTsresol, []byte{pcapngDefaultTsresol})
+		return appendPcapngOption(b, pcapngOptionEnd, nil)
+	})
+}
+
+// appendSocketCANFrame appends f in the LINKTYPE_CAN_SOCKETCAN
+// format, which holds the IDT and its flags in network byte order.
+func appendSocketCANFrame(b []byte, f *socketcan.CanFrame) []byte {
+	ID := f.ID & captureSFFMask
+	if f.Extended == true {
+		ID = f.ID&captureEFFMask | captureEFFFlag
+	}
+	if f.RTR == true {
+		ID |= captureRTRFlag
+	}
+	b = binary.BigEndian.AppendUint32(b, ID)
+	b = append(b, f.Dlc, 0, 0, 0)
+	data := [8]byte{}
+	if f.RTR == false {
+		copy(data[:], f.Data[:f.Dlc])
+	}
+	return append(b, data[:]...)
+}
+
+func (r *Recorder) writePcapng(record Record) error {
+	index, b, err := r.interfaceIndex(record.Interface, r.buffer[:0], appendPcapngInterface)
+	if err != nil {
+		return err
+	}
+	b = appendPcapngBlock(b, pcapngEnhancedPacket, func(b []byte) []byte {
+		ts := uint64(record.Time.UnixMicro())
+		b = binary.LittleEndian.AppendUint32(b, uint32(index))
+		b = binary.LittleEndian.AppendUint32(b, uint32(ts>>32))
+		b = binary.LittleEndian.AppendUint32(b, uint32(ts))
+		b = binary.LittleEndian.AppendUint32(b, uint32(socketCANFrameSize))
+		b = binary.LittleEndian.AppendUint32(b, uint32(socketCANFrameSize))
+		return appendSocketCANFrame(b, &record.Frame)
+	})
+	r.buffer = b
+	_, err = r.w.Write(b)
+	return err
+}
+
+type pcapngInterface struct {
+	name     string
+	linkType uint16
+	// timestamp units per second
+	resolution uint64
+}
+
+// pcapngReader holds the state of the current section of a pcapng
+// capture.
+type pcapngReader struct {
+	order      binary.ByteOrder
+	interfaces []pcapngInterface
+}
+
+func (p *Player) readPcapngBlock() (uint32, []byte, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(p.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	t := binary.LittleEndian.Uint32(header[0:])
+	if t == pcapngSectionHeader {
+		// the byte order of the new section is given by its body.
+		magic, err := p.r.Peek(4)
+		if err != nil {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		switch binary.LittleEndian.Uint32(magic) {
+		case pcapngByteOrderMagic:
+			p.pcapng.order = binary.LittleEndian
+		case bits.ReverseBytes32(pcapngByteOrderMagic):
+			p.pcapng.order = binary.BigEndian
+		default:
+			return 0, nil, fmt.Errorf("Invalid pcapng byte-order magic")
+		}
+		p.pcapng.interfaces = p.pcapng.interfaces[:0]
+	} else if p.pcapng.order == nil {
+		return 0, nil, fmt.Errorf("pcapng capture does not start with a section header")
+	}
+	order := p.pcapng.order
+	t = order.Uint32(header[0:])
+	length := order.Uint32(header[4:])
+	if length < 12 || length%4 != 0 || length > pcapngMaxBlockLength {
+		return 0, nil, fmt.Errorf("Invalid pcapng block length %d", length)
+	}
+	body := make([]byte, length-8)
+	if err := p.readFull(body); err != nil {
+		return 0, nil, err
+	}
+	if order.Uint32(body[len(body)-4:]) != length {
+		return 0, nil, fmt.Errorf("Inconsistent pcapng block length")
+	}
+	return t, body[:len(body)-4], nil
+}
+
+func (p *Player) readPcapngInterface(body []byte) error {
+	order := p.pcapng.order
+	if len(body) < 8 {
+		return fmt.Errorf("Invalid pcapng interface description")
+	}
+	res := pcapngInterface{
+		name:       fmt.Sprintf("pcapng%d", len(p.pcapng.interfaces)),
+		linkType:   order.Uint16(body[0:]),
+		resolution: 1000000,
+	}
+	options := body[8:]
+	for len(options) >= 4 {
+		code := order.Uint16(options[0:])
+		length := int(order.Uint16(options[2:]))
+		options = options[4:]
+		if code == pcapngOptionEnd || length > len(options) {
+			break
+		}
+		value := options[:length]
+		switch {
+		case code == pcapngOptionIfName && length > 0:
+			res.name = string(value)
+		case code == pcapngOptionIfTsresol && length == 1:
+			exponent := uint(value[0] & 0x7f)
+			if value[0]&0x80 != 0 && exponent < 64 {
+				res.resolution = 1 << exponent
+			} else if value[0]&0x80 == 0 && exponent <= 19 {
+				res.resolution = 1
+				for ; exponent > 0; exponent-- {
+					res.resolution *= 10
+				}
+			} else {
+				return fmt.Errorf("Unsupported pcapng timestamp resolution 0x%02x", value[0])
+			}
+		}
+		if padded := (length + 3) &^ 3; padded < len(options) {
+			options = options[padded:]
+		} else {
+			options = nil
+		}
+	}
+	p.pcapng.interfaces = append(p.pcapng.interfaces, res)
+	return nil
+}
+
+// readPcapngPacket returns the record of an Enhanced Packet Block, or
+// false if it is not a SocketCAN frame.
+func (p *Player) readPcapngPacket(body []byte) (Record, bool, error) {
+	order := p.pcapng.order
+	if len(body) < 20 {
+		return Record{}, false, fmt.Errorf("Invalid pcapng enhanced packet")
+	}
+	index := int(order.Uint32(body[0:]))
+	if index >= len(p.pcapng.interfaces) {
+		return Record{}, false, fmt.Errorf("Undeclared interface %d in pcapng capture", index)
+	}
+	itf := p.pcapng.interfaces[index]
+	if itf.linkType != linkTypeCANSocketCAN {
+		return Record{}, false, nil
+	}
+	ts := uint64(order.Uint32(body[4:]))<<32 | uint64(order.Uint32(body[8:]))
+	captured := int(order.Uint32(body[12:]))
+	data := body[20:]
+	if captured > len(data) || captured < socketCANFrameHeaderSize {
+		return Record{}, false, fmt.Errorf("Invalid pcapng SocketCAN packet length %d", captured)
+	}
+	data = data[:captured]
+
+	seconds, remainder := ts/itf.resolution, ts%itf.resolution
+	hi, lo := bits.Mul64(remainder, uint64(time.Second))
+	nanoseconds, _ := bits.Div64(hi, lo, itf.resolution)
+	res := Record{
+		Time:      time.Unix(int64(seconds), int64(nanoseconds)),
+		Interface: itf.name,
+	}
+
+	// the SocketCAN header is always in network byte order
+	ID := binary.BigEndian.Uint32(data[0:])
+	res.Frame.Extended = ID&captureEFFFlag != 0
+	res.Frame.RTR = ID&captureRTRFlag != 0
+	if res.Frame.Extended == true {
+		res.Frame.ID = ID & captureEFFMask
+	} else {
+		res.Frame.ID = ID & captureSFFMask
+	}
+	res.Frame.Dlc = data[4]
+	if res.Frame.Dlc > 8 {
+		return Record{}, false, fmt.Errorf("Invalid Dlc %d in pcapng capture", res.Frame.Dlc)
+	}
+	if res.Frame.RTR == false {
+		if int(res.Frame.Dlc) > len(data)-socketCANFrameHeaderSize {
+			return Record{}, false, fmt.Errorf("Truncated SocketCAN frame in pcapng capture")
+		}
+		res.Frame.Data = append([]byte{}, data[socketCANFrameHeaderSize:socketCANFrameHeaderSize+int(res.Frame.Dlc)]...)
+	}
+	return res, true, nil
+}
+
+func (p *Player) nextPcapng() (Record, error) {
+	for {
+		t, body, err := p.readPcapngBlock()
+		if err != nil {
+			return Record{}, err
+		}
+		switch t {
+		case pcapngSectionHeader:
+			if len(body) < 16 || p.pcapng.order.Uint16(body[4:]) != 1 {
+				return Record{}, fmt.Errorf("Unsupported pcapng version")
+			}
+		case pcapngInterfaceDescription:
+			if err := p.readPcapngInterface(body); err != nil {
+				return Record{}, err
+			}
+		case pcapngEnhancedPacket:
+			record, ok, err := p.readPcapngPacket(body)
+			if err != nil || ok == true {
+				return record, err
+			}
+		}
+	}
+}
@@ -2,20 +2,19 @@ package arke
 
 import "fmt"
 
-type PDConfig struct {
-	ProportionnalMultiplier uint8
-	DerivativeMultiplier    uint8
-	IntegralMultiplier      uint8
-	DividerPower            uint8
-	DividerPowerIntegral    uint8
+// pdConfigFields are the fields of a PDConfig which may be out of
+// range, relative to its payload.
+var pdConfigFields = []FieldInfo{
+	uintField("DividerPower", 24, 4),
+	uintField("DividerPowerIntegral", 28, 4),
 }
 
 func (c PDConfig) marshall(buffer []byte) error {
-	if c.DividerPower > 15 {
-		return fmt.Errorf("Maximal Proportional&Derivative Divider is 15")
+	if err := checkRange(&pdConfigFields[0], float64(c.DividerPower)); err != nil {
+		return err
 	}
-	if c.DividerPowerIntegral > 15 {
-		return fmt.Errorf("Maximal Integral Divider is 15")
+	if err := checkRange(&pdConfigFields[1], float64(c.DividerPowerIntegral)); err != nil {
+		return err
 	}
 
 	buffer[0] = c.ProportionnalMultiplier
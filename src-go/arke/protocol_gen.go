@@ -0,0 +1,457 @@
+// Code generated by arkegen from specs/protocol.json. DO NOT EDIT.
+
+package arke
+
+import "time"
+
+const (
+	BroadcastClass NodeClass = 0x00
+	ZeusClass      NodeClass = 0x38
+	HeliosClass    NodeClass = 0x34
+	CelaenoClass   NodeClass = 0x30
+	NotusClass     NodeClass = 0x2c
+)
+
+const (
+	ResetRequest           MessageClass = 0x00
+	SynchronisationRequest MessageClass = 0x01
+	IDChangeRequest        MessageClass = 0x02
+	ErrorReport            MessageClass = 0x03
+	HeartBeatRequest       MessageClass = 0x07
+)
+
+const (
+	ResetRequestMessage           MessageClass = MessageClass(0x7f8 | ResetRequest)
+	SynchronisationRequestMessage MessageClass = MessageClass(0x7f8 | SynchronisationRequest)
+	IDChangeRequestMessage        MessageClass = MessageClass(0x7f8 | IDChangeRequest)
+	ErrorReportMessage            MessageClass = MessageClass(0x7f8 | ErrorReport)
+	HeartBeatRequestMessage       MessageClass = MessageClass(0x7f8 | HeartBeatRequest)
+	ZeusSetPointMessage           MessageClass = 0x38
+	ZeusReportMessage             MessageClass = 0x39
+	ZeusVibrationReportMessage    MessageClass = 0x3a
+	ZeusConfigMessage             MessageClass = 0x3b
+	ZeusStatusMessage             MessageClass = 0x3c
+	ZeusControlPointMessage       MessageClass = 0x3d
+	ZeusDeltaTemperatureMessage   MessageClass = 0x3e
+	HeliosSetPointMessage         MessageClass = 0x34
+	HeliosPulseModeMessage        MessageClass = 0x35
+	HeliosTriggerModeMessage      MessageClass = 0x36
+	CelaenoSetPointMessage        MessageClass = 0x30
+	CelaenoStatusMessage          MessageClass = 0x31
+	CelaenoConfigMessage          MessageClass = 0x32
+	NotusSetPointMessage          MessageClass = 0x2c
+	NotusConfigMessage            MessageClass = 0x2d
+)
+
+type PDConfig struct {
+	ProportionnalMultiplier uint8
+	DerivativeMultiplier    uint8
+	IntegralMultiplier      uint8
+	DividerPower            uint8
+	DividerPowerIntegral    uint8
+}
+
+// ZeusSetPoint is the payload of Zeus.SetPoint messages (0x38).
+type ZeusSetPoint struct {
+	Humidity    float32 `positional-arg-name:"humidity" required:"yes"`
+	Temperature float32 `positional-arg-name:"temperature" required:"yes"`
+	Wind        uint8   `positional-arg-name:"wind" required:"yes"`
+}
+
+func (m *ZeusSetPoint) MessageClassID() MessageClass {
+	return ZeusSetPointMessage
+}
+
+func (m ZeusSetPoint) Fields() []FieldInfo {
+	return []FieldInfo{
+		field("Humidity", "%", 0, 16, false, 0.0061042607740202665, 0).limit(0, 100),
+		field("Temperature", "°C", 16, 16, false, 0.010072030277133439, -40).limit(-40, 125),
+		uintField("Wind", 32, 8),
+	}
+}
+
+var zeusSetPointFields = ZeusSetPoint{}.Fields()
+
+func (m ZeusSetPoint) Validate() error {
+	if err := checkRange(&zeusSetPointFields[0], float64(m.Humidity)); err != nil {
+		return err
+	}
+	return checkRange(&zeusSetPointFields[1], float64(m.Temperature))
+}
+
+// ZeusReport is the payload of Zeus.Report messages (0x39).
+type ZeusReport struct {
+	Humidity    float32
+	Temperature [4]float32
+}
+
+func (m *ZeusReport) MessageClassID() MessageClass {
+	return ZeusReportMessage
+}
+
+func (m ZeusReport) Fields() []FieldInfo {
+	return []FieldInfo{
+		field("Humidity", "%", 0, 14, false, 0.0061042607740202665, 0).limit(0, 100),
+		field("Temperature[0]", "°C", 14, 14, false, 0.010072030277133439, -40).limit(-40, 125),
+		field("Temperature[1]", "°C", 28, 12, true, 0.0625, 0),
+		field("Temperature[2]", "°C", 40, 12, true, 0.0625, 0),
+		field("Temperature[3]", "°C", 52, 12, true, 0.0625, 0),
+	}
+}
+
+var zeusReportFields = ZeusReport{}.Fields()
+
+func (m ZeusReport) Validate() error {
+	if err := checkRange(&zeusReportFields[0], float64(m.Humidity)); err != nil {
+		return err
+	}
+	if err := checkRange(&zeusReportFields[1], float64(m.Temperature[0])); err != nil {
+		return err
+	}
+	if err := checkRange(&zeusReportFields[2], float64(m.Temperature[1])); err != nil {
+		return err
+	}
+	if err := checkRange(&zeusReportFields[3], float64(m.Temperature[2])); err != nil {
+		return err
+	}
+	return checkRange(&zeusReportFields[4], float64(m.Temperature[3]))
+}
+
+// ZeusConfig is the payload of Zeus.Config messages (0x3b).
+type ZeusConfig struct {
+	Humidity    PDConfig
+	Temperature PDConfig
+}
+
+func (m *ZeusConfig) MessageClassID() MessageClass {
+	return ZeusConfigMessage
+}
+
+func (m ZeusConfig) Fields() []FieldInfo {
+	return []FieldInfo{
+		uintField("Humidity.ProportionnalMultiplier", 0, 8),
+		uintField("Humidity.DerivativeMultiplier", 8, 8),
+		uintField("Humidity.IntegralMultiplier", 16, 8),
+		uintField("Humidity.DividerPower", 24, 4),
+		uintField("Humidity.DividerPowerIntegral", 28, 4),
+		uintField("Temperature.ProportionnalMultiplier", 32, 8),
+		uintField("Temperature.DerivativeMultiplier", 40, 8),
+		uintField("Temperature.IntegralMultiplier", 48, 8),
+		uintField("Temperature.DividerPower", 56, 4),
+		uintField("Temperature.DividerPowerIntegral", 60, 4),
+	}
+}
+
+var zeusConfigFields = ZeusConfig{}.Fields()
+
+func (m ZeusConfig) Validate() error {
+	if err := checkRange(&zeusConfigFields[3], float64(m.Humidity.DividerPower)); err != nil {
+		return err
+	}
+	if err := checkRange(&zeusConfigFields[4], float64(m.Humidity.DividerPowerIntegral)); err != nil {
+		return err
+	}
+	if err := checkRange(&zeusConfigFields[8], float64(m.Temperature.DividerPower)); err != nil {
+		return err
+	}
+	return checkRange(&zeusConfigFields[9], float64(m.Temperature.DividerPowerIntegral))
+}
+
+// ZeusStatus is the payload of Zeus.Status messages (0x3c).
+type ZeusStatus struct {
+	Status ZeusStatusValue
+	Fans   [3]FanStatusAndRPM
+}
+
+func (m *ZeusStatus) MessageClassID() MessageClass {
+	return ZeusStatusMessage
+}
+
+func (m ZeusStatus) Fields() []FieldInfo {
+	return []FieldInfo{
+		uintField("Status", 0, 8),
+		uintField("Fans[0]", 8, 16),
+		uintField("Fans[1]", 24, 16),
+		uintField("Fans[2]", 40, 16),
+	}
+}
+
+func (m ZeusStatus) Validate() error {
+	// every value of the fields is valid
+	return nil
+}
+
+// ZeusControlPoint is the payload of Zeus.ControlPoint messages (0x3d).
+type ZeusControlPoint struct {
+	Humidity    int16
+	Temperature int16
+}
+
+func (m *ZeusControlPoint) MessageClassID() MessageClass {
+	return ZeusControlPointMessage
+}
+
+func (m ZeusControlPoint) Fields() []FieldInfo {
+	return []FieldInfo{
+		field("Humidity", "", 0, 16, true, 1, 0),
+		field("Temperature", "", 16, 16, true, 1, 0),
+	}
+}
+
+func (m ZeusControlPoint) Validate() error {
+	// every value of the fields is valid
+	return nil
+}
+
+// ZeusDeltaTemperature is the payload of Zeus.DeltaTemperature messages (0x3e).
+type ZeusDeltaTemperature struct {
+	Delta [4]float32
+}
+
+func (m *ZeusDeltaTemperature) MessageClassID() MessageClass {
+	return ZeusDeltaTemperatureMessage
+}
+
+func (m ZeusDeltaTemperature) Fields() []FieldInfo {
+	return []FieldInfo{
+		field("Delta[0]", "°C", 0, 16, true, 0.010072030277133439, 0),
+		field("Delta[1]", "°C", 16, 16, true, 0.0625, 0),
+		field("Delta[2]", "°C", 32, 16, true, 0.0625, 0),
+		field("Delta[3]", "°C", 48, 16, true, 0.0625, 0),
+	}
+}
+
+var zeusDeltaTemperatureFields = ZeusDeltaTemperature{}.Fields()
+
+func (m ZeusDeltaTemperature) Validate() error {
+	if err := checkRange(&zeusDeltaTemperatureFields[0], float64(m.Delta[0])); err != nil {
+		return err
+	}
+	if err := checkRange(&zeusDeltaTemperatureFields[1], float64(m.Delta[1])); err != nil {
+		return err
+	}
+	if err := checkRange(&zeusDeltaTemperatureFields[2], float64(m.Delta[2])); err != nil {
+		return err
+	}
+	return checkRange(&zeusDeltaTemperatureFields[3], float64(m.Delta[3]))
+}
+
+// HeliosSetPoint is the payload of Helios.SetPoint messages (0x34).
+type HeliosSetPoint struct {
+	Visible uint8 `positional-arg-name:"visible" required:"yes"`
+	UV      uint8 `positional-arg-name:"UV" required:"yes"`
+}
+
+func (m *HeliosSetPoint) MessageClassID() MessageClass {
+	return HeliosSetPointMessage
+}
+
+func (m HeliosSetPoint) Fields() []FieldInfo {
+	return []FieldInfo{
+		uintField("Visible", 0, 8),
+		uintField("UV", 8, 8),
+	}
+}
+
+func (m HeliosSetPoint) Validate() error {
+	// every value of the fields is valid
+	return nil
+}
+
+// HeliosPulseMode is the payload of Helios.PulseMode messages (0x35).
+type HeliosPulseMode struct {
+	Period time.Duration `positional-arg-name:"period" required:"yes"`
+}
+
+func (m *HeliosPulseMode) MessageClassID() MessageClass {
+	return HeliosPulseModeMessage
+}
+
+func (m HeliosPulseMode) Fields() []FieldInfo {
+	return []FieldInfo{
+		field("Period", "ms", 0, 16, false, 1, 0),
+	}
+}
+
+var heliosPulseModeFields = HeliosPulseMode{}.Fields()
+
+func (m HeliosPulseMode) Validate() error {
+	return checkRange(&heliosPulseModeFields[0], float64(m.Period)/float64(time.Millisecond))
+}
+
+// HeliosTriggerMode is the payload of Helios.TriggerMode messages (0x36).
+type HeliosTriggerMode struct {
+	Period      time.Duration `positional-arg-name:"period" required:"yes"`
+	PulseLength time.Duration `positional-arg-name:"length" required:"yes"`
+	CameraDelay time.Duration `positional-arg-name:"delay" required:"no" default:"0s"`
+}
+
+func (m *HeliosTriggerMode) MessageClassID() MessageClass {
+	return HeliosTriggerModeMessage
+}
+
+func (m HeliosTriggerMode) Fields() []FieldInfo {
+	return []FieldInfo{
+		field("Period", "µs", 0, 16, false, 100, 0),
+		field("PulseLength", "µs", 16, 16, false, 1, 0).limit(0, 3500),
+		field("CameraDelay", "µs", 32, 16, true, 1, 0),
+	}
+}
+
+var heliosTriggerModeFields = HeliosTriggerMode{}.Fields()
+
+func (m HeliosTriggerMode) Validate() error {
+	if err := checkRange(&heliosTriggerModeFields[0], float64(m.Period)/float64(time.Microsecond)); err != nil {
+		return err
+	}
+	if err := checkRange(&heliosTriggerModeFields[1], float64(m.PulseLength)/float64(time.Microsecond)); err != nil {
+		return err
+	}
+	return checkRange(&heliosTriggerModeFields[2], float64(m.CameraDelay)/float64(time.Microsecond))
+}
+
+// CelaenoSetPoint is the payload of Celaeno.SetPoint messages (0x30).
+type CelaenoSetPoint struct {
+	Power uint8 `positional-arg-name:"power" required:"yes"`
+}
+
+func (m *CelaenoSetPoint) MessageClassID() MessageClass {
+	return CelaenoSetPointMessage
+}
+
+func (m CelaenoSetPoint) Fields() []FieldInfo {
+	return []FieldInfo{
+		uintField("Power", 0, 8),
+	}
+}
+
+func (m CelaenoSetPoint) Validate() error {
+	// every value of the fields is valid
+	return nil
+}
+
+// CelaenoStatus is the payload of Celaeno.Status messages (0x31).
+type CelaenoStatus struct {
+	WaterLevel WaterLevelStatus
+	Fan        FanStatusAndRPM
+}
+
+func (m *CelaenoStatus) MessageClassID() MessageClass {
+	return CelaenoStatusMessage
+}
+
+func (m CelaenoStatus) Fields() []FieldInfo {
+	return []FieldInfo{
+		uintField("WaterLevel", 0, 8),
+		uintField("Fan", 8, 16),
+	}
+}
+
+func (m CelaenoStatus) Validate() error {
+	// every value of the fields is valid
+	return nil
+}
+
+// CelaenoConfig is the payload of Celaeno.Config messages (0x32).
+type CelaenoConfig struct {
+	RampUpTime    time.Duration `positional-arg-name:"ramp_up" required:"yes"`
+	RampDownTime  time.Duration `positional-arg-name:"ramp_down" required:"yes"`
+	MinimumOnTime time.Duration `positional-arg-name:"minimum_on" required:"yes"`
+	DebounceTime  time.Duration `positional-arg-name:"debounce_time" required:"yes"`
+}
+
+func (m *CelaenoConfig) MessageClassID() MessageClass {
+	return CelaenoConfigMessage
+}
+
+func (m CelaenoConfig) Fields() []FieldInfo {
+	return []FieldInfo{
+		field("RampUpTime", "ms", 0, 16, false, 1, 0),
+		field("RampDownTime", "ms", 16, 16, false, 1, 0),
+		field("MinimumOnTime", "ms", 32, 16, false, 1, 0),
+		field("DebounceTime", "ms", 48, 16, false, 1, 0),
+	}
+}
+
+var celaenoConfigFields = CelaenoConfig{}.Fields()
+
+func (m CelaenoConfig) Validate() error {
+	if err := checkRange(&celaenoConfigFields[0], float64(m.RampUpTime)/float64(time.Millisecond)); err != nil {
+		return err
+	}
+	if err := checkRange(&celaenoConfigFields[1], float64(m.RampDownTime)/float64(time.Millisecond)); err != nil {
+		return err
+	}
+	if err := checkRange(&celaenoConfigFields[2], float64(m.MinimumOnTime)/float64(time.Millisecond)); err != nil {
+		return err
+	}
+	return checkRange(&celaenoConfigFields[3], float64(m.DebounceTime)/float64(time.Millisecond))
+}
+
+// NotusSetPoint is the payload of Notus.SetPoint messages (0x2c).
+type NotusSetPoint struct {
+	Power uint8
+}
+
+func (m *NotusSetPoint) MessageClassID() MessageClass {
+	return NotusSetPointMessage
+}
+
+func (m NotusSetPoint) Fields() []FieldInfo {
+	return []FieldInfo{
+		uintField("Power", 0, 8),
+	}
+}
+
+func (m NotusSetPoint) Validate() error {
+	// every value of the fields is valid
+	return nil
+}
+
+// NotusConfig is the payload of Notus.Config messages (0x2d).
+type NotusConfig struct {
+	RampDownTime time.Duration `long:"ramp-down" description:"time to keep fan off on poweroff" default:"2s"`
+	MinFan       uint8         `long:"min-fan" description:"minimum fan power (0-255)" default:"50"`
+	MaxHeat      uint8         `long:"max-fan" description:"maximum heat power (0-255)" default:"200"`
+}
+
+func (m *NotusConfig) MessageClassID() MessageClass {
+	return NotusConfigMessage
+}
+
+func (m NotusConfig) Fields() []FieldInfo {
+	return []FieldInfo{
+		field("RampDownTime", "ms", 0, 16, false, 1, 0),
+		uintField("MinFan", 16, 8),
+		uintField("MaxHeat", 24, 8),
+	}
+}
+
+var notusConfigFields = NotusConfig{}.Fields()
+
+func (m NotusConfig) Validate() error {
+	return checkRange(&notusConfigFields[0], float64(m.RampDownTime)/float64(time.Millisecond))
+}
+
+func init() {
+	mustRegisterNodeClass(BroadcastClass, "Broadcast")
+	mustRegisterNodeClass(ZeusClass, "Zeus")
+	mustRegisterNodeClass(HeliosClass, "Helios")
+	mustRegisterNodeClass(CelaenoClass, "Celaeno")
+	mustRegisterNodeClass(NotusClass, "Notus")
+	mustRegisterMessage(ZeusSetPointMessage, "Zeus.SetPoint", func() Message { return &ZeusSetPoint{} })
+	mustRegisterMessage(ZeusReportMessage, "Zeus.Report", func() Message { return &ZeusReport{} })
+	mustRegisterMessage(ZeusVibrationReportMessage, "Zeus.VibrationReport", nil)
+	mustRegisterMessage(ZeusConfigMessage, "Zeus.Config", func() Message { return &ZeusConfig{} })
+	mustRegisterMessage(ZeusStatusMessage, "Zeus.Status", func() Message { return &ZeusStatus{} })
+	mustRegisterMessage(ZeusControlPointMessage, "Zeus.ControlPoint", func() Message { return &ZeusControlPoint{} })
+	mustRegisterMessage(ZeusDeltaTemperatureMessage, "Zeus.DeltaTemperature", func() Message { return &ZeusDeltaTemperature{} })
+	mustRegisterMessage(HeliosSetPointMessage, "Helios.SetPoint", func() Message { return &HeliosSetPoint{} })
+	mustRegisterMessage(HeliosPulseModeMessage, "Helios.PulseMode", func() Message { return &HeliosPulseMode{} })
+	mustRegisterMessage(HeliosTriggerModeMessage, "Helios.TriggerMode", func() Message { return &HeliosTriggerMode{} })
+	mustRegisterMessage(CelaenoSetPointMessage, "Celaeno.SetPoint", func() Message { return &CelaenoSetPoint{} })
+	mustRegisterMessage(CelaenoStatusMessage, "Celaeno.Status", func() Message { return &CelaenoStatus{} })
+	mustRegisterMessage(CelaenoConfigMessage, "Celaeno.Config", func() Message { return &CelaenoConfig{} })
+	mustRegisterMessage(NotusSetPointMessage, "Notus.SetPoint", func() Message { return &NotusSetPoint{} })
+	mustRegisterMessage(NotusConfigMessage, "Notus.Config", func() Message { return &NotusConfig{} })
+}
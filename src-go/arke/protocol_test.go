@@ -0,0 +1,38 @@
+package arke
+
+import (
+	"github.com/formicidae-tracker/libarke/src-go/arke/internal/protocol"
+	. "gopkg.in/check.v1"
+)
+
+type ProtocolSuite struct{}
+
+var _ = Suite(&ProtocolSuite{})
+
+func (s *ProtocolSuite) TestGeneratedFilesAreUpToDate(c *C) {
+	c.Check(protocol.Check("../.."), IsNil)
+}
+
+func (s *ProtocolSuite) TestCodecsMatchDefinition(c *C) {
+	p, err := protocol.Load("../..")
+	c.Assert(err, IsNil)
+	for _, def := range p.Messages {
+		mc := MessageClass(def.Class)
+		c.Check(mc.String(), Equals, def.Name)
+		m, err := NewMessage(mc)
+		if def.Undefined == true {
+			c.Check(err, ErrorMatches, "Unknown message class.*")
+			continue
+		}
+		if c.Check(err, IsNil, Commentf("%s", def.Name)) == false {
+			continue
+		}
+		size := p.Size(def)
+		buf := make([]byte, 8)
+		n, err := m.Marshal(buf)
+		c.Check(err, IsNil, Commentf("%s", def.Name))
+		c.Check(n, Equals, size, Commentf("%s", def.Name))
+		c.Check(m.Unmarshal(buf[:size]), IsNil, Commentf("%s", def.Name))
+		c.Check(m.Unmarshal(buf[:size-1]), ErrorMatches, "Invalid buffer size.*", Commentf("%s", def.Name))
+	}
+}
@@ -0,0 +1,57 @@
+package arke
+
+import (
+	"fmt"
+)
+
+// RawMessage holds a frame that could not be decoded, either because
+// its message class or network command is unknown, or because its
+// payload is invalid. It can be marshalled back to the same frame.
+type RawMessage struct {
+	Type  MessageType
+	Class MessageClass
+	ID    NodeID
+	Data  []byte
+}
+
+func newRawMessage(t MessageType, c MessageClass, ID NodeID, data []byte) *RawMessage {
+	res := &RawMessage{Type: t, Class: c, ID: ID}
+	res.Unmarshal(data)
+	return res
+}
+
+func (m *RawMessage) MessageClassID() MessageClass {
+	switch m.Type {
+	case NetworkControlCommand:
+		return MessageClass(0x7f8) | MessageClass(m.ID)
+	case HeartBeat:
+		return HeartBeatMessage
+	}
+	return m.Class
+}
+
+// IDT returns the CAN IDT of the original frame.
+func (m *RawMessage) IDT() uint32 {
+	return MakeCANIDT(m.Type, m.Class, m.ID)
+}
+
+func (m *RawMessage) Marshal(buf []byte) (int, error) {
+	if err := checkSize(buf, len(m.Data)); err != nil {
+		return 0, err
+	}
+	return copy(buf, m.Data), nil
+}
+
+func (m *RawMessage) Unmarshal(buf []byte) error {
+	m.Data = append([]byte{}, buf...)
+	return nil
+}
+
+func (m *RawMessage) String() string {
+	name := ""
+	if m.Type == StandardMessage || m.Type == HighPriorityMessage {
+		name = " (" + m.Class.String() + ")"
+	}
+	return fmt.Sprintf("arke.RawMessage{Type: %s, Class: 0x%02x%s, ID: %d, Data: [% x]}",
+		m.Type, int(m.Class), name, m.ID, m.Data)
+}
@@ -0,0 +1,40 @@
+package arke
+
+import (
+	socketcan "github.com/atuleu/golang-socketcan"
+	. "gopkg.in/check.v1"
+)
+
+type RawMessageSuite struct{}
+
+var _ = Suite(&RawMessageSuite{})
+
+func (s *RawMessageSuite) TestForwarding(c *C) {
+	frames := []socketcan.CanFrame{
+		{ID: MakeCANIDT(HighPriorityMessage, ZeusVibrationReportMessage, 3), Dlc: 4, Data: []byte{1, 2, 3, 4, 0, 0, 0, 0}},
+		{ID: MakeCANIDT(StandardMessage, 0x12, 1), Dlc: 0, Data: make([]byte, 8)},
+		{ID: MakeCANIDT(NetworkControlCommand, MessageClass(HeliosClass), 5), Dlc: 1, Data: []byte{0xff, 0, 0, 0, 0, 0, 0, 0}},
+	}
+
+	for _, f := range frames {
+		m, _, err := ParseMessage(&f)
+		c.Assert(err, IsNil)
+		raw, ok := m.(*RawMessage)
+		c.Assert(ok, Equals, true)
+		c.Check(raw.IDT(), Equals, f.ID)
+
+		buf := make([]byte, 8)
+		n, err := raw.Marshal(buf)
+		c.Check(err, IsNil)
+		c.Check(n, Equals, int(f.Dlc))
+		c.Check(buf, DeepEquals, f.Data)
+	}
+
+	raw := &RawMessage{Data: []byte{1, 2}}
+	_, err := raw.Marshal(make([]byte, 1))
+	c.Check(err, ErrorMatches, "Invalid buffer size 1, required: 2")
+
+	c.Check((&RawMessage{Type: NetworkControlCommand, ID: 5}).MessageClassID(), Equals, MessageClass(0x7fd))
+	c.Check((&RawMessage{Type: HeartBeat, Class: 0x12}).MessageClassID(), Equals, HeartBeatMessage)
+	c.Check((&RawMessage{Type: StandardMessage, Class: 0x12}).MessageClassID(), Equals, MessageClass(0x12))
+}
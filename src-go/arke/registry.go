@@ -0,0 +1,163 @@
+package arke
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type messageCreator func() Message
+
+type networkCommandParser func(c MessageClass, buffer []byte, s *messageSet) (ReceivableMessage, NodeID, error)
+
+// registryMx protects all the following maps, that can be modified at
+// runtime through RegisterMessage and RegisterNodeClass.
+var registryMx sync.RWMutex
+
+var messageFactory = make(map[MessageClass]messageCreator)
+
+var messagesName = make(map[MessageClass]string)
+
+var networkCommandFactory = make(map[NodeID]networkCommandParser)
+
+var nameByClass = make(map[NodeClass]string)
+
+var classByName = make(map[string]NodeClass)
+
+const maxClass = 0x3f
+
+// RegisterMessage registers a new message class, to be parsed by
+// ParseMessage and named by MessageClass.String(). creator may be nil
+// to only name a message class without decoding it. It fails if the
+// class or the name is already in use.
+func RegisterMessage(c MessageClass, name string, creator func() Message) error {
+	if c > maxClass {
+		return fmt.Errorf("Invalid message class 0x%02x (max is 0x%02x)", int(c), maxClass)
+	}
+	if len(name) == 0 {
+		return fmt.Errorf("Message class 0x%02x needs a name", int(c))
+	}
+	registryMx.Lock()
+	defer registryMx.Unlock()
+
+	if existing, ok := messagesName[c]; ok == true {
+		return fmt.Errorf("Message class 0x%02x is already registered as '%s'", int(c), existing)
+	}
+	for other, existing := range messagesName {
+		if existing == name {
+			return fmt.Errorf("Message name '%s' is already used by class 0x%02x", name, int(other))
+		}
+	}
+
+	messagesName[c] = name
+	if creator != nil {
+		messageFactory[c] = creator
+	}
+	return nil
+}
+
+// RegisterNodeClass registers a new node class name, to be used by
+// ClassName and Class. It fails if the class or the case-insensitive
+// name is already in use.
+func RegisterNodeClass(c NodeClass, name string) error {
+	if c > maxClass {
+		return fmt.Errorf("Invalid node class 0x%02x (max is 0x%02x)", int(c), maxClass)
+	}
+	if len(name) == 0 {
+		return fmt.Errorf("Node class 0x%02x needs a name", int(c))
+	}
+	registryMx.Lock()
+	defer registryMx.Unlock()
+
+	if existing, ok := nameByClass[c]; ok == true {
+		return fmt.Errorf("Node class 0x%02x is already registered as '%s'", int(c), existing)
+	}
+	if other, ok := classByName[strings.ToLower(name)]; ok == true {
+		return fmt.Errorf("Node class name '%s' is already used by class 0x%02x", name, int(other))
+	}
+	nameByClass[c] = name
+	classByName[strings.ToLower(name)] = c
+	return nil
+}
+
+func mustRegisterMessage(c MessageClass, name string, creator func() Message) {
+	if err := RegisterMessage(c, name, creator); err != nil {
+		panic(err.Error())
+	}
+}
+
+func mustRegisterNodeClass(c NodeClass, name string) {
+	if err := RegisterNodeClass(c, name); err != nil {
+		panic(err.Error())
+	}
+}
+
+func registerNetworkCommand(command MessageClass, parser networkCommandParser) {
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	networkCommandFactory[NodeID(command)] = parser
+}
+
+func lookupMessage(c MessageClass) (messageCreator, bool) {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	creator, ok := messageFactory[c]
+	return creator, ok
+}
+
+func lookupNetworkCommand(command NodeID) (networkCommandParser, bool) {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	parser, ok := networkCommandFactory[command]
+	return parser, ok
+}
+
+// NewMessage returns a new zero message for a registered message
+// class, or ErrUnknownMessageClass if no decoder is registered for c.
+func NewMessage(c MessageClass) (Message, error) {
+	creator, ok := lookupMessage(c)
+	if ok == false {
+		return nil, fmt.Errorf("%w 0x%02x", ErrUnknownMessageClass, int(c))
+	}
+	return creator(), nil
+}
+
+// MessageClasses returns all the named message classes, in increasing
+// order.
+func MessageClasses() []MessageClass {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	res := make([]MessageClass, 0, len(messagesName))
+	for c := range messagesName {
+		res = append(res, c)
+	}
+	slices.Sort(res)
+	return res
+}
+
+// networkCommands returns the codes of all network commands, in
+// increasing order.
+func networkCommands() []MessageClass {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	res := make([]MessageClass, 0, len(networkCommandFactory))
+	for c := range networkCommandFactory {
+		res = append(res, MessageClass(c))
+	}
+	slices.Sort(res)
+	return res
+}
+
+// NodeClasses returns all the registered node classes, in increasing
+// order.
+func NodeClasses() []NodeClass {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	res := make([]NodeClass, 0, len(nameByClass))
+	for c := range nameByClass {
+		res = append(res, c)
+	}
+	slices.Sort(res)
+	return res
+}
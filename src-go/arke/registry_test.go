@@ -0,0 +1,138 @@
+package arke
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+	. "gopkg.in/check.v1"
+)
+
+type RegistrySuite struct{}
+
+var _ = Suite(&RegistrySuite{})
+
+const (
+	testNodeClass      NodeClass    = 0x10
+	testMessageClass   MessageClass = 0x10
+	testNameOnlyClass  MessageClass = 0x11
+	testNodeClassName               = "Hermes"
+	testMessageName                 = "Hermes.Ping"
+	testNameOnlyString              = "Hermes.Pong"
+)
+
+type hermesPing struct {
+	Value uint8
+}
+
+func (m *hermesPing) MessageClassID() MessageClass { return testMessageClass }
+
+func (m *hermesPing) String() string { return fmt.Sprintf("Hermes.Ping{Value: %d}", m.Value) }
+
+func (m *hermesPing) Marshal(buf []byte) (int, error) {
+	if err := checkSize(buf, 1); err != nil {
+		return 0, err
+	}
+	buf[0] = m.Value
+	return 1, nil
+}
+
+func (m *hermesPing) Unmarshal(buf []byte) error {
+	if err := checkSize(buf, 1); err != nil {
+		return err
+	}
+	m.Value = buf[0]
+	return nil
+}
+
+func (s *RegistrySuite) TearDownTest(c *C) {
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	for _, mc := range []MessageClass{testMessageClass, testNameOnlyClass} {
+		delete(messageFactory, mc)
+		delete(messagesName, mc)
+	}
+	delete(nameByClass, testNodeClass)
+	delete(classByName, strings.ToLower(testNodeClassName))
+}
+
+func (s *RegistrySuite) TestRegistration(c *C) {
+	c.Assert(RegisterNodeClass(testNodeClass, testNodeClassName), IsNil)
+	c.Assert(RegisterMessage(testMessageClass, testMessageName, func() Message { return &hermesPing{} }), IsNil)
+	c.Assert(RegisterMessage(testNameOnlyClass, testNameOnlyString, nil), IsNil)
+
+	c.Check(ClassName(testNodeClass), Equals, "Hermes")
+	cls, err := Class("hermes")
+	c.Check(err, IsNil)
+	c.Check(cls, Equals, testNodeClass)
+	c.Check(testMessageClass.String(), Equals, "Hermes.Ping")
+	c.Check(testNameOnlyClass.String(), Equals, "Hermes.Pong")
+	c.Check(testNameOnlyClass.NodeClass(), Equals, testNodeClass)
+	c.Check(NodeClasses(), DeepEquals, []NodeClass{BroadcastClass, testNodeClass, NotusClass, CelaenoClass, HeliosClass, ZeusClass})
+	c.Check(MessageClasses()[0:2], DeepEquals, []MessageClass{testMessageClass, testNameOnlyClass})
+
+	f := socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, testMessageClass, 2), Dlc: 1, Data: []byte{42}}
+	m, ID, err := ParseMessage(&f)
+	c.Check(err, IsNil)
+	c.Check(ID, Equals, NodeID(2))
+	c.Check(m, DeepEquals, &hermesPing{Value: 42})
+
+	f = socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, testMessageClass, 2), RTR: true}
+	m, _, err = ParseMessage(&f)
+	c.Check(err, IsNil)
+	c.Check(m.String(), Equals, "arke.MessageRequest{Message:Hermes.Ping, Node: 2}")
+
+	f = socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, testNameOnlyClass, 2), Dlc: 1, Data: []byte{42}}
+	m, _, err = ParseMessage(&f)
+	c.Check(err, IsNil)
+	c.Check(m, DeepEquals, &RawMessage{StandardMessage, testNameOnlyClass, 2, []byte{42}})
+}
+
+func (s *RegistrySuite) TestConflicts(c *C) {
+	creator := func() Message { return &hermesPing{} }
+	testdata := []struct {
+		Err error
+		E   string
+	}{
+		{RegisterMessage(ZeusReportMessage, testMessageName, creator), "Message class 0x39 is already registered as 'Zeus.Report'"},
+		{RegisterMessage(ZeusVibrationReportMessage, testMessageName, creator), "Message class 0x3a is already registered as 'Zeus.VibrationReport'"},
+		{RegisterMessage(testMessageClass, "Zeus.Report", creator), "Message name 'Zeus.Report' is already used by class 0x39"},
+		{RegisterMessage(0x40, testMessageName, creator), "Invalid message class 0x40 \\(max is 0x3f\\)"},
+		{RegisterMessage(testMessageClass, "", creator), "Message class 0x10 needs a name"},
+		{RegisterNodeClass(ZeusClass, testNodeClassName), "Node class 0x38 is already registered as 'Zeus'"},
+		{RegisterNodeClass(testNodeClass, "zeus"), "Node class name 'zeus' is already used by class 0x38"},
+		{RegisterNodeClass(0x40, testNodeClassName), "Invalid node class 0x40 \\(max is 0x3f\\)"},
+		{RegisterNodeClass(testNodeClass, ""), "Node class 0x10 needs a name"},
+	}
+	for _, d := range testdata {
+		c.Check(d.Err, ErrorMatches, d.E)
+	}
+
+	c.Check(testMessageClass.String(), Equals, "<unknown>")
+	c.Check(ClassName(testNodeClass), Equals, "<unknown>")
+}
+
+func (s *RegistrySuite) TestConcurrentRegistration(c *C) {
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- RegisterMessage(testMessageClass, testMessageName, func() Message { return &hermesPing{} })
+			_ = testMessageClass.String()
+			f := socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, testMessageClass, 2), Dlc: 1, Data: []byte{42}}
+			ParseMessage(&f)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded += 1
+		}
+	}
+	c.Check(succeeded, Equals, 1)
+}
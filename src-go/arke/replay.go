@@ -0,0 +1,151 @@
+package arke
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+)
+
+// FrameNode returns the class and ID of the node a frame is about:
+// the emitter of standard messages and heartbeats, or the target or
+// emitter designated by the payload of network commands. Broadcast
+// network commands return BroadcastID.
+func FrameNode(f *socketcan.CanFrame) (NodeClass, NodeID) {
+	mType, mClass, mID := ExtractCANIDT(f.ID)
+	switch mType {
+	case StandardMessage, HighPriorityMessage:
+		return mClass.NodeClass(), mID
+	case HeartBeat:
+		return NodeClass(mClass), mID
+	}
+	m, ID, err := ParseMessage(f)
+	if err != nil {
+		return NodeClass(mClass), BroadcastID
+	}
+	if e, ok := m.(*ErrorReportData); ok == true {
+		return e.Class, e.ID
+	}
+	return NodeClass(mClass), ID
+}
+
+// FrameFilter selects frames by node class, node ID and message name,
+// as returned by FrameNode and MessageName. A frame matches if it
+// matches all non-empty lists. Frames that cannot be decoded have
+// the "arke.RawMessage" name.
+type FrameFilter struct {
+	Classes  []NodeClass
+	IDs      []NodeID
+	Messages []string
+}
+
+// Match returns true if f is selected by the filter.
+func (filter FrameFilter) Match(f *socketcan.CanFrame) bool {
+	if len(filter.Classes) > 0 || len(filter.IDs) > 0 {
+		c, ID := FrameNode(f)
+		if len(filter.Classes) > 0 && slices.Contains(filter.Classes, c) == false {
+			return false
+		}
+		if len(filter.IDs) > 0 && slices.Contains(filter.IDs, ID) == false {
+			return false
+		}
+	}
+	if len(filter.Messages) == 0 {
+		return true
+	}
+	name := "arke.RawMessage"
+	if m, _, err := ParseMessage(f); err == nil {
+		name, _ = MessageName(m)
+	}
+	return slices.Contains(filter.Messages, name)
+}
+
+// RewriteNodeIDs returns a copy of f where the node IDs found in ids
+// are replaced, both in the IDT of standard messages and heartbeats,
+// and in the payload of network commands.
+func RewriteNodeIDs(f socketcan.CanFrame, ids map[NodeID]NodeID) socketcan.CanFrame {
+	rewrite := func(ID NodeID) NodeID {
+		if n, ok := ids[ID]; ok == true {
+			return n
+		}
+		return ID
+	}
+	f.Data = slices.Clone(f.Data)
+	mType, mClass, mID := ExtractCANIDT(f.ID)
+	if mType != NetworkControlCommand {
+		f.ID = MakeCANIDT(mType, mClass, rewrite(mID))
+		return f
+	}
+	if f.RTR == true || int(f.Dlc) > len(f.Data) {
+		return f
+	}
+	rewriteByte := func(i int) {
+		if i < int(f.Dlc) {
+			f.Data[i] = byte(rewrite(NodeID(f.Data[i])))
+		}
+	}
+	switch MessageClass(mID) {
+	case ResetRequest:
+		rewriteByte(0)
+	case SynchronisationRequest:
+		if f.Dlc > 1 {
+			rewriteByte(1)
+		}
+	case IDChangeRequest:
+		rewriteByte(0)
+		rewriteByte(1)
+	case ErrorReport:
+		rewriteByte(1)
+	}
+	return f
+}
+
+// Replay sends the frames of a capture to s, reproducing the delays
+// between their records divided by speed. A zero speed sends frames
+// as fast as possible. transform, if not nil, is called on each record
+// and may modify it, or drop it by returning false. Replay returns the
+// number of frames sent, and stops without error at the end of the
+// capture.
+func Replay(ctx context.Context, p *Player, s FrameSender, speed float64, transform func(*Record) bool) (int, error) {
+	var start, first time.Time
+	sent := 0
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	for {
+		record, err := p.Next()
+		if errors.Is(err, io.EOF) == true {
+			return sent, nil
+		}
+		if err != nil {
+			return sent, err
+		}
+		if transform != nil && transform(&record) == false {
+			continue
+		}
+		if start.IsZero() == true {
+			start = time.Now()
+			first = record.Time
+		} else if speed > 0 {
+			offset := time.Duration(float64(record.Time.Sub(first)) / speed)
+			if wait := time.Until(start.Add(offset)); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-ctx.Done():
+					return sent, ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		if err := s.Send(record.Frame); err != nil {
+			return sent, err
+		}
+		sent += 1
+	}
+}
@@ -0,0 +1,160 @@
+package arke
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+	. "gopkg.in/check.v1"
+)
+
+type ReplaySuite struct{}
+
+var _ = Suite(&ReplaySuite{})
+
+func (s *ReplaySuite) TestFrameNode(c *C) {
+	testdata := []struct {
+		Frame socketcan.CanFrame
+		Class NodeClass
+		ID    NodeID
+	}{
+		{socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusReportMessage, 3), Dlc: 8, Data: make([]byte, 8)}, ZeusClass, 3},
+		{makeRequestFrame(HeliosSetPointMessage, 2), HeliosClass, 2},
+		{MakeHeartBeat(CelaenoClass, 4, FirmwareVersion{}), CelaenoClass, 4},
+		{MakeResetRequest(HeliosClass, 2), HeliosClass, 2},
+		{MakePing(ZeusClass), ZeusClass, BroadcastID},
+		{MakeSynchronisationReply(NotusClass, 5, 1, time.Second), NotusClass, 5},
+		{MakeIDChangeRequest(ZeusClass, 1, 2), ZeusClass, 1},
+		{MakeErrorReport(CelaenoClass, 6, 0x42), CelaenoClass, 6},
+		{socketcan.CanFrame{ID: MakeCANIDT(NetworkControlCommand, MessageClass(ZeusClass), NodeID(ResetRequest))}, ZeusClass, BroadcastID},
+	}
+	for _, d := range testdata {
+		class, ID := FrameNode(&d.Frame)
+		c.Check(class, Equals, d.Class, Commentf("frame %v", d.Frame))
+		c.Check(ID, Equals, d.ID, Commentf("frame %v", d.Frame))
+	}
+}
+
+func (s *ReplaySuite) TestFrameFilter(c *C) {
+	report := socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusReportMessage, 3), Dlc: 8, Data: make([]byte, 8)}
+	heartbeat := MakeHeartBeat(HeliosClass, 3, FirmwareVersion{})
+	reset := MakeResetRequest(ZeusClass, 1)
+	unknown := socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, 0x01, 1)}
+
+	testdata := []struct {
+		Filter  FrameFilter
+		Matches []bool
+	}{
+		{FrameFilter{}, []bool{true, true, true, true}},
+		{FrameFilter{Classes: []NodeClass{ZeusClass}}, []bool{true, false, true, false}},
+		{FrameFilter{IDs: []NodeID{3}}, []bool{true, true, false, false}},
+		{FrameFilter{Classes: []NodeClass{ZeusClass}, IDs: []NodeID{3}}, []bool{true, false, false, false}},
+		{FrameFilter{Messages: []string{"Zeus.Report", "arke.ResetRequest"}}, []bool{true, false, true, false}},
+		{FrameFilter{Messages: []string{"arke.RawMessage"}}, []bool{false, false, false, true}},
+	}
+	for _, d := range testdata {
+		for i, f := range []socketcan.CanFrame{report, heartbeat, reset, unknown} {
+			c.Check(d.Filter.Match(&f), Equals, d.Matches[i], Commentf("filter %+v, frame %v", d.Filter, f))
+		}
+	}
+}
+
+func (s *ReplaySuite) TestRewriteNodeIDs(c *C) {
+	ids := map[NodeID]NodeID{1: 3, 2: 4}
+	testdata := []struct {
+		Frame, Expected socketcan.CanFrame
+	}{
+		{
+			socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusReportMessage, 1), Dlc: 1, Data: []byte{1}},
+			socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, ZeusReportMessage, 3), Dlc: 1, Data: []byte{1}},
+		},
+		{makeRequestFrame(ZeusReportMessage, 2), makeRequestFrame(ZeusReportMessage, 4)},
+		{MakeHeartBeat(ZeusClass, 5, FirmwareVersion{}), MakeHeartBeat(ZeusClass, 5, FirmwareVersion{})},
+		{MakeResetRequest(ZeusClass, 1), MakeResetRequest(ZeusClass, 3)},
+		{MakePing(ZeusClass), MakePing(ZeusClass)},
+		{MakeSynchronisationRequest(ZeusClass, 1), MakeSynchronisationRequest(ZeusClass, 1)},
+		{MakeSynchronisationReply(ZeusClass, 2, 1, 0), MakeSynchronisationReply(ZeusClass, 4, 1, 0)},
+		{MakeIDChangeRequest(ZeusClass, 1, 2), MakeIDChangeRequest(ZeusClass, 3, 4)},
+		{MakeErrorReport(ZeusClass, 2, 1), MakeErrorReport(ZeusClass, 4, 1)},
+	}
+	for _, d := range testdata {
+		original := d.Frame
+		original.Data = slices.Clone(d.Frame.Data)
+		c.Check(RewriteNodeIDs(d.Frame, ids), DeepEquals, d.Expected)
+		c.Check(d.Frame, DeepEquals, original)
+	}
+}
+
+type timedSender struct {
+	frames []socketcan.CanFrame
+	times  []time.Time
+}
+
+func (s *timedSender) Send(f socketcan.CanFrame) error {
+	s.frames = append(s.frames, f)
+	s.times = append(s.times, time.Now())
+	return nil
+}
+
+func (s *ReplaySuite) capture(c *C, delays ...time.Duration) *Player {
+	buffer := bytes.NewBuffer(nil)
+	r, err := NewRecorder(buffer, BinaryFormat)
+	c.Assert(err, IsNil)
+	start := time.Now()
+	for i, d := range delays {
+		c.Assert(r.Write(Record{
+			Time:      start.Add(d),
+			Interface: "can0",
+			Frame:     MakeResetRequest(ZeusClass, NodeID(i)),
+		}), IsNil)
+	}
+	c.Assert(r.Flush(), IsNil)
+	p, err := NewPlayer(buffer)
+	c.Assert(err, IsNil)
+	return p
+}
+
+func (s *ReplaySuite) TestReplayTiming(c *C) {
+	sender := &timedSender{}
+	n, err := Replay(context.Background(), s.capture(c, 0, 40*time.Millisecond, 80*time.Millisecond), sender, 2.0, nil)
+	c.Assert(err, IsNil)
+	c.Check(n, Equals, 3)
+	c.Assert(sender.times, HasLen, 3)
+	for i, expected := range []time.Duration{20 * time.Millisecond, 40 * time.Millisecond} {
+		elapsed := sender.times[i+1].Sub(sender.times[0])
+		c.Check(elapsed >= expected, Equals, true, Commentf("frame %d sent after %s", i+1, elapsed))
+		c.Check(elapsed < expected+30*time.Millisecond, Equals, true, Commentf("frame %d sent after %s", i+1, elapsed))
+	}
+
+	sender = &timedSender{}
+	n, err = Replay(context.Background(), s.capture(c, 0, time.Hour), sender, 0, nil)
+	c.Check(err, IsNil)
+	c.Check(n, Equals, 2)
+}
+
+func (s *ReplaySuite) TestReplayTransform(c *C) {
+	sender := &timedSender{}
+	n, err := Replay(context.Background(), s.capture(c, 0, 0, 0), sender, 1.0, func(r *Record) bool {
+		if r.Frame.Data[0] == 1 {
+			return false
+		}
+		r.Frame = RewriteNodeIDs(r.Frame, map[NodeID]NodeID{2: 5})
+		return true
+	})
+	c.Assert(err, IsNil)
+	c.Check(n, Equals, 2)
+	c.Assert(sender.frames, HasLen, 2)
+	c.Check(sender.frames[0].Data, DeepEquals, []byte{0})
+	c.Check(sender.frames[1].Data, DeepEquals, []byte{5})
+}
+
+func (s *ReplaySuite) TestReplayCancellation(c *C) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	sender := &timedSender{}
+	n, err := Replay(ctx, s.capture(c, 0, time.Hour), sender, 1.0, nil)
+	c.Check(err, Equals, context.DeadlineExceeded)
+	c.Check(n, Equals, 1)
+}
@@ -0,0 +1,105 @@
+package arke
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+)
+
+// DefaultRequestTimeout is used by Request and RequestAll when the
+// given context has no deadline.
+var DefaultRequestTimeout = 500 * time.Millisecond
+
+func withDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok == true {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func isReplyTo(f *socketcan.CanFrame, c MessageClass) (NodeID, bool) {
+	if f.RTR == true || f.Extended == true {
+		return 0, false
+	}
+	mType, mClass, ID := ExtractCANIDT(f.ID)
+	if mType != StandardMessage && mType != HighPriorityMessage {
+		return 0, false
+	}
+	return ID, mClass == c
+}
+
+func sendRequest(bus Bus, c MessageClass, ID NodeID) error {
+	if err := checkID(ID); err != nil {
+		return err
+	}
+	return bus.Send(makeRequestFrame(c, ID))
+}
+
+// Request sends a RTR frame for the message class c to the node ID,
+// and waits for its reply. Any other frame received in the meantime
+// is discarded, so a dedicated Bus endpoint should be used.
+func Request(ctx context.Context, bus Bus, c MessageClass, ID NodeID) (ReceivableMessage, error) {
+	if ID == BroadcastID {
+		return nil, fmt.Errorf("Request needs a node ID, use RequestAll to broadcast")
+	}
+	ctx, cancel := withDefaultTimeout(ctx, DefaultRequestTimeout)
+	defer cancel()
+
+	if err := sendRequest(bus, c, ID); err != nil {
+		return nil, err
+	}
+
+	for {
+		f, err := bus.Receive(ctx)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) == true {
+				return nil, fmt.Errorf("No %s reply from node %d: %w", c, ID, err)
+			}
+			return nil, err
+		}
+		if fID, ok := isReplyTo(&f, c); ok == false || fID != ID {
+			continue
+		}
+		m, _, err := ParseMessage(&f)
+		return m, err
+	}
+}
+
+// RequestAll broadcasts a RTR frame for the message class c and
+// collects the replies of every node until the context is done. A
+// context reaching its deadline is not considered as an error. Replies
+// that cannot be parsed do not stop the collection: their errors are
+// returned joined, alongside the valid replies.
+func RequestAll(ctx context.Context, bus Bus, c MessageClass) (map[NodeID]ReceivableMessage, error) {
+	ctx, cancel := withDefaultTimeout(ctx, DefaultRequestTimeout)
+	defer cancel()
+
+	if err := sendRequest(bus, c, BroadcastID); err != nil {
+		return nil, err
+	}
+
+	res := make(map[NodeID]ReceivableMessage)
+	var errs []error
+	for {
+		f, err := bus.Receive(ctx)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) == true {
+				return res, errors.Join(errs...)
+			}
+			return res, errors.Join(append(errs, err)...)
+		}
+		ID, ok := isReplyTo(&f, c)
+		if ok == false {
+			continue
+		}
+		m, _, err := ParseMessage(&f)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Invalid %s reply from node %d: %w", c, ID, err))
+			continue
+		}
+		res[ID] = m
+	}
+}
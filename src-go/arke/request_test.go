@@ -0,0 +1,222 @@
+package arke
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+	. "gopkg.in/check.v1"
+)
+
+type RequestSuite struct {
+	loopback *Loopback
+	host     Bus
+}
+
+var _ = Suite(&RequestSuite{})
+
+// fakeNode answers RTR frames targeting its ID, or broadcasted to all
+// IDs, with the registered replies. If it has a class, it also answers
+// pings with a versioned heartbeat.
+type fakeNode struct {
+	bus     Bus
+	Class   NodeClass
+	ID      NodeID
+	Version FirmwareVersion
+	replies map[MessageClass]SendableMessage
+	done    chan struct{}
+}
+
+func startFakeNode(l *Loopback, ID NodeID, replies ...SendableMessage) *fakeNode {
+	return startClassedFakeNode(l, BroadcastClass, ID, FirmwareVersion{}, replies...)
+}
+
+func startClassedFakeNode(l *Loopback, c NodeClass, ID NodeID, v FirmwareVersion, replies ...SendableMessage) *fakeNode {
+	n := &fakeNode{
+		bus:     l.Endpoint(),
+		Class:   c,
+		ID:      ID,
+		Version: v,
+		replies: make(map[MessageClass]SendableMessage),
+		done:    make(chan struct{}),
+	}
+	for _, r := range replies {
+		n.replies[r.MessageClassID()] = r
+	}
+	go n.run()
+	return n
+}
+
+func makeHeartBeat(c NodeClass, ID NodeID, v FirmwareVersion) socketcan.CanFrame {
+	f := socketcan.CanFrame{
+		ID:   MakeCANIDT(HeartBeat, MessageClass(c), ID),
+		Data: make([]byte, 8),
+	}
+	if v.IsZero() == false {
+		f.Dlc = 4
+		copy(f.Data, []byte{v.Major, v.Minor, v.Patch, v.Tweak})
+	}
+	return f
+}
+
+func (n *fakeNode) run() {
+	defer close(n.done)
+	for {
+		f, err := n.bus.Receive(context.Background())
+		if err != nil {
+			return
+		}
+		t, c, ID := ExtractCANIDT(f.ID)
+		if t == NetworkControlCommand && ID == NodeID(HeartBeatRequest) && f.Dlc == 0 {
+			if n.Class != BroadcastClass && (NodeClass(c) == n.Class || NodeClass(c) == BroadcastClass) {
+				n.bus.Send(makeHeartBeat(n.Class, n.ID, n.Version))
+			}
+			continue
+		}
+		if f.RTR == false {
+			continue
+		}
+		if ID != n.ID && ID != BroadcastID {
+			continue
+		}
+		if r, ok := n.replies[c]; ok == true {
+			SendMessage(n.bus, r, false, n.ID)
+		}
+	}
+}
+
+func (n *fakeNode) stop() {
+	n.bus.Close()
+	<-n.done
+}
+
+func (s *RequestSuite) SetUpTest(c *C) {
+	s.loopback = NewLoopback()
+	s.host = s.loopback.Endpoint()
+}
+
+func (s *RequestSuite) TearDownTest(c *C) {
+	s.loopback.Close()
+}
+
+func (s *RequestSuite) TestRequest(c *C) {
+	n1 := startFakeNode(s.loopback, 1, &ZeusSetPoint{Humidity: 0, Temperature: -40, Wind: 12})
+	defer n1.stop()
+	n2 := startFakeNode(s.loopback, 2, &ZeusSetPoint{Humidity: 0, Temperature: -40, Wind: 42})
+	defer n2.stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	m, err := Request(ctx, s.host, ZeusSetPointMessage, 2)
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, &ZeusSetPoint{Humidity: 0, Temperature: -40, Wind: 42})
+}
+
+func (s *RequestSuite) TestRequestIgnoresOtherTraffic(c *C) {
+	other := s.loopback.Endpoint()
+	defer other.Close()
+	SendMessage(other, &ZeusReport{Temperature: [4]float32{-40, 0, 0, 0}}, false, 3)
+	SendMessage(other, &CelaenoSetPoint{Power: 1}, false, 4)
+	other.Send(MakePing(ZeusClass))
+
+	n := startFakeNode(s.loopback, 3, &CelaenoSetPoint{Power: 128})
+	defer n.stop()
+
+	m, err := Request(context.Background(), s.host, CelaenoSetPointMessage, 3)
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, &CelaenoSetPoint{Power: 128})
+}
+
+func (s *RequestSuite) TestRequestTimeout(c *C) {
+	n := startFakeNode(s.loopback, 1)
+	defer n.stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := Request(ctx, s.host, HeliosSetPointMessage, 1)
+	c.Check(err, ErrorMatches, "No Helios.SetPoint reply from node 1: context deadline exceeded")
+	c.Check(errors.Is(err, context.DeadlineExceeded), Equals, true)
+}
+
+func (s *RequestSuite) TestRequestErrors(c *C) {
+	_, err := Request(context.Background(), s.host, ZeusReportMessage, 0)
+	c.Check(err, ErrorMatches, "Request needs a node ID, use RequestAll to broadcast")
+	_, err = Request(context.Background(), s.host, ZeusReportMessage, 8)
+	c.Check(err, ErrorMatches, "Invalid device ID 8 \\(max is 7\\)")
+
+	other := s.loopback.Endpoint()
+	defer other.Close()
+	go func() {
+		f, err := other.Receive(context.Background())
+		if err != nil {
+			return
+		}
+		_, _, ID := ExtractCANIDT(f.ID)
+		other.Send(socketcan.CanFrame{
+			ID:   MakeCANIDT(StandardMessage, ZeusReportMessage, ID),
+			Dlc:  1,
+			Data: []byte{0},
+		})
+	}()
+	_, err = Request(context.Background(), s.host, ZeusReportMessage, 1)
+	c.Check(err, ErrorMatches, "Could not parse message data: .*")
+}
+
+func (s *RequestSuite) TestRequestUndecodedMessage(c *C) {
+	vibration := &RawMessage{StandardMessage, ZeusVibrationReportMessage, 1, []byte{1, 2, 3}}
+	n := startFakeNode(s.loopback, 1, vibration)
+	defer n.stop()
+
+	m, err := Request(context.Background(), s.host, ZeusVibrationReportMessage, 1)
+	c.Check(err, IsNil)
+	c.Check(m, DeepEquals, vibration)
+}
+
+func (s *RequestSuite) TestRequestAll(c *C) {
+	for _, ID := range []NodeID{1, 2, 5} {
+		n := startFakeNode(s.loopback, ID, &NotusSetPoint{Power: uint8(ID)})
+		defer n.stop()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	res, err := RequestAll(ctx, s.host, NotusSetPointMessage)
+	c.Assert(err, IsNil)
+	c.Check(res, DeepEquals, map[NodeID]ReceivableMessage{
+		1: &NotusSetPoint{Power: 1},
+		2: &NotusSetPoint{Power: 2},
+		5: &NotusSetPoint{Power: 5},
+	})
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	res, err = RequestAll(ctx, s.host, NotusConfigMessage)
+	c.Check(err, IsNil)
+	c.Check(res, HasLen, 0)
+}
+
+func (s *RequestSuite) TestRequestAllMalformedReply(c *C) {
+	for _, ID := range []NodeID{1, 5} {
+		n := startFakeNode(s.loopback, ID, &NotusSetPoint{Power: uint8(ID)})
+		defer n.stop()
+	}
+	// node 3 answers with an empty payload
+	malformed := s.loopback.Endpoint()
+	defer malformed.Close()
+	go func() {
+		if _, err := malformed.Receive(context.Background()); err != nil {
+			return
+		}
+		malformed.Send(socketcan.CanFrame{ID: MakeCANIDT(StandardMessage, NotusSetPointMessage, 3)})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	res, err := RequestAll(ctx, s.host, NotusSetPointMessage)
+	c.Check(err, ErrorMatches, "Invalid Notus.SetPoint reply from node 3: .*")
+	c.Check(res, DeepEquals, map[NodeID]ReceivableMessage{
+		1: &NotusSetPoint{Power: 1},
+		5: &NotusSetPoint{Power: 5},
+	})
+}
@@ -0,0 +1,85 @@
+package arke
+
+import (
+	"context"
+	"sync"
+
+	socketcan "github.com/atuleu/golang-socketcan"
+)
+
+type receivedFrame struct {
+	frame socketcan.CanFrame
+	err   error
+}
+
+type socketCANBus struct {
+	itf       socketcan.RawInterface
+	frames    chan receivedFrame
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewSocketCANBus(itf socketcan.RawInterface) Bus {
+	res := &socketCANBus{
+		itf:    itf,
+		frames: make(chan receivedFrame, 10),
+		done:   make(chan struct{}),
+	}
+	go res.receiveLoop()
+	return res
+}
+
+func OpenSocketCANBus(ifname string) (Bus, error) {
+	itf, err := socketcan.NewRawInterface(ifname)
+	if err != nil {
+		return nil, err
+	}
+	return NewSocketCANBus(itf), nil
+}
+
+func (b *socketCANBus) receiveLoop() {
+	defer close(b.frames)
+	for {
+		f, err := b.itf.Receive()
+		if err != nil && socketcan.IsClosedInterfaceError(err) == true {
+			return
+		}
+		select {
+		case b.frames <- receivedFrame{frame: f, err: err}:
+		case <-b.done:
+			return
+		}
+	}
+}
+
+func (b *socketCANBus) Send(f socketcan.CanFrame) error {
+	select {
+	case <-b.done:
+		return ErrBusClosed
+	default:
+	}
+	return b.itf.Send(f)
+}
+
+func (b *socketCANBus) Receive(ctx context.Context) (socketcan.CanFrame, error) {
+	select {
+	case <-ctx.Done():
+		return socketcan.CanFrame{}, ctx.Err()
+	case <-b.done:
+		return socketcan.CanFrame{}, ErrBusClosed
+	case r, ok := <-b.frames:
+		if ok == false {
+			return socketcan.CanFrame{}, ErrBusClosed
+		}
+		return r.frame, r.err
+	}
+}
+
+func (b *socketCANBus) Close() error {
+	err := ErrBusClosed
+	b.closeOnce.Do(func() {
+		close(b.done)
+		err = b.itf.Close()
+	})
+	return err
+}
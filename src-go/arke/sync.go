@@ -0,0 +1,225 @@
+package arke
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ClockEstimate relates the clock of a node to the host clock. The
+// node clock reads Offset at the host time Reference, and runs at a
+// rate of 1+Drift relative to the host clock.
+type ClockEstimate struct {
+	Class     NodeClass
+	ID        NodeID
+	Reference time.Time
+	Offset    time.Duration
+	Drift     float64
+	Samples   int
+}
+
+func (e ClockEstimate) String() string {
+	return fmt.Sprintf("arke.ClockEstimate{Node: %s.%d, Offset: %s, Drift: %.1fppm, Samples: %d}",
+		ClassName(e.Class), e.ID, e.Offset, e.Drift*1e6, e.Samples)
+}
+
+// NodeTime returns the expected node clock reading at host time t.
+func (e ClockEstimate) NodeTime(t time.Time) time.Duration {
+	dt := t.Sub(e.Reference)
+	return e.Offset + dt + time.Duration(e.Drift*float64(dt))
+}
+
+// HostTime converts a node clock reading to host time.
+func (e ClockEstimate) HostTime(timestamp time.Duration) time.Time {
+	return e.Reference.Add(time.Duration(float64(timestamp-e.Offset) / (1 + e.Drift)))
+}
+
+type syncSample struct {
+	host time.Time
+	node time.Duration
+}
+
+type clockTracker struct {
+	mx     sync.Mutex
+	window int
+	sent   map[uint8]time.Time
+	nodes  map[nodeKey][]syncSample
+}
+
+func newClockTracker(window int) *clockTracker {
+	return &clockTracker{
+		window: max(window, 2),
+		sent:   make(map[uint8]time.Time),
+		nodes:  make(map[nodeKey][]syncSample),
+	}
+}
+
+func (t *clockTracker) request(sequence uint8, now time.Time) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.sent[sequence] = now
+}
+
+func (t *clockTracker) reply(r *SynchronisationReplyData) (ClockEstimate, bool) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	sentAt, ok := t.sent[r.Sequence]
+	if ok == false {
+		return ClockEstimate{}, false
+	}
+	key := nodeKey{r.Class, r.ID}
+	samples := t.nodes[key]
+	if len(samples) > 0 {
+		last := samples[len(samples)-1]
+		if last.host.Equal(sentAt) == true {
+			// duplicated reply
+			return ClockEstimate{}, false
+		}
+		if r.Timestamp < last.node {
+			// the node rebooted, its clock restarted.
+			samples = samples[:0]
+		}
+	}
+	samples = append(samples, syncSample{host: sentAt, node: r.Timestamp})
+	if len(samples) > t.window {
+		samples = slices.Delete(samples, 0, len(samples)-t.window)
+	}
+	t.nodes[key] = samples
+	return fitClock(key, samples), true
+}
+
+// fitClock computes a least square linear fit of the node clock
+// against the host clock.
+func fitClock(key nodeKey, samples []syncSample) ClockEstimate {
+	first := samples[0]
+	n := float64(len(samples))
+	var sx, sy, sxx, sxy float64
+	for _, s := range samples {
+		x := s.host.Sub(first.host).Seconds()
+		y := (s.node - first.node).Seconds()
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+	}
+	slope := 1.0
+	if d := n*sxx - sx*sx; d > 0 {
+		slope = (n*sxy - sx*sy) / d
+	}
+	intercept := (sy - slope*sx) / n
+
+	last := samples[len(samples)-1]
+	x := last.host.Sub(first.host).Seconds()
+	return ClockEstimate{
+		Class:     key.Class,
+		ID:        key.ID,
+		Reference: last.host,
+		Offset:    first.node + time.Duration((intercept+slope*x)*float64(time.Second)),
+		Drift:     slope - 1,
+		Samples:   len(samples),
+	}
+}
+
+func (t *clockTracker) estimate(c NodeClass, ID NodeID) (ClockEstimate, bool) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	samples, ok := t.nodes[nodeKey{c, ID}]
+	if ok == false || len(samples) == 0 {
+		return ClockEstimate{}, false
+	}
+	return fitClock(nodeKey{c, ID}, samples), true
+}
+
+func (t *clockTracker) estimates() []ClockEstimate {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	res := make([]ClockEstimate, 0, len(t.nodes))
+	for key, samples := range t.nodes {
+		res = append(res, fitClock(key, samples))
+	}
+	slices.SortFunc(res, func(a, b ClockEstimate) int {
+		if a.Class != b.Class {
+			return int(b.Class) - int(a.Class)
+		}
+		return int(a.ID) - int(b.ID)
+	})
+	return res
+}
+
+// SyncService periodically broadcasts Timestamp Synchronization
+// requests, and estimates the clock offset and drift of every node
+// replying to them.
+type SyncService struct {
+	bus      Bus
+	class    NodeClass
+	period   time.Duration
+	tracker  *clockTracker
+	sequence uint8
+}
+
+// NewSyncService creates a service synchronizing the nodes of class c,
+// or all nodes with BroadcastClass, every period. Estimates are
+// computed on the last window replies of each node.
+func NewSyncService(bus Bus, c NodeClass, period time.Duration, window int) *SyncService {
+	return &SyncService{
+		bus:     bus,
+		class:   c,
+		period:  max(period, time.Millisecond),
+		tracker: newClockTracker(window),
+	}
+}
+
+// Estimate returns the current clock estimate for a node, if it ever
+// replied.
+func (s *SyncService) Estimate(c NodeClass, ID NodeID) (ClockEstimate, bool) {
+	return s.tracker.estimate(c, ID)
+}
+
+// Estimates returns the current clock estimates of all nodes, sorted
+// like an Inventory.
+func (s *SyncService) Estimates() []ClockEstimate {
+	return s.tracker.estimates()
+}
+
+func (s *SyncService) synchronise() error {
+	s.sequence += 1
+	s.tracker.request(s.sequence, time.Now())
+	return s.bus.Send(MakeSynchronisationRequest(s.class, s.sequence))
+}
+
+// Run sends the synchronisation requests and processes the replies
+// until the context is done or the Bus fails.
+func (s *SyncService) Run(ctx context.Context) error {
+	if err := s.synchronise(); err != nil {
+		return err
+	}
+	next := time.Now().Add(s.period)
+	for {
+		rctx, cancel := context.WithDeadline(ctx, next)
+		f, err := s.bus.Receive(rctx)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil && errors.Is(err, context.DeadlineExceeded) == false {
+			return err
+		}
+		if err == nil {
+			if m, _, perr := ParseMessage(&f); perr == nil {
+				if r, ok := m.(*SynchronisationReplyData); ok == true {
+					s.tracker.reply(r)
+				}
+			}
+		}
+		if time.Now().Before(next) == true {
+			continue
+		}
+		if err := s.synchronise(); err != nil {
+			return err
+		}
+		next = next.Add(s.period)
+	}
+}
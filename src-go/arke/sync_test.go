@@ -0,0 +1,103 @@
+package arke
+
+import (
+	"context"
+	"math"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type SyncSuite struct{}
+
+var _ = Suite(&SyncSuite{})
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func (s *SyncSuite) TestTracker(c *C) {
+	t := newClockTracker(4)
+	start := time.Now()
+	node := func(d time.Duration) time.Duration {
+		// node booted 10s before start, and runs 100ppm fast.
+		return 10*time.Second + d + d/10000
+	}
+	reply := func(seq uint8, d time.Duration) (ClockEstimate, bool) {
+		return t.reply(&SynchronisationReplyData{Class: ZeusClass, ID: 1, Sequence: seq, Timestamp: node(d)})
+	}
+
+	// unsolicited replies are ignored
+	_, ok := reply(1, 0)
+	c.Check(ok, Equals, false)
+
+	for i := 0; i < 6; i++ {
+		d := time.Duration(i) * time.Second
+		t.request(uint8(i), start.Add(d))
+		e, ok := reply(uint8(i), d)
+		c.Assert(ok, Equals, true)
+		c.Check(e.Samples, Equals, min(i+1, 4))
+		c.Check(e.Reference, Equals, start.Add(d))
+		c.Check(absDuration(e.Offset-node(d)) <= time.Microsecond, Equals, true)
+	}
+	// duplicated replies are ignored
+	_, ok = reply(5, 5*time.Second)
+	c.Check(ok, Equals, false)
+
+	e, ok := t.estimate(ZeusClass, 1)
+	c.Assert(ok, Equals, true)
+	c.Check(math.Abs(e.Drift-1e-4) < 1e-9, Equals, true, Commentf("%s", e))
+	at := start.Add(20 * time.Second)
+	c.Check(absDuration(e.NodeTime(at)-node(20*time.Second)) <= time.Microsecond, Equals, true)
+	c.Check(absDuration(e.HostTime(node(20*time.Second)).Sub(at)) <= time.Microsecond, Equals, true)
+
+	// a reboot restarts the estimation
+	t.request(6, start.Add(6*time.Second))
+	e, ok = t.reply(&SynchronisationReplyData{Class: ZeusClass, ID: 1, Sequence: 6, Timestamp: time.Millisecond})
+	c.Check(ok, Equals, true)
+	c.Check(e.Samples, Equals, 1)
+	c.Check(e.Drift, Equals, 0.0)
+
+	_, ok = t.estimate(ZeusClass, 2)
+	c.Check(ok, Equals, false)
+}
+
+func (s *SyncSuite) TestService(c *C) {
+	loopback := NewLoopback()
+	defer loopback.Close()
+	host := loopback.Endpoint()
+	node := loopback.Endpoint()
+	boot := time.Now().Add(-time.Minute)
+
+	go func() {
+		for {
+			f, err := node.Receive(context.Background())
+			if err != nil {
+				return
+			}
+			m, _, err := ParseMessage(&f)
+			if err != nil {
+				continue
+			}
+			if r, ok := m.(*SynchronisationRequestData); ok == true {
+				node.Send(MakeSynchronisationReply(CelaenoClass, 2, r.Sequence, time.Since(boot)))
+			}
+		}
+	}()
+
+	service := NewSyncService(host, CelaenoClass, 10*time.Millisecond, 8)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	c.Check(service.Run(ctx), IsNil)
+
+	estimates := service.Estimates()
+	c.Assert(estimates, HasLen, 1)
+	e := estimates[0]
+	c.Check(e.Class, Equals, CelaenoClass)
+	c.Check(e.ID, Equals, NodeID(2))
+	c.Check(e.Samples > 2, Equals, true)
+	c.Check(absDuration(e.HostTime(time.Minute).Sub(boot.Add(time.Minute))) <= 5*time.Millisecond, Equals, true)
+}
@@ -0,0 +1,292 @@
+package arke
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// textAliases maps the keys used by the String() representation of a
+// message to its field, when they differ.
+var textAliases = map[reflect.Type]map[string]string{
+	reflect.TypeOf(ZeusReport{}): {
+		"Ant": "Temperature[0]", "Aux1": "Temperature[1]", "Aux2": "Temperature[2]", "Aux3": "Temperature[3]",
+	},
+	reflect.TypeOf(ZeusDeltaTemperature{}): {
+		"Ants": "Delta[0]", "Aux1": "Delta[1]", "Aux2": "Delta[2]", "Aux3": "Delta[3]",
+	},
+	reflect.TypeOf(ZeusStatus{}): {
+		"General": "Status", "WindFan": "Fans[0]", "RightFan": "Fans[1]", "LeftFan": "Fans[2]",
+	},
+	reflect.TypeOf(CelaenoConfig{}): {
+		"RampUp": "RampUpTime", "RampDown": "RampDownTime", "MinimumOn": "MinimumOnTime", "Debounce": "DebounceTime",
+	},
+	reflect.TypeOf(MessageRequestData{}):         {"Message": "Class", "Node": "ID"},
+	reflect.TypeOf(ResetRequestData{}):           {"Node": "ID"},
+	reflect.TypeOf(HeartBeatRequestData{}):       {"Node": ""},
+	reflect.TypeOf(SynchronisationRequestData{}): {"Node": ""},
+	reflect.TypeOf(IDChangeRequestData{}):        {"OldID": "Old", "NewID": "New"},
+}
+
+var (
+	nodeIDType          = reflect.TypeOf(NodeID(0))
+	nodeClassType       = reflect.TypeOf(NodeClass(0))
+	messageClassType    = reflect.TypeOf(MessageClass(0))
+	fanStatusAndRPMType = reflect.TypeOf(FanStatusAndRPM(0))
+	pdConfigType        = reflect.TypeOf(PDConfig{})
+)
+
+type textField struct {
+	Key, Value string
+}
+
+// splitText splits "Name{Key: Value, Key: Value}" in its name and
+// fields. Values may themselves contain braces.
+func splitText(s string) (string, []textField, error) {
+	s = strings.TrimSpace(s)
+	start := strings.IndexByte(s, '{')
+	if start < 0 || strings.HasSuffix(s, "}") == false {
+		return "", nil, fmt.Errorf("Invalid message text '%s'", s)
+	}
+	name, body := s[:start], s[start+1:len(s)-1]
+	var fields []textField
+	depth, last := 0, 0
+	for i := 0; i <= len(body); i++ {
+		if i < len(body) {
+			switch body[i] {
+			case '{', '[', '(':
+				depth += 1
+			case '}', ']', ')':
+				depth -= 1
+			}
+			if body[i] != ',' || depth > 0 {
+				continue
+			}
+		}
+		part := strings.TrimSpace(body[last:i])
+		last = i + 1
+		if len(part) == 0 {
+			continue
+		}
+		key, value, ok := strings.Cut(part, ":")
+		if ok == false {
+			return "", nil, fmt.Errorf("Invalid field '%s' in '%s'", part, s)
+		}
+		fields = append(fields, textField{strings.TrimSpace(key), strings.TrimSpace(value)})
+	}
+	if depth != 0 {
+		return "", nil, fmt.Errorf("Unbalanced braces in '%s'", s)
+	}
+	return name, fields, nil
+}
+
+// ParseMessageText parses the String() representation of a message,
+// e.g. "Zeus.SetPoint{Humidity: 40.00%, Temperature: 26.00°C, Wind:
+// 127}". Values are only as precise as they are printed.
+func ParseMessageText(s string) (ReceivableMessage, error) {
+	name, fields, err := splitText(s)
+	if err != nil {
+		return nil, err
+	}
+	m, err := NewMessageByName(name)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(m).Elem()
+	aliases := textAliases[v.Type()]
+	for _, f := range fields {
+		if h, ok := m.(*HeartBeatData); ok == true && f.Key == "Version" {
+			if err := parseVersionText(h, f.Value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		path, ok := aliases[f.Key]
+		if ok == false {
+			path = f.Key
+		}
+		if len(path) == 0 {
+			continue
+		}
+		field, err := fieldByPath(v, path)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s field '%s': %w", name, f.Key, err)
+		}
+		if err := setTextValue(field, f.Value); err != nil {
+			return nil, fmt.Errorf("Invalid %s field '%s': %w", name, f.Key, err)
+		}
+	}
+	return m, nil
+}
+
+func parseVersionText(h *HeartBeatData, s string) error {
+	var v [4]uint8
+	parts := strings.Split(s, ".")
+	if len(parts) > 4 {
+		return fmt.Errorf("Invalid version '%s'", s)
+	}
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 8)
+		if err != nil {
+			return fmt.Errorf("Invalid version '%s'", s)
+		}
+		v[i] = uint8(n)
+	}
+	h.MajorVersion, h.MinorVersion, h.PatchVersion, h.TweakVersion = v[0], v[1], v[2], v[3]
+	return nil
+}
+
+func parsePDConfigText(s string) (PDConfig, error) {
+	res := PDConfig{}
+	_, fields, err := splitText(s)
+	if err != nil {
+		return res, err
+	}
+	for _, f := range fields {
+		mult, div, ok := strings.Cut(f.Value, "/")
+		if ok == false {
+			return res, fmt.Errorf("Invalid ratio '%s'", f.Value)
+		}
+		m, err := strconv.ParseUint(mult, 10, 8)
+		if err != nil {
+			return res, err
+		}
+		d, err := strconv.ParseUint(div, 10, 16)
+		if err != nil || d == 0 || d&(d-1) != 0 {
+			return res, fmt.Errorf("Invalid divider '%s'", div)
+		}
+		power := uint8(0)
+		for ; d > 1; d >>= 1 {
+			power += 1
+		}
+		switch f.Key {
+		case "Proportional":
+			res.ProportionnalMultiplier, res.DividerPower = uint8(m), power
+		case "Derivative":
+			res.DerivativeMultiplier, res.DividerPower = uint8(m), power
+		case "Integral":
+			res.IntegralMultiplier, res.DividerPowerIntegral = uint8(m), power
+		default:
+			return res, fmt.Errorf("Unknown field '%s'", f.Key)
+		}
+	}
+	return res, nil
+}
+
+func parseFanStatusAndRPMText(s string) (FanStatusAndRPM, error) {
+	_, fields, err := splitText(s)
+	if err != nil {
+		return 0, err
+	}
+	status, rpm, aging := FanOK, uint64(0), false
+	for _, f := range fields {
+		switch f.Key {
+		case "Status":
+			err = status.UnmarshalText([]byte(f.Value))
+		case "Aging":
+			aging, err = strconv.ParseBool(f.Value)
+		case "RPM":
+			rpm, err = strconv.ParseUint(f.Value, 10, 16)
+		default:
+			err = fmt.Errorf("Unknown field '%s'", f.Key)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	res, err := MakeFanStatusAndRPM(status, uint16(rpm))
+	if err != nil {
+		return 0, err
+	}
+	if aging == true {
+		res |= 0x4000
+	}
+	return res, nil
+}
+
+func setTextValue(v reflect.Value, s string) error {
+	t := v.Type()
+	// numbers may be followed by a name or description
+	number, _, _ := strings.Cut(s, " ")
+
+	switch t {
+	case durationType:
+		if s == "SinglePing" {
+			v.SetInt(0)
+			return nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case nodeIDType:
+		if strings.ToLower(s) == "all" {
+			v.SetUint(0)
+			return nil
+		}
+	case messageClassType:
+		for _, c := range MessageClasses() {
+			if c.String() == s {
+				v.SetUint(uint64(c))
+				return nil
+			}
+		}
+	case fanStatusAndRPMType:
+		res, err := parseFanStatusAndRPMText(s)
+		if err != nil {
+			return err
+		}
+		v.SetUint(uint64(res))
+		return nil
+	case pdConfigType:
+		res, err := parsePDConfigText(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(res))
+		return nil
+	}
+
+	if u, ok := v.Addr().Interface().(interface{ UnmarshalText([]byte) error }); ok == true {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		s = strings.TrimSuffix(strings.TrimSuffix(s, "%"), "°C")
+		f, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(number, 0, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(number, 0, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type %s", t)
+		}
+		data, err := hex.DecodeString(strings.ReplaceAll(strings.Trim(s, "[]"), " ", ""))
+		if err != nil {
+			return err
+		}
+		v.SetBytes(data)
+	default:
+		return fmt.Errorf("unsupported type %s", t)
+	}
+	return nil
+}
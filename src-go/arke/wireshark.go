@@ -0,0 +1,356 @@
+package arke
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// wiresharkField is a payload field of the generated dissector. Kind
+// selects how the Lua code extracts and displays it: "uint", "int",
+// "uint64", "double" for scaled values, "class" for node classes and
+// "fan" for FanStatusAndRPM flags.
+type wiresharkField struct {
+	FieldInfo
+	Abbrev string
+	Kind   string
+}
+
+// wiresharkLayout is the payload layout of a message.
+type wiresharkLayout struct {
+	Name   string
+	Fields []wiresharkField
+}
+
+// size returns the payload length in bytes covered by the fields.
+func (l wiresharkLayout) size() int {
+	res := 0
+	for _, f := range l.Fields {
+		res = max(res, (f.BitOffset+f.BitSize+7)/8)
+	}
+	return res
+}
+
+// networkCommandPayloads names the payloads of each network command,
+// by increasing size when a command has several of them.
+var networkCommandPayloads = map[MessageClass][]string{
+	ResetRequest:           {"arke.ResetRequest"},
+	SynchronisationRequest: {"arke.SynchronisationRequest", "arke.SynchronisationReply"},
+	IDChangeRequest:        {"arke.IDChangeRequest"},
+	ErrorReport:            {"arke.ErrorReport"},
+	HeartBeatRequest:       {"arke.HeartBeatRequest"},
+}
+
+// wiresharkAbbrev returns the Wireshark filter name of a field,
+// e.g. "arke.zeus.report.temperature_1".
+func wiresharkAbbrev(message, field string) string {
+	res := "arke." + strings.TrimPrefix(message, "arke.") + "." + field
+	res = strings.NewReplacer("[", "_", "]", "").Replace(res)
+	return strings.ToLower(res)
+}
+
+func newWiresharkLayout(name string, m any) wiresharkLayout {
+	res := wiresharkLayout{Name: name}
+	d, ok := m.(Describable)
+	if ok == false {
+		return res
+	}
+	for _, info := range d.Fields() {
+		f := wiresharkField{FieldInfo: info, Abbrev: wiresharkAbbrev(name, info.Name), Kind: "uint"}
+		t := fieldType(m, info.Name)
+		switch {
+		case t == fanStatusAndRPMType && info.BitSize == 16 && info.BitOffset%8 == 0:
+			f.Kind = "fan"
+		case t == nodeClassType:
+			f.Kind = "class"
+		case info.Resolution != 1 || info.Bias != 0:
+			f.Kind = "double"
+		case info.BitSize > 32:
+			f.Kind = "uint64"
+		case info.Signed == true:
+			f.Kind = "int"
+		}
+		res.Fields = append(res.Fields, f)
+	}
+	return res
+}
+
+func luaFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func (f wiresharkField) protoField() string {
+	label := f.Name
+	if len(f.Unit) > 0 {
+		label += " [" + f.Unit + "]"
+	}
+	switch f.Kind {
+	case "int":
+		return fmt.Sprintf("ProtoField.int32(%q, %q)", f.Abbrev, label)
+	case "uint64":
+		return fmt.Sprintf("ProtoField.uint64(%q, %q)", f.Abbrev, label)
+	case "double":
+		return fmt.Sprintf("ProtoField.double(%q, %q)", f.Abbrev, label)
+	case "class":
+		return fmt.Sprintf("ProtoField.uint8(%q, %q, base.HEX, node_classes)", f.Abbrev, label)
+	case "fan":
+		return fmt.Sprintf("ProtoField.uint16(%q, %q, base.HEX)", f.Abbrev, label)
+	}
+	return fmt.Sprintf("ProtoField.uint32(%q, %q)", f.Abbrev, label)
+}
+
+func writeLuaLayout(b *strings.Builder, indent string, l wiresharkLayout) {
+	fmt.Fprintf(b, "%sname = %q,\n", indent, l.Name)
+	fmt.Fprintf(b, "%ssize = %d,\n", indent, l.size())
+	fmt.Fprintf(b, "%sfields = {\n", indent)
+	for _, f := range l.Fields {
+		fmt.Fprintf(b, "%s\t{ field = %s, kind = %q, offset = %d, size = %d, signed = %t, resolution = %s, bias = %s },\n",
+			indent, f.protoField(), f.Kind, f.BitOffset, f.BitSize, f.Signed, luaFloat(f.Resolution), luaFloat(f.Bias))
+	}
+	fmt.Fprintf(b, "%s},\n", indent)
+}
+
+// WriteWiresharkDissector writes a Wireshark Lua dissector for Arke
+// frames carried over SocketCAN, generated from the registered node
+// classes, message classes and network commands. Payload fields are
+// decoded from the messages implementing Describable.
+func WriteWiresharkDissector(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(wiresharkHeader)
+
+	b.WriteString("local message_types = {\n")
+	for _, t := range []MessageType{NetworkControlCommand, HighPriorityMessage, StandardMessage, HeartBeat} {
+		fmt.Fprintf(&b, "\t[%d] = %q,\n", t, t.String())
+	}
+	b.WriteString("}\n\nlocal node_classes = {\n")
+	for _, c := range NodeClasses() {
+		fmt.Fprintf(&b, "\t[0x%02x] = %q,\n", int(c), ClassName(c))
+	}
+
+	b.WriteString("}\n\n-- payload layouts of standard and high priority messages, by\n-- message class\nlocal messages = {\n")
+	for _, c := range MessageClasses() {
+		var m any
+		if created, err := NewMessage(c); err == nil {
+			m = created
+		}
+		fmt.Fprintf(&b, "\t[0x%02x] = {\n", int(c))
+		writeLuaLayout(&b, "\t\t", newWiresharkLayout(c.String(), m))
+		b.WriteString("\t},\n")
+	}
+
+	b.WriteString("}\n\n-- payload layouts of network commands, by command. The first layout\n-- large enough for the payload is used.\nlocal commands = {\n")
+	for _, c := range networkCommands() {
+		fmt.Fprintf(&b, "\t[%d] = {\n", int(c))
+		names := networkCommandPayloads[c]
+		if len(names) == 0 {
+			names = []string{fmt.Sprintf("arke.Command%d", int(c))}
+		}
+		for _, name := range names {
+			var m any
+			if creator, ok := builtinMessages[name]; ok == true {
+				m = creator()
+			}
+			b.WriteString("\t\t{\n")
+			writeLuaLayout(&b, "\t\t\t", newWiresharkLayout(name, m))
+			b.WriteString("\t\t},\n")
+		}
+		b.WriteString("\t},\n")
+	}
+
+	b.WriteString("}\n\nlocal heartbeat = {\n")
+	writeLuaLayout(&b, "\t", newWiresharkLayout("arke.HeartBeat", &HeartBeatData{}))
+	b.WriteString("}\n")
+
+	b.WriteString(wiresharkDissector)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+const wiresharkHeader = `-- Code generated by arkewireshark. DO NOT EDIT.
+--
+-- Wireshark dissector for the Arke protocol over SocketCAN. Copy this
+-- file in the Wireshark personal Lua plugins folder, for example
+-- ~/.local/lib/wireshark/plugins/, and open a SocketCAN capture, such
+-- as the pcapng files written by arkedump.
+
+local arke = Proto("arke", "Arke Protocol")
+
+`
+
+const wiresharkDissector = `
+local pf = {
+	type = ProtoField.uint8("arke.type", "Type", base.DEC, message_types),
+	class = ProtoField.uint8("arke.class", "Class", base.HEX),
+	id = ProtoField.uint8("arke.id", "ID", base.DEC),
+	node = ProtoField.uint8("arke.node", "Node Class", base.HEX, node_classes),
+	message = ProtoField.string("arke.message", "Message"),
+	request = ProtoField.bool("arke.request", "Request"),
+	payload = ProtoField.bytes("arke.payload", "Payload"),
+	fan_rpm = ProtoField.uint16("arke.fan.rpm", "RPM", base.DEC, nil, 0x3fff),
+	fan_aging = ProtoField.bool("arke.fan.aging", "Aging", 16, nil, 0x4000),
+	fan_stalled = ProtoField.bool("arke.fan.stalled", "Stalled", 16, nil, 0x8000),
+}
+
+local fields = {}
+for _, f in pairs(pf) do
+	table.insert(fields, f)
+end
+local function register_layout(layout)
+	for _, f in ipairs(layout.fields) do
+		table.insert(fields, f.field)
+	end
+end
+for _, layout in pairs(messages) do
+	register_layout(layout)
+end
+for _, layouts in pairs(commands) do
+	for _, layout in ipairs(layouts) do
+		register_layout(layout)
+	end
+end
+register_layout(heartbeat)
+arke.fields = fields
+
+local can_id = Field.new("can.id")
+local can_rtr = Field.new("can.flags.rtr")
+local can_xtd = Field.new("can.flags.xtd")
+
+-- add_field decodes a little endian field of at most 64 bits starting
+-- at bit f.offset of the payload.
+local function add_field(tree, tvb, f)
+	local first = math.floor(f.offset / 8)
+	local last = math.floor((f.offset + f.size - 1) / 8)
+	local range = tvb(first, last - first + 1)
+	if f.kind == "fan" then
+		local item = tree:add_le(f.field, range)
+		item:add_le(pf.fan_rpm, range)
+		item:add_le(pf.fan_aging, range)
+		item:add_le(pf.fan_stalled, range)
+		return
+	end
+	local raw = range:le_uint64():rshift(f.offset % 8):band(UInt64.max():rshift(64 - f.size))
+	if f.kind == "uint64" then
+		tree:add(f.field, range, raw)
+		return
+	end
+	local value = raw:tonumber()
+	if f.signed and value >= 2 ^ (f.size - 1) then
+		value = value - 2 ^ f.size
+	end
+	if f.kind == "double" then
+		value = value * f.resolution + f.bias
+	end
+	tree:add(f.field, range, value)
+end
+
+local function add_payload(tree, tvb, layout)
+	if tvb:len() == 0 then
+		return
+	end
+	tree:add(pf.payload, tvb())
+	for _, f in ipairs(layout.fields) do
+		if f.offset + f.size <= tvb:len() * 8 then
+			add_field(tree, tvb, f)
+		end
+	end
+end
+
+-- node_of returns the class of the node handling a message class, i.e.
+-- the highest node class not greater than it.
+local function node_of(class)
+	local res = 0
+	for c, _ in pairs(node_classes) do
+		if c <= class and c > res then
+			res = c
+		end
+	end
+	return res
+end
+
+local function class_name(class)
+	return node_classes[class] or string.format("0x%02x", class)
+end
+
+local function message_name(class)
+	local layout = messages[class]
+	if layout == nil then
+		return string.format("Unknown 0x%02x", class)
+	end
+	return layout.name
+end
+
+local function dissect(tvb, pinfo, tree)
+	local id = can_id()
+	local xtd = can_xtd()
+	if id == nil or (xtd ~= nil and xtd.value) then
+		return false
+	end
+	local idt = id.value
+	local mtype = math.floor(idt / 512) % 4
+	local class = math.floor(idt / 8) % 64
+	local node = idt % 8
+	local rtr = can_rtr()
+
+	pinfo.cols.protocol = "Arke"
+	local subtree = tree:add(arke)
+	subtree:add(pf.type, mtype):set_generated()
+	subtree:add(pf.class, class):set_generated()
+	subtree:add(pf.id, node):set_generated()
+
+	local summary
+	if rtr ~= nil and rtr.value then
+		subtree:add(pf.request, true):set_generated()
+		subtree:add(pf.node, node_of(class)):set_generated()
+		subtree:add(pf.message, message_name(class)):set_generated()
+		local target = "all"
+		if node ~= 0 then
+			target = string.format("ID:%d", node)
+		end
+		summary = string.format("Request %s %s", message_name(class), target)
+	elseif mtype == 0 then
+		local layouts = commands[node]
+		local layout = { name = string.format("arke.Command%d", node), fields = {} }
+		if layouts ~= nil then
+			layout = layouts[#layouts]
+			for _, candidate in ipairs(layouts) do
+				if tvb:len() <= candidate.size then
+					layout = candidate
+					break
+				end
+			end
+		end
+		subtree:add(pf.node, class):set_generated()
+		subtree:add(pf.message, layout.name):set_generated()
+		add_payload(subtree, tvb, layout)
+		summary = string.format("%s %s", layout.name, class_name(class))
+	elseif mtype == 3 then
+		subtree:add(pf.node, class):set_generated()
+		subtree:add(pf.message, heartbeat.name):set_generated()
+		add_payload(subtree, tvb, heartbeat)
+		summary = string.format("%s %s ID:%d", heartbeat.name, class_name(class), node)
+	else
+		local layout = messages[class] or { name = message_name(class), fields = {} }
+		subtree:add(pf.node, node_of(class)):set_generated()
+		subtree:add(pf.message, layout.name):set_generated()
+		add_payload(subtree, tvb, layout)
+		summary = string.format("%s ID:%d", layout.name, node)
+		if mtype == 1 then
+			summary = summary .. " (high priority)"
+		end
+	end
+	subtree:append_text(", " .. summary)
+	pinfo.cols.info:set(summary)
+	return true
+end
+
+function arke.dissector(tvb, pinfo, tree)
+	if dissect(tvb, pinfo, tree) == false then
+		return 0
+	end
+	return tvb:len()
+end
+
+DissectorTable.get("can.subdissector"):add_for_decode_as(arke)
+arke:register_heuristic("can", dissect)
+`
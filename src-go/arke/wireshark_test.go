@@ -0,0 +1,66 @@
+package arke
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type WiresharkSuite struct{}
+
+var _ = Suite(&WiresharkSuite{})
+
+const wiresharkDissectorPath = "../../specs/arke.lua"
+
+func (s *WiresharkSuite) TestDissectorIsUpToDate(c *C) {
+	expected, err := os.ReadFile(wiresharkDissectorPath)
+	c.Assert(err, IsNil)
+	var b bytes.Buffer
+	c.Assert(WriteWiresharkDissector(&b), IsNil)
+	c.Check(b.String() == string(expected), Equals, true,
+		Commentf("%s is outdated, run go generate", wiresharkDissectorPath))
+}
+
+func (s *WiresharkSuite) TestLayouts(c *C) {
+	report := newWiresharkLayout("Zeus.Report", &ZeusReport{})
+	c.Check(report.size(), Equals, 8)
+	c.Assert(report.Fields, HasLen, 5)
+	c.Check(report.Fields[1].Abbrev, Equals, "arke.zeus.report.temperature_0")
+	c.Check(report.Fields[1].Kind, Equals, "double")
+	c.Check(report.Fields[1].BitOffset, Equals, 14)
+	c.Check(report.Fields[3].Signed, Equals, true)
+
+	testdata := []struct {
+		Layout wiresharkLayout
+		Index  int
+		Kind   string
+	}{
+		{newWiresharkLayout("Celaeno.Status", &CelaenoStatus{}), 1, "fan"},
+		{newWiresharkLayout("Zeus.Status", &ZeusStatus{}), 3, "fan"},
+		{newWiresharkLayout("Helios.TriggerMode", &HeliosTriggerMode{}), 2, "int"},
+		{newWiresharkLayout("arke.ErrorReport", &ErrorReportData{}), 0, "class"},
+		{newWiresharkLayout("arke.SynchronisationReply", &SynchronisationReplyData{}), 2, "uint64"},
+		{newWiresharkLayout("arke.HeartBeat", &HeartBeatData{}), 0, "uint"},
+	}
+	for _, d := range testdata {
+		comment := Commentf("%s field %d", d.Layout.Name, d.Index)
+		if c.Check(len(d.Layout.Fields) > d.Index, Equals, true, comment) {
+			c.Check(d.Layout.Fields[d.Index].Kind, Equals, d.Kind, comment)
+		}
+	}
+
+	c.Check(newWiresharkLayout("Zeus.VibrationReport", nil).Fields, HasLen, 0)
+}
+
+func (s *WiresharkSuite) TestIncludesRegisteredClasses(c *C) {
+	defer (&RegistrySuite{}).TearDownTest(c)
+	c.Assert(RegisterNodeClass(testNodeClass, testNodeClassName), IsNil)
+	c.Assert(RegisterMessage(testMessageClass, testMessageName, func() Message { return &hermesPing{} }), IsNil)
+
+	var b bytes.Buffer
+	c.Assert(WriteWiresharkDissector(&b), IsNil)
+	c.Check(strings.Contains(b.String(), "\t[0x10] = \"Hermes\",\n"), Equals, true)
+	c.Check(strings.Contains(b.String(), "\t\tname = \"Hermes.Ping\",\n\t\tsize = 0,\n"), Equals, true)
+}
@@ -4,21 +4,13 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 )
 
-type ZeusSetPoint struct {
-	Humidity    float32 `positional-arg-name:"humidity" required:"yes"`
-	Temperature float32 `positional-arg-name:"temperature" required:"yes"`
-	Wind        uint8   `positional-arg-name:"wind" required:"yes"`
-}
-
-func (m *ZeusSetPoint) MessageClassID() MessageClass {
-	return ZeusSetPointMessage
-}
-
 func checkSize(buf []byte, expected int) error {
 	if len(buf) < expected {
-		return fmt.Errorf("Invalid buffer size %d, required: %d", len(buf), expected)
+		return shortBufferError(len(buf), expected)
 	}
 	return nil
 }
@@ -27,6 +19,9 @@ func (m ZeusSetPoint) Marshal(buf []byte) (int, error) {
 	if err := checkSize(buf, 5); err != nil {
 		return 0, err
 	}
+	if err := m.Validate(); err != nil {
+		return 0, err
+	}
 	binary.LittleEndian.PutUint16(buf[0:], humidityFloatToBinary(m.Humidity))
 	binary.LittleEndian.PutUint16(buf[2:], hih6030TemperatureFloatToBinary(m.Temperature))
 	buf[4] = m.Wind
@@ -39,11 +34,11 @@ func (m *ZeusSetPoint) Unmarshal(buf []byte) error {
 	}
 	m.Humidity = humidityBinaryToFloat(binary.LittleEndian.Uint16(buf[0:]))
 	if math.IsNaN(float64(m.Humidity)) == true {
-		return fmt.Errorf("Invalid humidity value")
+		return sensorValueError{"Humidity"}
 	}
 	m.Temperature = hih6030TemperatureBinaryToFloat(binary.LittleEndian.Uint16(buf[2:]))
 	if math.IsNaN(float64(m.Temperature)) == true {
-		return fmt.Errorf("Invalid temperature value")
+		return sensorValueError{"Temperature"}
 	}
 	m.Wind = buf[4]
 	return nil
@@ -54,22 +49,16 @@ func (m *ZeusSetPoint) String() string {
 		m.Humidity, m.Temperature, m.Wind)
 }
 
-type ZeusReport struct {
-	Humidity    float32
-	Temperature [4]float32
-}
-
-func (m *ZeusReport) MessageClassID() MessageClass {
-	return ZeusReportMessage
-}
-
 func (m ZeusReport) Marshal(buf []byte) (int, error) {
 	if err := checkSize(buf, 8); err != nil {
 		return 0, err
 	}
-	packed := make([]uint16, 4)
+	if err := m.Validate(); err != nil {
+		return 0, err
+	}
+	var packed [4]uint16
 	binTemp := hih6030TemperatureFloatToBinary(m.Temperature[0])
-	auxs := []uint16{
+	auxs := [3]uint16{
 		tmp1075FloatToBinaray(m.Temperature[1]),
 		tmp1075FloatToBinaray(m.Temperature[2]),
 		tmp1075FloatToBinaray(m.Temperature[3]),
@@ -90,7 +79,7 @@ func (m *ZeusReport) Unmarshal(buf []byte) error {
 	if err := checkSize(buf, 8); err != nil {
 		return err
 	}
-	packed := []uint16{
+	packed := [4]uint16{
 		binary.LittleEndian.Uint16(buf[0:]),
 		binary.LittleEndian.Uint16(buf[2:]),
 		binary.LittleEndian.Uint16(buf[4:]),
@@ -98,12 +87,12 @@ func (m *ZeusReport) Unmarshal(buf []byte) error {
 	}
 	m.Humidity = humidityBinaryToFloat(packed[0] & 0x3fff)
 	if math.IsNaN(float64(m.Humidity)) == true {
-		return fmt.Errorf("Invalid humidity value")
+		return sensorValueError{"Humidity"}
 	}
 
 	m.Temperature[0] = hih6030TemperatureBinaryToFloat((packed[0] >> 14) | (packed[1]&0x0fff)<<2)
 	if math.IsNaN(float64(m.Temperature[0])) == true {
-		return fmt.Errorf("Invalid temperature value")
+		return sensorValueError{"Temperature"}
 	}
 	m.Temperature[1] = tmp1075BinaryToFloat((packed[1] >> 12) | (packed[2]&0x00ff)<<4)
 	m.Temperature[2] = tmp1075BinaryToFloat((packed[2] >> 8) | (packed[3]&0x000f)<<8)
@@ -116,24 +105,18 @@ func (m *ZeusReport) String() string {
 		m.Humidity, m.Temperature[0], m.Temperature[1], m.Temperature[2], m.Temperature[3])
 }
 
-type ZeusConfig struct {
-	Humidity    PDConfig
-	Temperature PDConfig
-}
-
 func (m *ZeusConfig) String() string {
 	return fmt.Sprintf("Zeus.Config{Humidity:%s, Temperature:%s}",
 		m.Humidity, m.Temperature)
 }
 
-func (m *ZeusConfig) MessageClassID() MessageClass {
-	return ZeusConfigMessage
-}
-
 func (m ZeusConfig) Marshal(buf []byte) (int, error) {
 	if err := checkSize(buf, 8); err != nil {
 		return 0, err
 	}
+	if err := m.Validate(); err != nil {
+		return 0, err
+	}
 	if err := m.Humidity.marshall(buf[0:]); err != nil {
 		return 0, err
 	}
@@ -162,11 +145,6 @@ const (
 	ZeusTemperatureUnreachable       ZeusStatusValue = 1 << 3
 )
 
-type ZeusStatus struct {
-	Status ZeusStatusValue
-	Fans   [3]FanStatusAndRPM
-}
-
 func (s ZeusStatusValue) String() string {
 	prefix := ""
 	if s&ZeusTemperatureUnreachable != 0 {
@@ -187,6 +165,41 @@ func (s ZeusStatusValue) String() string {
 	return prefix + "idle"
 }
 
+func (s ZeusStatusValue) MarshalText() ([]byte, error) {
+	res := s.String()
+	if unknown := s &^ 0x0f; unknown != 0 {
+		res += fmt.Sprintf("|0x%02x", uint8(unknown))
+	}
+	return []byte(res), nil
+}
+
+func (s *ZeusStatusValue) UnmarshalText(text []byte) error {
+	res := ZeusIdle
+	for _, flag := range strings.Split(string(text), "|") {
+		switch flag {
+		case "idle":
+		case "active":
+			res |= ZeusActive
+		case "sensor-issue":
+			res |= ZeusActive | ZeusClimateNotControlledWatchDog
+		case "climate-uncontrolled":
+			res |= ZeusClimateNotControlledWatchDog
+		case "humidity-unreachable":
+			res |= ZeusHumidityUnreachable
+		case "temperature-unreachable":
+			res |= ZeusTemperatureUnreachable
+		default:
+			v, err := strconv.ParseUint(flag, 0, 8)
+			if err != nil {
+				return fmt.Errorf("Invalid Zeus status '%s'", flag)
+			}
+			res |= ZeusStatusValue(v)
+		}
+	}
+	*s = res
+	return nil
+}
+
 func (s *ZeusStatus) String() string {
 	return fmt.Sprintf("Zeus.Status{General: %s, WindFan: %s, LeftFan: %s, RightFan: %s}",
 		s.Status,
@@ -196,14 +209,13 @@ func (s *ZeusStatus) String() string {
 	)
 }
 
-func (m *ZeusStatus) MessageClassID() MessageClass {
-	return ZeusStatusMessage
-}
-
 func (m ZeusStatus) Marshal(buf []byte) (int, error) {
 	if err := checkSize(buf, 7); err != nil {
 		return 0, err
 	}
+	if err := m.Validate(); err != nil {
+		return 0, err
+	}
 	buf[0] = byte(m.Status)
 	binary.LittleEndian.PutUint16(buf[1:], uint16(m.Fans[0]))
 	binary.LittleEndian.PutUint16(buf[3:], uint16(m.Fans[1]))
@@ -222,11 +234,6 @@ func (m *ZeusStatus) Unmarshal(buf []byte) error {
 	return nil
 }
 
-type ZeusControlPoint struct {
-	Humidity    int16
-	Temperature int16
-}
-
 func (m *ZeusControlPoint) String() string {
 	return fmt.Sprintf("Zeus.ControlPoint{Humidity: %d, Temperature: %d}",
 		m.Humidity,
@@ -234,14 +241,13 @@ func (m *ZeusControlPoint) String() string {
 	)
 }
 
-func (m *ZeusControlPoint) MessageClassID() MessageClass {
-	return ZeusControlPointMessage
-}
-
 func (m *ZeusControlPoint) Marshal(buf []byte) (int, error) {
 	if err := checkSize(buf, 4); err != nil {
 		return 0, err
 	}
+	if err := m.Validate(); err != nil {
+		return 0, err
+	}
 	binary.LittleEndian.PutUint16(buf[0:], uint16(m.Humidity))
 	binary.LittleEndian.PutUint16(buf[2:], uint16(m.Temperature))
 	return 4, nil
@@ -256,10 +262,6 @@ func (m *ZeusControlPoint) Unmarshal(buf []byte) error {
 	return nil
 }
 
-type ZeusDeltaTemperature struct {
-	Delta [4]float32
-}
-
 func (m *ZeusDeltaTemperature) String() string {
 	return fmt.Sprintf("Zeus.DeltaTemperature{Ants: %.4f°C, Aux1: %.4f°C, Aux2: %.4f°C, Aux3: %.4f°C}",
 		m.Delta[0],
@@ -269,14 +271,13 @@ func (m *ZeusDeltaTemperature) String() string {
 	)
 }
 
-func (m *ZeusDeltaTemperature) MessageClassID() MessageClass {
-	return ZeusDeltaTemperatureMessage
-}
-
 func (m *ZeusDeltaTemperature) Marshal(buf []byte) (int, error) {
 	if err := checkSize(buf, 8); err != nil {
 		return 0, err
 	}
+	if err := m.Validate(); err != nil {
+		return 0, err
+	}
 
 	binary.LittleEndian.PutUint16(buf[0:], uint16(int16(m.Delta[0]*float32(hih6030Max)/165.0)))
 	for i := 1; i < 4; i++ {
@@ -297,19 +298,3 @@ func (m *ZeusDeltaTemperature) Unmarshal(buf []byte) error {
 
 	return nil
 }
-
-func init() {
-	messageFactory[ZeusSetPointMessage] = func() Message { return &ZeusSetPoint{} }
-	messagesName[ZeusSetPointMessage] = "Zeus.SetPoint"
-	messageFactory[ZeusReportMessage] = func() Message { return &ZeusReport{} }
-	messagesName[ZeusReportMessage] = "Zeus.Report"
-	messageFactory[ZeusConfigMessage] = func() Message { return &ZeusConfig{} }
-	messagesName[ZeusConfigMessage] = "Zeus.Config"
-	messageFactory[ZeusStatusMessage] = func() Message { return &ZeusStatus{} }
-	messagesName[ZeusStatusMessage] = "Zeus.Status"
-	messageFactory[ZeusControlPointMessage] = func() Message { return &ZeusControlPoint{} }
-	messagesName[ZeusControlPointMessage] = "Zeus.ControlPoint"
-	messageFactory[ZeusDeltaTemperatureMessage] = func() Message { return &ZeusDeltaTemperature{} }
-	messagesName[ZeusDeltaTemperatureMessage] = "Zeus.DeltaTemperature"
-	messagesName[ZeusVibrationReportMessage] = "Zeus.VibrationReport"
-}
@@ -136,12 +136,12 @@ func (s *ZeusSuite) TestConfigIO(c *C) {
 		{
 			ZeusConfig{PDConfig{0, 0, 0, 16, 0}, PDConfig{}},
 			make([]byte, 8),
-			"Maximal Proportional&Derivative Divider is 15",
+			"Humidity.DividerPower 16 is out of range \\[0, 15\\]",
 		},
 		{
 			ZeusConfig{PDConfig{}, PDConfig{0, 0, 0, 0, 16}},
 			make([]byte, 8),
-			"Maximal Integral Divider is 15",
+			"Temperature.DividerPowerIntegral 16 is out of range \\[0, 15\\]",
 		},
 	}
 